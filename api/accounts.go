@@ -0,0 +1,73 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/bartick/golang-order-matching-system/engine"
+	"github.com/bartick/golang-order-matching-system/models"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	apiKeyHeader = "X-API-Key"
+	accountKey   = "account"
+)
+
+// AccountMiddleware authenticates requests carrying an X-API-Key header and
+// stores the account in the context; disabled accounts are refused. Requests without the header are let
+// through anonymously; endpoints that need an account check for one with
+// requestAccount. An IP address that sent too many unknown keys is refused
+// before its keys are looked up.
+func AccountMiddleware(eng *engine.Engine, limiter *RateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		apiKey := c.GetHeader(apiKeyHeader)
+		if apiKey == "" {
+			c.Next()
+			return
+		}
+
+		client := "ip:" + c.ClientIP()
+		if decision := limiter.peek(rateClassAuthentication, client); !decision.allowed {
+			rejectRateLimited(c, decision, "Too many requests: too many invalid API keys")
+			return
+		}
+
+		account, err := eng.GetAccountByAPIKey(apiKey)
+		if errors.Is(err, engine.ErrAccountNotFound) {
+			limiter.take(rateClassAuthentication, client)
+			respondError(c, http.StatusUnauthorized, CodeUnauthorized, "Invalid API key")
+			return
+		}
+		if err != nil {
+			respondInternalError(c, "Failed to authenticate request", err)
+			return
+		}
+		if !account.Enabled {
+			respondError(c, http.StatusForbidden, CodeForbidden, "Account is disabled")
+			return
+		}
+
+		c.Set(accountKey, account)
+		c.Next()
+	}
+}
+
+// requestAccount returns the account authenticated by AccountMiddleware, or
+// nil for anonymous requests.
+func requestAccount(c *gin.Context) *models.Account {
+	value, ok := c.Get(accountKey)
+	if !ok {
+		return nil
+	}
+	return value.(*models.Account)
+}
+
+// requestActor is who the order events of a request are attributed to: the
+// authenticated account, or else the anonymous HTTP client.
+func requestActor(c *gin.Context) string {
+	if account := requestAccount(c); account != nil {
+		return engine.AccountActor(account.ID)
+	}
+	return "http"
+}
@@ -0,0 +1,350 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/bartick/golang-order-matching-system/engine"
+	"github.com/bartick/golang-order-matching-system/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	adminKeyHeader  = "X-Admin-Key"
+	adminKey        = "admin"
+	auditDetailsKey = "audit_details"
+)
+
+type AccountEnabledRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+type OrderLimitsRequest = engine.OrderLimits
+
+// AdminMiddleware only lets through requests carrying the X-Admin-Key of
+// an administrator, and stores the administrator in the context.
+func AdminMiddleware(eng *engine.Engine) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(adminKeyHeader)
+		if key == "" {
+			respondError(c, http.StatusUnauthorized, CodeUnauthorized, "Admin API key required")
+			return
+		}
+
+		admin, err := eng.GetAdminByAPIKey(key)
+		if errors.Is(err, engine.ErrAdminNotFound) {
+			respondError(c, http.StatusUnauthorized, CodeUnauthorized, "Invalid admin API key")
+			return
+		}
+		if err != nil {
+			respondInternalError(c, "Failed to authenticate request", err)
+			return
+		}
+
+		c.Set(adminKey, admin)
+		c.Next()
+	}
+}
+
+// audited records the action in the admin audit log, whatever the
+// outcome. A pending entry is written before the action runs, which does
+// not run when it cannot be, and the status is filled in afterwards. The
+// response is held back until then: when the entry cannot be finished,
+// the request fails with an internal error instead and the entry stays
+// pending, so that no administrator is told an action succeeded without a
+// trace of it. Handlers describe what they were asked to do with
+// setAuditDetails.
+func audited(eng *engine.Engine, action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		admin := c.MustGet(adminKey).(*models.Admin)
+		entry := models.AdminAction{
+			AdminID:   admin.ID,
+			AdminName: admin.Name,
+			Action:    action,
+		}
+		if len(c.Params) > 0 {
+			target := c.Params[0].Value
+			if c.Params[0].Key == "symbol" {
+				target = strings.ToUpper(target)
+			}
+			entry.Target = &target
+		}
+		if id := requestID(c); id != "" {
+			entry.RequestID = &id
+		}
+		id, err := eng.StartAdminAction(entry)
+		if err != nil {
+			requestLogger(c).Error("admin action refused", "action", action, "admin", admin.Name, "target", entry.Target)
+			respondInternalError(c, "Failed to record admin action", err)
+			return
+		}
+
+		original := c.Writer
+		held := &heldResponse{ResponseWriter: original, status: original.Status()}
+		c.Writer = held
+		// A panicking handler is answered by the recovery middleware and
+		// leaves the entry pending
+		defer func() { c.Writer = original }()
+		c.Next()
+		c.Writer = original
+
+		if details, ok := c.Get(auditDetailsKey); ok {
+			data, err := json.Marshal(details)
+			if err == nil {
+				entry.Details = data
+			}
+		}
+		if err := eng.FinishAdminAction(id, held.status, entry.Details); err != nil {
+			requestLogger(c).Error("admin action not recorded", "id", id, "action", action, "admin", admin.Name,
+				"target", entry.Target, "details", string(entry.Details), "status", held.status)
+			respondInternalError(c, "Failed to record admin action", err)
+			return
+		}
+		requestLogger(c).Info("admin action", "action", action, "admin", admin.Name, "target", entry.Target, "status", held.status)
+		held.flush()
+	}
+}
+
+// heldResponse keeps the response of a handler until flush.
+type heldResponse struct {
+	gin.ResponseWriter
+	status  int
+	body    bytes.Buffer
+	written bool
+}
+
+func (w *heldResponse) WriteHeader(status int) {
+	if status > 0 && !w.written {
+		w.status = status
+	}
+}
+
+func (w *heldResponse) WriteHeaderNow() {
+	w.written = true
+}
+
+func (w *heldResponse) Write(data []byte) (int, error) {
+	w.written = true
+	return w.body.Write(data)
+}
+
+func (w *heldResponse) WriteString(s string) (int, error) {
+	w.written = true
+	return w.body.WriteString(s)
+}
+
+func (w *heldResponse) Status() int {
+	return w.status
+}
+
+func (w *heldResponse) Size() int {
+	if !w.written {
+		return -1
+	}
+	return w.body.Len()
+}
+
+func (w *heldResponse) Written() bool {
+	return w.written
+}
+
+// flush sends the response held back.
+func (w *heldResponse) flush() {
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.body.Len() == 0 {
+		w.ResponseWriter.WriteHeaderNow()
+		return
+	}
+	w.ResponseWriter.Write(w.body.Bytes())
+}
+
+func setAuditDetails(c *gin.Context, details any) {
+	c.Set(auditDetailsKey, details)
+}
+
+// AddAdminRoute registers the operator endpoints under /admin. Every one of
+// them needs an administrator key, and every change is recorded in the
+// admin audit log.
+func AddAdminRoute(r *gin.Engine, eng *engine.Engine) {
+	admin := r.Group("/admin", AdminMiddleware(eng))
+
+	admin.POST("/symbols/:symbol/halt", audited(eng, "halt_symbol"), func(c *gin.Context) {
+		setSymbolState(c, eng, engine.StateHalted)
+	})
+	admin.POST("/symbols/:symbol/resume", audited(eng, "resume_symbol"), func(c *gin.Context) {
+		setSymbolState(c, eng, engine.StateContinuous)
+	})
+	admin.POST("/symbols/:symbol/cancel-all", audited(eng, "cancel_symbol_orders"), func(c *gin.Context) {
+		adminCancelOrders(c, eng, engine.CancelFilter{Symbol: c.Param("symbol"), Side: c.Query("side")})
+	})
+	admin.POST("/accounts/:id/cancel-all", audited(eng, "cancel_account_orders"), func(c *gin.Context) {
+		accountID, ok := accountParam(c)
+		if !ok {
+			return
+		}
+		adminCancelOrders(c, eng, engine.CancelFilter{AccountID: &accountID, Symbol: c.Query("symbol"), Side: c.Query("side")})
+	})
+	admin.PUT("/accounts/:id/enabled", audited(eng, "set_account_enabled"), func(c *gin.Context) {
+		setAccountEnabled(c, eng)
+	})
+	admin.GET("/risk-limits", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"order_limits": eng.OrderLimits()})
+	})
+	admin.PUT("/risk-limits/:symbol", audited(eng, "set_risk_limits"), func(c *gin.Context) {
+		setRiskLimits(c, eng)
+	})
+	admin.DELETE("/risk-limits/:symbol", audited(eng, "reset_risk_limits"), func(c *gin.Context) {
+		symbol := strings.ToUpper(c.Param("symbol"))
+		limits, err := eng.ResetOrderLimit(symbol)
+		if err != nil {
+			respondInternalError(c, "Failed to reset risk limits", err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"symbol": symbol, "order_limits": limits})
+	})
+	admin.POST("/snapshot", audited(eng, "snapshot"), func(c *gin.Context) {
+		snapshot, err := eng.SnapshotOrderBooks()
+		if err != nil {
+			respondInternalError(c, "Failed to snapshot order books", err)
+			return
+		}
+		c.JSON(http.StatusOK, snapshot)
+	})
+	admin.GET("/internals", func(c *gin.Context) {
+		internals, err := eng.Internals()
+		if err != nil {
+			respondInternalError(c, "Failed to fetch engine internals", err)
+			return
+		}
+		c.JSON(http.StatusOK, internals)
+	})
+	admin.GET("/orders", func(c *gin.Context) {
+		latestOrders(c, eng, nil)
+	})
+	admin.GET("/orders/:id", func(c *gin.Context) {
+		orderStatus(c, eng, nil)
+	})
+	admin.GET("/orders/:id/history", func(c *gin.Context) {
+		orderHistory(c, eng, nil)
+	})
+	admin.GET("/audit", func(c *gin.Context) {
+		getAdminActions(c, eng)
+	})
+}
+
+func setSymbolState(c *gin.Context, eng *engine.Engine, state string) {
+	instrument, err := eng.SetInstrumentState(c.Param("symbol"), state, "")
+	var validationErr *engine.ValidationError
+	if errors.As(err, &validationErr) {
+		respondError(c, http.StatusBadRequest, CodeValidationFailed, validationErr.Error())
+		return
+	}
+	if err != nil {
+		respondInternalError(c, "Failed to change instrument state", err)
+		return
+	}
+	c.JSON(http.StatusOK, instrument)
+}
+
+func adminCancelOrders(c *gin.Context, eng *engine.Engine, filter engine.CancelFilter) {
+	setAuditDetails(c, gin.H{"symbol": strings.ToUpper(filter.Symbol), "side": filter.Side})
+	filter.Actor = engine.AdminActor(c.MustGet(adminKey).(*models.Admin).Name)
+
+	orders, err := eng.AdminCancelOrders(filter)
+	var validationErr *engine.ValidationError
+	if errors.As(err, &validationErr) {
+		respondError(c, http.StatusBadRequest, CodeValidationFailed, validationErr.Error())
+		return
+	}
+	if err != nil {
+		respondInternalError(c, "Failed to cancel orders", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("%d orders canceled", len(orders)), "orders": orders})
+}
+
+func setAccountEnabled(c *gin.Context, eng *engine.Engine) {
+	accountID, ok := accountParam(c)
+	if !ok {
+		return
+	}
+	var req AccountEnabledRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+	setAuditDetails(c, req)
+
+	account, err := eng.SetAccountEnabled(accountID, *req.Enabled)
+	if errors.Is(err, engine.ErrAccountNotFound) {
+		respondError(c, http.StatusNotFound, CodeNotFound, "Account not found")
+		return
+	}
+	if err != nil {
+		respondInternalError(c, "Failed to update account", err)
+		return
+	}
+	c.JSON(http.StatusOK, account)
+}
+
+func setRiskLimits(c *gin.Context, eng *engine.Engine) {
+	var req OrderLimitsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+	setAuditDetails(c, req)
+
+	symbol := strings.ToUpper(c.Param("symbol"))
+	err := eng.SetOrderLimit(symbol, req)
+	var validationErr *engine.ValidationError
+	if errors.As(err, &validationErr) {
+		respondError(c, http.StatusBadRequest, CodeValidationFailed, validationErr.Error())
+		return
+	}
+	if err != nil {
+		respondInternalError(c, "Failed to set risk limits", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"symbol": symbol, "order_limits": req})
+}
+
+func getAdminActions(c *gin.Context, eng *engine.Engine) {
+	var limit int
+	var before int64
+	var err error
+	if value := c.Query("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil {
+			respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid limit")
+			return
+		}
+	}
+	if value := c.Query("before"); value != "" {
+		if before, err = strconv.ParseInt(value, 10, 64); err != nil {
+			respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid before")
+			return
+		}
+	}
+
+	actions, err := eng.GetAdminActions(limit, before)
+	if err != nil {
+		respondInternalError(c, "Failed to fetch the audit log", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"actions": actions})
+}
+
+func accountParam(c *gin.Context) (uuid.UUID, bool) {
+	accountID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid account ID format")
+		return uuid.Nil, false
+	}
+	return accountID, true
+}
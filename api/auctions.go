@@ -0,0 +1,79 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/bartick/golang-order-matching-system/engine"
+	"github.com/gin-gonic/gin"
+)
+
+type StartAuctionRequest struct {
+	// Type is "opening" or "closing".
+	Type string `json:"type" binding:"required"`
+}
+
+// AddAuctionRoute registers the endpoints that show the call auction of a
+// symbol and let an administrator start and uncross the opening and closing
+// auctions, recorded in the admin audit log.
+func AddAuctionRoute(r *gin.Engine, eng *engine.Engine) {
+	admin := r.Group("", AdminMiddleware(eng))
+
+	admin.POST("/auctions/:symbol/start", audited(eng, "start_auction"), func(c *gin.Context) {
+		startAuction(c, eng)
+	})
+	admin.POST("/auctions/:symbol/uncross", audited(eng, "run_auction"), func(c *gin.Context) {
+		runAuction(c, eng)
+	})
+	r.GET("/auctions/:symbol", func(c *gin.Context) {
+		getAuctionState(c, eng)
+	})
+}
+
+func startAuction(c *gin.Context, eng *engine.Engine) {
+	var req StartAuctionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+	setAuditDetails(c, req)
+
+	state, err := eng.StartAuction(c.Param("symbol"), req.Type)
+	if err != nil {
+		auctionError(c, err, "Failed to start auction")
+		return
+	}
+	c.JSON(http.StatusOK, state)
+}
+
+func runAuction(c *gin.Context, eng *engine.Engine) {
+	result, err := eng.RunAuction(c.Param("symbol"))
+	if err != nil {
+		auctionError(c, err, "Failed to run auction")
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func getAuctionState(c *gin.Context, eng *engine.Engine) {
+	state, err := eng.GetAuctionState(c.Param("symbol"))
+	if err != nil {
+		auctionError(c, err, "Failed to fetch auction")
+		return
+	}
+	c.JSON(http.StatusOK, state)
+}
+
+func auctionError(c *gin.Context, err error, message string) {
+	var validationErr *engine.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		respondError(c, http.StatusBadRequest, CodeValidationFailed, validationErr.Error())
+	case errors.Is(err, engine.ErrAuctionInProgress):
+		respondError(c, http.StatusConflict, CodeConflict, "Symbol is already in an auction call phase")
+	case errors.Is(err, engine.ErrNoAuction):
+		respondError(c, http.StatusConflict, CodeConflict, "Symbol is not in an auction call phase")
+	default:
+		respondInternalError(c, message, err)
+	}
+}
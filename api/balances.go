@@ -0,0 +1,27 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/bartick/golang-order-matching-system/engine"
+	"github.com/gin-gonic/gin"
+)
+
+// AddBalanceRoute registers the endpoint that shows the asset balances of
+// the authenticated account, as moved by the settlement of its trades.
+func AddBalanceRoute(r *gin.Engine, eng *engine.Engine) {
+	r.GET("/balances", func(c *gin.Context) {
+		account := requestAccount(c)
+		if account == nil {
+			respondError(c, http.StatusUnauthorized, CodeUnauthorized, "Balances require an API key")
+			return
+		}
+
+		balances, err := eng.GetBalances(account.ID)
+		if err != nil {
+			respondInternalError(c, "Failed to fetch balances", err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"balances": balances})
+	})
+}
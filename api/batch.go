@@ -0,0 +1,162 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/bartick/golang-order-matching-system/engine"
+	"github.com/bartick/golang-order-matching-system/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type BatchOrderRequest struct {
+	Orders    []OrderRequest `json:"orders" binding:"required"`
+	AllOrNone bool           `json:"all_or_none"`
+}
+
+type BatchCancelRequest struct {
+	OrderIDs []string `json:"order_ids" binding:"required"`
+}
+
+// BatchResult is the outcome of one entry of a batch request, reported at
+// the same index as the entry.
+type BatchResult struct {
+	Index  int            `json:"index"`
+	Order  *models.Order  `json:"order,omitempty"`
+	Trades []models.Trade `json:"trades,omitempty"`
+	Error  string         `json:"error,omitempty"`
+}
+
+type BatchResponse struct {
+	Results []BatchResult `json:"results"`
+}
+
+func placeOrders(c *gin.Context, eng *engine.Engine) {
+	account := requestAccount(c)
+	if account == nil {
+		respondError(c, http.StatusUnauthorized, CodeUnauthorized, "Placing orders requires an API key")
+		return
+	}
+	var req BatchOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	for i := range req.Orders {
+		req.Orders[i].Source = "http"
+		req.Orders[i].RequestID = requestID(c)
+		req.Orders[i].AccountID = &account.ID
+	}
+
+	results, err := eng.PlaceOrders(c.Request.Context(), req.Orders, req.AllOrNone)
+	if errors.Is(err, engine.ErrBatchRejected) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Batch rejected", "code": CodeBatchRejected, "request_id": requestID(c), "results": batchResults(results),
+		})
+		return
+	}
+	var validationErr *engine.ValidationError
+	if errors.As(err, &validationErr) {
+		respondError(c, http.StatusBadRequest, CodeValidationFailed, validationErr.Error())
+		return
+	}
+	if err != nil {
+		respondInternalError(c, "Failed to place orders", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, BatchResponse{Results: batchResults(results)})
+}
+
+func cancelOrdersByID(c *gin.Context, eng *engine.Engine) {
+	account := requestAccount(c)
+	if account == nil {
+		respondError(c, http.StatusUnauthorized, CodeUnauthorized, "Canceling orders requires an API key")
+		return
+	}
+	var req BatchCancelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	orderIDs := make([]uuid.UUID, 0, len(req.OrderIDs))
+	for _, idStr := range req.OrderIDs {
+		orderID, err := uuid.Parse(idStr)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, CodeInvalidRequest, fmt.Sprintf("Invalid order ID format: %s", idStr))
+			return
+		}
+		orderIDs = append(orderIDs, orderID)
+	}
+
+	results, err := eng.CancelOrdersByID(orderIDs, &account.ID, requestActor(c))
+	var validationErr *engine.ValidationError
+	if errors.As(err, &validationErr) {
+		respondError(c, http.StatusBadRequest, CodeValidationFailed, validationErr.Error())
+		return
+	}
+	if err != nil {
+		respondInternalError(c, "Failed to cancel orders", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, BatchResponse{Results: batchResults(results)})
+}
+
+// cancelOrders mass cancels the open orders of the authenticated account,
+// optionally narrowed down to one symbol and/or side.
+func cancelOrders(c *gin.Context, eng *engine.Engine) {
+	account := requestAccount(c)
+	if account == nil {
+		respondError(c, http.StatusUnauthorized, CodeUnauthorized, "Mass cancel requires an API key")
+		return
+	}
+
+	orders, err := eng.CancelOrders(engine.CancelFilter{
+		AccountID: &account.ID,
+		Symbol:    c.Query("symbol"),
+		Side:      c.Query("side"),
+	})
+	var validationErr *engine.ValidationError
+	if errors.As(err, &validationErr) {
+		respondError(c, http.StatusBadRequest, CodeValidationFailed, validationErr.Error())
+		return
+	}
+	if err != nil {
+		respondInternalError(c, "Failed to cancel orders", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("%d orders canceled", len(orders)), "orders": orders})
+}
+
+func batchResults(results []engine.BatchResult) []BatchResult {
+	response := make([]BatchResult, len(results))
+	for i, result := range results {
+		response[i] = BatchResult{Index: i, Order: result.Order, Trades: result.Trades}
+		if result.Err != nil {
+			response[i].Error = batchError(result.Err)
+		}
+	}
+	return response
+}
+
+// batchError describes the failure of one batch entry without leaking
+// internal errors to the client.
+func batchError(err error) string {
+	var validationErr *engine.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		return validationErr.Error()
+	case errors.Is(err, engine.ErrOrderNotFound):
+		return "Order not found"
+	case errors.Is(err, engine.ErrOrderNotCancelable):
+		return "Cannot cancel filled or already canceled order"
+	default:
+		return "Internal error"
+	}
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/bartick/golang-order-matching-system/engine"
+	"github.com/gin-gonic/gin"
+)
+
+type CancelAllAfterRequest struct {
+	// TimeoutSeconds of 0 disarms the switch.
+	TimeoutSeconds *int `json:"timeout_seconds" binding:"required"`
+}
+
+// AddCancelAllAfterRoute registers the dead man's switch endpoint. Clients
+// call it periodically; if they stop, all open orders of their account are
+// canceled once the timeout expires.
+func AddCancelAllAfterRoute(r *gin.Engine, eng *engine.Engine) {
+	r.POST("/cancel-all-after", func(c *gin.Context) {
+		cancelAllAfter(c, eng)
+	})
+}
+
+func cancelAllAfter(c *gin.Context, eng *engine.Engine) {
+	account := requestAccount(c)
+	if account == nil {
+		respondError(c, http.StatusUnauthorized, CodeUnauthorized, "Cancel-all-after requires an API key")
+		return
+	}
+
+	var req CancelAllAfterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	timeout := time.Duration(*req.TimeoutSeconds) * time.Second
+	deadline, err := eng.CancelAllAfter(engine.CancelFilter{AccountID: &account.ID}, timeout)
+	var validationErr *engine.ValidationError
+	if errors.As(err, &validationErr) {
+		respondError(c, http.StatusBadRequest, CodeValidationFailed, validationErr.Error())
+		return
+	}
+	if err != nil {
+		respondInternalError(c, "Failed to arm cancel-all-after", err)
+		return
+	}
+
+	if deadline.IsZero() {
+		c.JSON(http.StatusOK, gin.H{"armed": false})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"armed": true, "cancel_at": deadline})
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Error codes returned in the "code" field of every error response. Unlike
+// the messages, they are stable and meant for clients to act upon.
+const (
+	CodeInvalidRequest   = "invalid_request"
+	CodeValidationFailed = "validation_failed"
+	CodeBatchRejected    = "batch_rejected"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeNotFound         = "not_found"
+	CodeConflict         = "conflict"
+	CodeRateLimited      = "rate_limited"
+	CodeInternal         = "internal_error"
+)
+
+// ErrorResponse is the body of every error response. RequestID matches the
+// X-Request-ID response header and the request_id of the server logs.
+type ErrorResponse struct {
+	Error     string `json:"error"`
+	Code      string `json:"code"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+func respondError(c *gin.Context, status int, code, message string) {
+	c.AbortWithStatusJSON(status, ErrorResponse{Error: message, Code: code, RequestID: requestID(c)})
+}
+
+// respondInternalError logs err with the request ID and answers with
+// message alone, so that database and other internal errors never reach the
+// client.
+func respondInternalError(c *gin.Context, message string, err error) {
+	requestLogger(c).Error(message, "error", err)
+	respondError(c, http.StatusInternalServerError, CodeInternal, message)
+}
+
+// RecoveryMiddleware turns a panicking handler into an internal error
+// response, logging the panic with the request ID.
+func RecoveryMiddleware() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered any) {
+		respondInternalError(c, "Internal error", fmt.Errorf("panic: %v", recovered))
+	})
+}
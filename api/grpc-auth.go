@@ -0,0 +1,109 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+
+	"github.com/bartick/golang-order-matching-system/engine"
+	"github.com/bartick/golang-order-matching-system/models"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// apiKeyMetadata carries the same API key as the X-API-Key header of the
+// HTTP API.
+const apiKeyMetadata = "x-api-key"
+
+type grpcAccountKey struct{}
+
+// UnaryAuthInterceptor authenticates calls carrying an x-api-key metadata
+// entry like AccountMiddleware does for HTTP: unknown keys are refused with
+// Unauthenticated and disabled accounts with PermissionDenied, and an
+// address that sent too many unknown keys with ResourceExhausted before its
+// keys are looked up. Calls without the entry go through anonymously;
+// methods that need an account check for one with grpcAccount.
+func UnaryAuthInterceptor(eng *engine.Engine, limiter *RateLimiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := authenticate(ctx, eng, limiter)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamAuthInterceptor is the streaming counterpart of
+// UnaryAuthInterceptor.
+func StreamAuthInterceptor(eng *engine.Engine, limiter *RateLimiter) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(stream.Context(), eng, limiter)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authenticatedStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// authenticatedStream hands the authenticated context to the handler.
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// authenticate returns ctx with the account of the API key in the metadata,
+// or ctx itself when the call has no API key.
+func authenticate(ctx context.Context, eng *engine.Engine, limiter *RateLimiter) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	keys := md.Get(apiKeyMetadata)
+	if len(keys) == 0 || keys[0] == "" {
+		return ctx, nil
+	}
+
+	client := "ip:" + peerIP(ctx)
+	if decision := limiter.peek(rateClassAuthentication, client); !decision.allowed {
+		return nil, status.Error(codes.ResourceExhausted, "too many invalid API keys")
+	}
+
+	account, err := eng.GetAccountByAPIKey(keys[0])
+	if errors.Is(err, engine.ErrAccountNotFound) {
+		limiter.take(rateClassAuthentication, client)
+		return nil, status.Error(codes.Unauthenticated, "invalid API key")
+	}
+	if err != nil {
+		slog.Error("grpc authentication failed", "error", err)
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+	if !account.Enabled {
+		return nil, status.Error(codes.PermissionDenied, "account is disabled")
+	}
+	return context.WithValue(ctx, grpcAccountKey{}, account), nil
+}
+
+// grpcAccount returns the account authenticated by the interceptors, or nil
+// for anonymous calls.
+func grpcAccount(ctx context.Context) *models.Account {
+	account, _ := ctx.Value(grpcAccountKey{}).(*models.Account)
+	return account
+}
+
+// peerIP returns the address of the client of a call, without its port.
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	internalDb "github.com/bartick/golang-order-matching-system/db"
+	"github.com/bartick/golang-order-matching-system/engine"
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+// Lifecycle phases of the service, as reported by /readyz.
+const (
+	PhaseStarting = "starting"
+	PhaseReady    = "ready"
+	PhaseStopping = "stopping"
+)
+
+const (
+	componentOK      = "ok"
+	componentPending = "pending"
+	componentFailed  = "failed"
+)
+
+// readinessTimeout bounds the database checks of a readiness probe.
+const readinessTimeout = 2 * time.Second
+
+// Health tracks the lifecycle of the service for the readiness probe. It
+// starts out starting, becomes ready once the books are loaded and every
+// server is up, and stops being ready as soon as a graceful shutdown
+// begins.
+type Health struct {
+	db  *sqlx.DB
+	eng *engine.Engine
+
+	mu          sync.RWMutex
+	phase       string
+	books       int
+	booksLoaded bool
+}
+
+func NewHealth(db *sqlx.DB, eng *engine.Engine) *Health {
+	return &Health{db: db, eng: eng, phase: PhaseStarting}
+}
+
+// BooksLoaded records that startup loaded the given number of books.
+func (h *Health) BooksLoaded(count int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.books = count
+	h.booksLoaded = true
+}
+
+func (h *Health) SetPhase(phase string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.phase = phase
+}
+
+// ComponentStatus is the state of one dependency of the service.
+type ComponentStatus struct {
+	Status  string         `json:"status"`
+	Message string         `json:"message,omitempty"`
+	Details map[string]any `json:"details,omitempty"`
+}
+
+type ReadinessResponse struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentStatus `json:"components"`
+}
+
+func AddHealthRoute(r *gin.Engine, health *Health) {
+	// The process is alive as long as it answers
+	r.GET("/healthz", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "alive"})
+	})
+
+	r.GET("/readyz", func(c *gin.Context) {
+		response, ready := health.check(c)
+		status := http.StatusOK
+		if !ready {
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, response)
+	})
+}
+
+// check reports every component; the service is ready when all of them
+// are ok outside of startup and shutdown.
+func (h *Health) check(c *gin.Context) (ReadinessResponse, bool) {
+	h.mu.RLock()
+	phase, books, booksLoaded := h.phase, h.books, h.booksLoaded
+	h.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	components := map[string]ComponentStatus{
+		"database":   h.checkDatabase(ctx, c),
+		"migrations": h.checkMigrations(ctx, c),
+		"books":      checkBooks(books, booksLoaded),
+		"monitors":   h.checkMonitors(),
+	}
+
+	ready := phase == PhaseReady
+	for _, component := range components {
+		if component.Status != componentOK {
+			ready = false
+		}
+	}
+	status := phase
+	if phase == PhaseReady && !ready {
+		status = "unavailable"
+	}
+	return ReadinessResponse{Status: status, Components: components}, ready
+}
+
+func (h *Health) checkDatabase(ctx context.Context, c *gin.Context) ComponentStatus {
+	start := time.Now()
+	if err := h.db.PingContext(ctx); err != nil {
+		requestLogger(c).Warn("readiness: database unreachable", "error", err)
+		return ComponentStatus{Status: componentFailed, Message: "Database unreachable"}
+	}
+	return ComponentStatus{
+		Status:  componentOK,
+		Details: map[string]any{"latency_ms": float64(time.Since(start).Microseconds()) / 1000},
+	}
+}
+
+func (h *Health) checkMigrations(ctx context.Context, c *gin.Context) ComponentStatus {
+	version, err := internalDb.CurrentSchemaVersion(ctx, h.db)
+	if err != nil {
+		requestLogger(c).Warn("readiness: failed to read the schema version", "error", err)
+		return ComponentStatus{Status: componentFailed, Message: "Schema version unavailable"}
+	}
+
+	details := map[string]any{"version": version, "required": internalDb.SchemaVersion}
+	if version < internalDb.SchemaVersion {
+		return ComponentStatus{
+			Status:  componentFailed,
+			Message: fmt.Sprintf("Database is at migration %d, m%02d.sql is required", version, internalDb.SchemaVersion),
+			Details: details,
+		}
+	}
+	return ComponentStatus{Status: componentOK, Details: details}
+}
+
+func checkBooks(books int, loaded bool) ComponentStatus {
+	if !loaded {
+		return ComponentStatus{Status: componentPending, Message: "Books are being loaded"}
+	}
+	return ComponentStatus{Status: componentOK, Details: map[string]any{"loaded": books}}
+}
+
+func (h *Health) checkMonitors() ComponentStatus {
+	monitors := h.eng.Monitors()
+	details := make(map[string]any, len(monitors))
+	status := ComponentStatus{Status: componentOK, Details: details}
+	for _, monitor := range monitors {
+		details[monitor.Name] = monitor
+		if !monitor.Responsive {
+			status.Status = componentFailed
+			status.Message = "A background monitor is not responding"
+		}
+	}
+	return status
+}
@@ -0,0 +1,297 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/bartick/golang-order-matching-system/engine"
+	"github.com/gin-gonic/gin"
+)
+
+type InstrumentStateRequest struct {
+	State string `json:"state" binding:"required"`
+	// Auction is "opening" or "closing", for the "auction" state only.
+	Auction string `json:"auction"`
+}
+
+type PriceBandRequest struct {
+	// Percent is the width of the band around the last trade price; 0
+	// disables the circuit breaker and null restores the default.
+	Percent     *float64 `json:"percent"`
+	HaltSeconds *int     `json:"halt_seconds"`
+}
+
+type MatchingAlgorithmRequest struct {
+	Algorithm     string   `json:"algorithm" binding:"required"`
+	MinAllocation *float64 `json:"min_allocation"`
+}
+
+type PairRequest struct {
+	BaseAsset         string `json:"base_asset" binding:"required"`
+	QuoteAsset        string `json:"quote_asset" binding:"required"`
+	PricePrecision    *int   `json:"price_precision" binding:"required"`
+	QuantityPrecision *int   `json:"quantity_precision" binding:"required"`
+}
+
+type FutureRequest struct {
+	ContractMultiplier float64 `json:"contract_multiplier" binding:"required"`
+	// The dates are formatted as YYYY-MM-DD.
+	ExpiryDate              string `json:"expiry_date" binding:"required"`
+	LastTradingDay          string `json:"last_trading_day" binding:"required"`
+	SettlementWindowMinutes *int   `json:"settlement_window_minutes"`
+}
+
+type MarginRequest struct {
+	// Both null stop trading the future on margin.
+	InitialMarginPercent     *float64 `json:"initial_margin_percent"`
+	MaintenanceMarginPercent *float64 `json:"maintenance_margin_percent"`
+}
+
+type SettlementRequest struct {
+	// Date is the trading day to settle, formatted as YYYY-MM-DD; empty
+	// settles today.
+	Date string `json:"date"`
+}
+
+// AddInstrumentRoute registers the endpoints that show the definition and
+// trading state of a symbol, its halts and its settlement prices, and let
+// an administrator define its pair or futures contract and its margin,
+// override the trading schedule, configure the circuit breaker and matching
+// algorithm and settle a future. The changes need an administrator key and
+// are recorded in the admin audit log.
+func AddInstrumentRoute(r *gin.Engine, eng *engine.Engine) {
+	admin := r.Group("", AdminMiddleware(eng))
+
+	r.GET("/instruments/:symbol", func(c *gin.Context) {
+		instrument, err := eng.GetInstrument(c.Param("symbol"))
+		if err != nil {
+			respondInternalError(c, "Failed to fetch instrument", err)
+			return
+		}
+		c.JSON(http.StatusOK, instrument)
+	})
+	admin.PUT("/instruments/:symbol/pair", audited(eng, "set_pair"), func(c *gin.Context) {
+		setPair(c, eng)
+	})
+	admin.PUT("/instruments/:symbol/future", audited(eng, "set_future"), func(c *gin.Context) {
+		setFuture(c, eng)
+	})
+	admin.PUT("/instruments/:symbol/margin", audited(eng, "set_margin"), func(c *gin.Context) {
+		setMargin(c, eng)
+	})
+	admin.PUT("/instruments/:symbol/state", audited(eng, "set_instrument_state"), func(c *gin.Context) {
+		setInstrumentState(c, eng)
+	})
+	admin.PUT("/instruments/:symbol/price-band", audited(eng, "set_price_band"), func(c *gin.Context) {
+		setPriceBand(c, eng)
+	})
+	admin.PUT("/instruments/:symbol/matching", audited(eng, "set_matching_algorithm"), func(c *gin.Context) {
+		setMatchingAlgorithm(c, eng)
+	})
+	r.GET("/instruments/:symbol/halts", func(c *gin.Context) {
+		halts, err := eng.GetTradingHalts(c.Param("symbol"))
+		if err != nil {
+			respondInternalError(c, "Failed to fetch trading halts", err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"halts": halts})
+	})
+	r.GET("/instruments/:symbol/settlements", func(c *gin.Context) {
+		settlements, err := eng.GetSettlementPrices(c.Param("symbol"))
+		if err != nil {
+			respondInternalError(c, "Failed to fetch settlement prices", err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"settlements": settlements})
+	})
+	admin.POST("/instruments/:symbol/settlements", audited(eng, "settle_future"), func(c *gin.Context) {
+		settleFuture(c, eng)
+	})
+	admin.DELETE("/instruments/:symbol/override", audited(eng, "release_instrument"), func(c *gin.Context) {
+		instrument, err := eng.ReleaseInstrument(c.Param("symbol"))
+		if err != nil {
+			respondInternalError(c, "Failed to release instrument", err)
+			return
+		}
+		c.JSON(http.StatusOK, instrument)
+	})
+}
+
+func setPair(c *gin.Context, eng *engine.Engine) {
+	var req PairRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+	setAuditDetails(c, req)
+
+	instrument, err := eng.SetPair(c.Param("symbol"), req.BaseAsset, req.QuoteAsset, *req.PricePrecision, *req.QuantityPrecision)
+	var validationErr *engine.ValidationError
+	if errors.As(err, &validationErr) {
+		respondError(c, http.StatusBadRequest, CodeValidationFailed, validationErr.Error())
+		return
+	}
+	if err != nil {
+		respondInternalError(c, "Failed to define pair", err)
+		return
+	}
+	c.JSON(http.StatusOK, instrument)
+}
+
+func setFuture(c *gin.Context, eng *engine.Engine) {
+	var req FutureRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+	setAuditDetails(c, req)
+	expiryDate, err := time.Parse(time.DateOnly, req.ExpiryDate)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid expiry_date, expected YYYY-MM-DD")
+		return
+	}
+	lastTradingDay, err := time.Parse(time.DateOnly, req.LastTradingDay)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid last_trading_day, expected YYYY-MM-DD")
+		return
+	}
+
+	instrument, err := eng.SetFuture(c.Param("symbol"), req.ContractMultiplier, expiryDate, lastTradingDay, req.SettlementWindowMinutes)
+	var validationErr *engine.ValidationError
+	if errors.As(err, &validationErr) {
+		respondError(c, http.StatusBadRequest, CodeValidationFailed, validationErr.Error())
+		return
+	}
+	if err != nil {
+		respondInternalError(c, "Failed to define future", err)
+		return
+	}
+	c.JSON(http.StatusOK, instrument)
+}
+
+func setMargin(c *gin.Context, eng *engine.Engine) {
+	var req MarginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+	setAuditDetails(c, req)
+
+	instrument, err := eng.SetMargin(c.Param("symbol"), req.InitialMarginPercent, req.MaintenanceMarginPercent)
+	if errors.Is(err, engine.ErrNotAFuture) {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Only futures trade on margin")
+		return
+	}
+	var validationErr *engine.ValidationError
+	if errors.As(err, &validationErr) {
+		respondError(c, http.StatusBadRequest, CodeValidationFailed, validationErr.Error())
+		return
+	}
+	if err != nil {
+		respondInternalError(c, "Failed to change margin", err)
+		return
+	}
+	c.JSON(http.StatusOK, instrument)
+}
+
+func settleFuture(c *gin.Context, eng *engine.Engine) {
+	var req SettlementRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+			return
+		}
+	}
+	setAuditDetails(c, req)
+	day := time.Now()
+	if req.Date != "" {
+		var err error
+		if day, err = time.Parse(time.DateOnly, req.Date); err != nil {
+			respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid date, expected YYYY-MM-DD")
+			return
+		}
+	}
+
+	settlement, err := eng.SettleFuture(c.Param("symbol"), day)
+	if errors.Is(err, engine.ErrNotAFuture) {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Symbol is not a future")
+		return
+	}
+	if errors.Is(err, engine.ErrNoSettlementPrice) {
+		respondError(c, http.StatusConflict, CodeConflict, "No trades to compute a settlement price from")
+		return
+	}
+	if err != nil {
+		respondInternalError(c, "Failed to settle future", err)
+		return
+	}
+	c.JSON(http.StatusOK, settlement)
+}
+
+func setInstrumentState(c *gin.Context, eng *engine.Engine) {
+	var req InstrumentStateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+	setAuditDetails(c, req)
+
+	instrument, err := eng.SetInstrumentState(c.Param("symbol"), req.State, req.Auction)
+	var validationErr *engine.ValidationError
+	if errors.As(err, &validationErr) {
+		respondError(c, http.StatusBadRequest, CodeValidationFailed, validationErr.Error())
+		return
+	}
+	if err != nil {
+		respondInternalError(c, "Failed to change instrument state", err)
+		return
+	}
+	c.JSON(http.StatusOK, instrument)
+}
+
+func setPriceBand(c *gin.Context, eng *engine.Engine) {
+	var req PriceBandRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+	setAuditDetails(c, req)
+
+	instrument, err := eng.SetPriceBand(c.Param("symbol"), req.Percent, req.HaltSeconds)
+	var validationErr *engine.ValidationError
+	if errors.As(err, &validationErr) {
+		respondError(c, http.StatusBadRequest, CodeValidationFailed, validationErr.Error())
+		return
+	}
+	if err != nil {
+		respondInternalError(c, "Failed to change price band", err)
+		return
+	}
+	c.JSON(http.StatusOK, instrument)
+}
+
+func setMatchingAlgorithm(c *gin.Context, eng *engine.Engine) {
+	var req MatchingAlgorithmRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+	setAuditDetails(c, req)
+	minAllocation := 1.0
+	if req.MinAllocation != nil {
+		minAllocation = *req.MinAllocation
+	}
+
+	instrument, err := eng.SetMatchingAlgorithm(c.Param("symbol"), req.Algorithm, minAllocation)
+	var validationErr *engine.ValidationError
+	if errors.As(err, &validationErr) {
+		respondError(c, http.StatusBadRequest, CodeValidationFailed, validationErr.Error())
+		return
+	}
+	if err != nil {
+		respondInternalError(c, "Failed to change matching algorithm", err)
+		return
+	}
+	c.JSON(http.StatusOK, instrument)
+}
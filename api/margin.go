@@ -0,0 +1,62 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/bartick/golang-order-matching-system/engine"
+	"github.com/gin-gonic/gin"
+)
+
+type InsuranceDepositRequest struct {
+	Asset  string  `json:"asset" binding:"required"`
+	Amount float64 `json:"amount" binding:"required"`
+}
+
+// AddMarginRoute registers the endpoints that show the margin state of the
+// authenticated account and the insurance fund, and let an administrator
+// pay into the fund, as recorded in the admin audit log.
+func AddMarginRoute(r *gin.Engine, eng *engine.Engine) {
+	r.GET("/margin", func(c *gin.Context) {
+		account := requestAccount(c)
+		if account == nil {
+			respondError(c, http.StatusUnauthorized, CodeUnauthorized, "Margin requires an API key")
+			return
+		}
+
+		margins, err := eng.GetMargins(account.ID)
+		if err != nil {
+			respondInternalError(c, "Failed to fetch margin", err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"margins": margins})
+	})
+	r.GET("/insurance-fund", func(c *gin.Context) {
+		balances, entries, err := eng.GetInsuranceFund()
+		if err != nil {
+			respondInternalError(c, "Failed to fetch insurance fund", err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"balances": balances, "entries": entries})
+	})
+	r.POST("/insurance-fund/deposits", AdminMiddleware(eng), audited(eng, "deposit_insurance_fund"), func(c *gin.Context) {
+		var req InsuranceDepositRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+			return
+		}
+		setAuditDetails(c, req)
+
+		entry, err := eng.DepositInsuranceFund(req.Asset, req.Amount)
+		var validationErr *engine.ValidationError
+		if errors.As(err, &validationErr) {
+			respondError(c, http.StatusBadRequest, CodeValidationFailed, validationErr.Error())
+			return
+		}
+		if err != nil {
+			respondInternalError(c, "Failed to deposit into insurance fund", err)
+			return
+		}
+		c.JSON(http.StatusCreated, entry)
+	})
+}
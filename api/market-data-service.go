@@ -0,0 +1,216 @@
+package api
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/bartick/golang-order-matching-system/engine"
+	"github.com/bartick/golang-order-matching-system/models"
+	"github.com/bartick/golang-order-matching-system/proto/omspb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type marketDataService struct {
+	omspb.UnimplementedMarketDataServiceServer
+	eng *engine.Engine
+}
+
+func RegisterMarketDataService(s *grpc.Server, eng *engine.Engine) {
+	omspb.RegisterMarketDataServiceServer(s, &marketDataService{eng: eng})
+}
+
+func (s *marketDataService) StreamTrades(req *omspb.StreamTradesRequest, stream grpc.ServerStreamingServer[omspb.Trade]) error {
+	symbol := strings.ToUpper(req.GetSymbol())
+	if symbol == "" {
+		return status.Error(codes.InvalidArgument, "symbol is required")
+	}
+
+	sub := s.eng.MarketData().Subscribe(symbol)
+	defer s.eng.MarketData().Unsubscribe(sub)
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event, ok := <-sub.Events:
+			if !ok {
+				return status.Error(codes.ResourceExhausted, "subscriber fell too far behind")
+			}
+			if event.Trade == nil {
+				continue
+			}
+			if err := stream.Send(tradeToProto(event.Trade)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *marketDataService) StreamOrderBook(req *omspb.StreamOrderBookRequest, stream grpc.ServerStreamingServer[omspb.OrderBook]) error {
+	symbol := strings.ToUpper(req.GetSymbol())
+	if symbol == "" {
+		return status.Error(codes.InvalidArgument, "symbol is required")
+	}
+
+	// Subscribe before taking the snapshot so that no change is missed
+	// between the two.
+	sub := s.eng.MarketData().Subscribe(symbol)
+	defer s.eng.MarketData().Unsubscribe(sub)
+
+	orderBook, err := s.eng.GetOrderBook(symbol)
+	if err != nil {
+		return status.Error(codes.Internal, "failed to load order book")
+	}
+	if err := stream.Send(orderBookToProto(orderBook)); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event, ok := <-sub.Events:
+			if !ok {
+				return status.Error(codes.ResourceExhausted, "subscriber fell too far behind")
+			}
+			if event.OrderBook == nil {
+				continue
+			}
+			if err := stream.Send(orderBookToProto(event.OrderBook)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *marketDataService) StreamAuction(req *omspb.StreamAuctionRequest, stream grpc.ServerStreamingServer[omspb.AuctionState]) error {
+	symbol := strings.ToUpper(req.GetSymbol())
+	if symbol == "" {
+		return status.Error(codes.InvalidArgument, "symbol is required")
+	}
+
+	sub := s.eng.MarketData().Subscribe(symbol)
+	defer s.eng.MarketData().Unsubscribe(sub)
+
+	// Send the current state first if an auction is already running
+	state, err := s.eng.GetAuctionState(symbol)
+	switch {
+	case err == nil:
+		if err := stream.Send(auctionStateToProto(state)); err != nil {
+			return err
+		}
+	case !errors.Is(err, engine.ErrNoAuction):
+		return status.Error(codes.Internal, "failed to load auction")
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event, ok := <-sub.Events:
+			if !ok {
+				return status.Error(codes.ResourceExhausted, "subscriber fell too far behind")
+			}
+			if event.Auction == nil {
+				continue
+			}
+			if err := stream.Send(auctionStateToProto(event.Auction)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *marketDataService) StreamInstrument(req *omspb.StreamInstrumentRequest, stream grpc.ServerStreamingServer[omspb.Instrument]) error {
+	symbol := strings.ToUpper(req.GetSymbol())
+	if symbol == "" {
+		return status.Error(codes.InvalidArgument, "symbol is required")
+	}
+
+	sub := s.eng.MarketData().Subscribe(symbol)
+	defer s.eng.MarketData().Unsubscribe(sub)
+
+	instrument, err := s.eng.GetInstrument(symbol)
+	if err != nil {
+		return status.Error(codes.Internal, "failed to load instrument")
+	}
+	if err := stream.Send(instrumentToProto(instrument)); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event, ok := <-sub.Events:
+			if !ok {
+				return status.Error(codes.ResourceExhausted, "subscriber fell too far behind")
+			}
+			if event.Instrument == nil {
+				continue
+			}
+			if err := stream.Send(instrumentToProto(event.Instrument)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func instrumentToProto(instrument *models.Instrument) *omspb.Instrument {
+	result := &omspb.Instrument{
+		Symbol:             instrument.Symbol,
+		State:              instrument.State,
+		Auction:            instrument.Auction,
+		Manual:             instrument.Manual,
+		BaseAsset:          instrument.BaseAsset,
+		QuoteAsset:         instrument.QuoteAsset,
+		PricePrecision:     int32(instrument.PricePrecision),
+		QuantityPrecision:  int32(instrument.QuantityPrecision),
+		Type:               instrument.Type,
+		ContractMultiplier: instrument.ContractMultiplier,
+	}
+	if !instrument.UpdatedAt.IsZero() {
+		result.UpdatedAt = timestamppb.New(instrument.UpdatedAt)
+	}
+	if instrument.ExpiryDate != nil {
+		result.ExpiryDate = timestamppb.New(*instrument.ExpiryDate)
+	}
+	if instrument.LastTradingDay != nil {
+		result.LastTradingDay = timestamppb.New(*instrument.LastTradingDay)
+	}
+	return result
+}
+
+func auctionStateToProto(state *models.AuctionState) *omspb.AuctionState {
+	return &omspb.AuctionState{
+		Symbol:           state.Symbol,
+		Auction:          state.Auction,
+		IndicativePrice:  state.Price,
+		IndicativeVolume: state.Volume,
+		Imbalance:        state.Imbalance,
+		ImbalanceSide:    state.ImbalanceSide,
+	}
+}
+
+func orderBookToProto(orderBook *models.OrderBook) *omspb.OrderBook {
+	return &omspb.OrderBook{
+		Symbol: orderBook.Symbol,
+		Bids:   levelsToProto(orderBook.Bids),
+		Asks:   levelsToProto(orderBook.Asks),
+	}
+}
+
+func levelsToProto(levels []models.OrderBookLevel) []*omspb.OrderBookLevel {
+	result := make([]*omspb.OrderBookLevel, 0, len(levels))
+	for _, level := range levels {
+		result = append(result, &omspb.OrderBookLevel{
+			Price:         level.Price,
+			TotalQuantity: level.TotalQuantity,
+			OrderCount:    int64(level.OrderCount),
+		})
+	}
+	return result
+}
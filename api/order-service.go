@@ -0,0 +1,222 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/bartick/golang-order-matching-system/engine"
+	"github.com/bartick/golang-order-matching-system/models"
+	"github.com/bartick/golang-order-matching-system/proto/omspb"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type orderService struct {
+	omspb.UnimplementedOrderServiceServer
+	eng *engine.Engine
+}
+
+func RegisterOrderService(s *grpc.Server, eng *engine.Engine) {
+	omspb.RegisterOrderServiceServer(s, &orderService{eng: eng})
+}
+
+func (s *orderService) PlaceOrder(ctx context.Context, req *omspb.PlaceOrderRequest) (*omspb.PlaceOrderResponse, error) {
+	account := grpcAccount(ctx)
+	if account == nil {
+		return nil, status.Error(codes.Unauthenticated, "placing orders requires an API key")
+	}
+
+	orderReq := engine.OrderRequest{
+		Symbol:   req.GetSymbol(),
+		Side:     req.GetSide(),
+		Type:     req.GetType(),
+		Price:    req.Price,
+		Quantity: req.GetQuantity(),
+		Source:   "grpc",
+
+		PostOnly:        req.GetPostOnly(),
+		PostOnlyReprice: req.GetPostOnlyReprice(),
+		Hidden:          req.GetHidden(),
+
+		StopPrice:    req.StopPrice,
+		TrailAmount:  req.TrailAmount,
+		TrailPercent: req.TrailPercent,
+
+		PegReference:  req.GetPegReference(),
+		PegOffset:     req.PegOffset,
+		PegLimitPrice: req.PegLimitPrice,
+
+		DisplayQuantity: req.DisplayQuantity,
+		AccountID:       &account.ID,
+	}
+	order, trades, err := s.eng.PlaceOrder(ctx, orderReq)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	return &omspb.PlaceOrderResponse{
+		Order:  orderToProto(order),
+		Trades: tradesToProto(trades),
+	}, nil
+}
+
+func (s *orderService) CancelOrder(ctx context.Context, req *omspb.CancelOrderRequest) (*omspb.CancelOrderResponse, error) {
+	orderID, err := uuid.Parse(req.GetOrderId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid order ID format")
+	}
+
+	account := grpcAccount(ctx)
+	if account == nil {
+		return nil, status.Error(codes.Unauthenticated, "canceling orders requires an API key")
+	}
+
+	order, err := s.eng.CancelOrder(orderID, &account.ID, engine.AccountActor(account.ID))
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	return &omspb.CancelOrderResponse{Order: orderToProto(order)}, nil
+}
+
+func (s *orderService) AmendOrder(ctx context.Context, req *omspb.AmendOrderRequest) (*omspb.AmendOrderResponse, error) {
+	orderID, err := uuid.Parse(req.GetOrderId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid order ID format")
+	}
+
+	account := grpcAccount(ctx)
+	if account == nil {
+		return nil, status.Error(codes.Unauthenticated, "amending orders requires an API key")
+	}
+
+	amend := engine.AmendRequest{
+		Price:         req.Price,
+		Quantity:      req.Quantity,
+		ClientOrderID: req.GetClientOrderId(),
+		AccountID:     &account.ID,
+		Actor:         engine.AccountActor(account.ID),
+	}
+
+	order, trades, err := s.eng.AmendOrder(ctx, orderID, amend)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	return &omspb.AmendOrderResponse{
+		Order:  orderToProto(order),
+		Trades: tradesToProto(trades),
+	}, nil
+}
+
+func (s *orderService) GetOrder(ctx context.Context, req *omspb.GetOrderRequest) (*omspb.GetOrderResponse, error) {
+	orderID, err := uuid.Parse(req.GetOrderId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid order ID format")
+	}
+
+	account := grpcAccount(ctx)
+	if account == nil {
+		return nil, status.Error(codes.Unauthenticated, "reading orders requires an API key")
+	}
+
+	order, err := s.eng.GetOrder(orderID, &account.ID)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	return &omspb.GetOrderResponse{Order: orderToProto(order)}, nil
+}
+
+func (s *orderService) ListOrders(ctx context.Context, req *omspb.ListOrdersRequest) (*omspb.ListOrdersResponse, error) {
+	account := grpcAccount(ctx)
+	if account == nil {
+		return nil, status.Error(codes.Unauthenticated, "reading orders requires an API key")
+	}
+
+	orders, err := s.eng.ListOrders(engine.OrderFilter{
+		Symbol:    req.GetSymbol(),
+		Side:      req.GetSide(),
+		Status:    req.GetStatus(),
+		Limit:     int(req.GetLimit()),
+		AccountID: &account.ID,
+	})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	response := &omspb.ListOrdersResponse{Orders: make([]*omspb.Order, 0, len(orders))}
+	for i := range orders {
+		response.Orders = append(response.Orders, orderToProto(&orders[i]))
+	}
+	return response, nil
+}
+
+// grpcError maps engine errors onto gRPC status codes. Internal errors are
+// logged but not described to the client.
+func grpcError(err error) error {
+	var validationErr *engine.ValidationError
+	switch {
+	case errors.Is(err, engine.ErrOrderToTradeRatio):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.As(err, &validationErr):
+		return status.Error(codes.InvalidArgument, validationErr.Error())
+	case errors.Is(err, engine.ErrOrderNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, engine.ErrOrderNotCancelable), errors.Is(err, engine.ErrOrderNotAmendable):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		slog.Error("grpc request failed", "error", err)
+		return status.Error(codes.Internal, "internal error")
+	}
+}
+
+func orderToProto(order *models.Order) *omspb.Order {
+	return &omspb.Order{
+		Id:                order.ID.String(),
+		Symbol:            order.Symbol,
+		Side:              order.Side,
+		Type:              order.Type,
+		Price:             order.Price,
+		InitialQuantity:   order.InitialQuantity,
+		RemainingQuantity: order.RemainingQuantity,
+		Status:            order.Status,
+		CreatedAt:         timestamppb.New(order.CreatedAt),
+		UpdatedAt:         timestamppb.New(order.UpdatedAt),
+		PostOnly:          order.PostOnly,
+		Hidden:            order.Hidden,
+		StopPrice:         order.StopPrice,
+		TrailAmount:       order.TrailAmount,
+		TrailPercent:      order.TrailPercent,
+		PegReference:      order.PegReference,
+		PegOffset:         order.PegOffset,
+		PegLimitPrice:     order.PegLimitPrice,
+		DisplayQuantity:   order.DisplayQuantity,
+		VisibleQuantity:   order.VisibleQuantity,
+		Liquidation:       order.Liquidation,
+	}
+}
+
+func tradeToProto(trade *models.Trade) *omspb.Trade {
+	return &omspb.Trade{
+		Id:          trade.ID.String(),
+		BuyOrderId:  trade.BuyOrderID.String(),
+		SellOrderId: trade.SellOrderID.String(),
+		Symbol:      trade.Symbol,
+		Price:       trade.Price,
+		Quantity:    trade.Quantity,
+		ExecutedAt:  timestamppb.New(trade.ExecutedAt),
+	}
+}
+
+func tradesToProto(trades []models.Trade) []*omspb.Trade {
+	result := make([]*omspb.Trade, 0, len(trades))
+	for i := range trades {
+		result = append(result, tradeToProto(&trades[i]))
+	}
+	return result
+}
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/bartick/golang-order-matching-system/engine"
+	"github.com/gin-gonic/gin"
+)
+
+// AddPositionRoute registers the endpoints that show the positions and PnL
+// of the authenticated account.
+func AddPositionRoute(r *gin.Engine, eng *engine.Engine) {
+	r.GET("/positions", func(c *gin.Context) {
+		account := requestAccount(c)
+		if account == nil {
+			respondError(c, http.StatusUnauthorized, CodeUnauthorized, "Positions require an API key")
+			return
+		}
+
+		positions, err := eng.GetPositions(account.ID)
+		if err != nil {
+			respondInternalError(c, "Failed to fetch positions", err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"positions": positions})
+	})
+	r.GET("/positions/:symbol", func(c *gin.Context) {
+		account := requestAccount(c)
+		if account == nil {
+			respondError(c, http.StatusUnauthorized, CodeUnauthorized, "Positions require an API key")
+			return
+		}
+
+		position, err := eng.GetPosition(account.ID, c.Param("symbol"))
+		if errors.Is(err, engine.ErrPositionNotFound) {
+			respondError(c, http.StatusNotFound, CodeNotFound, "Position not found")
+			return
+		}
+		if err != nil {
+			respondInternalError(c, "Failed to fetch position", err)
+			return
+		}
+		c.JSON(http.StatusOK, position)
+	})
+}
@@ -0,0 +1,203 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bartick/golang-order-matching-system/internals"
+	"github.com/gin-gonic/gin"
+)
+
+// Request classes limited by separate buckets.
+const (
+	rateClassOrders     = "orders"
+	rateClassCancels    = "cancels"
+	rateClassMarketData = "market_data"
+
+	// rateClassAuthentication counts the requests with an unknown API key
+	// of each IP address.
+	rateClassAuthentication = "authentication"
+)
+
+// rateClasses maps the limited routes to their class; the other routes are
+// not limited.
+var rateClasses = map[string]string{
+	"POST /orders":                         rateClassOrders,
+	"POST /orders/batch":                   rateClassOrders,
+	"PATCH /orders/:id":                    rateClassOrders,
+	"POST /orders/cancel":                  rateClassCancels,
+	"DELETE /orders":                       rateClassCancels,
+	"DELETE /orders/:id":                   rateClassCancels,
+	"POST /cancel-all-after":               rateClassCancels,
+	"GET /orderbook":                       rateClassMarketData,
+	"GET /trades":                          rateClassMarketData,
+	"GET /instruments/:symbol":             rateClassMarketData,
+	"GET /instruments/:symbol/halts":       rateClassMarketData,
+	"GET /instruments/:symbol/settlements": rateClassMarketData,
+	"GET /auctions/:symbol":                rateClassMarketData,
+}
+
+// rateSweepInterval is how often buckets that refilled completely, and so
+// behave like new ones, are dropped.
+const rateSweepInterval = time.Minute
+
+type bucketKey struct {
+	class  string
+	client string
+}
+
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// RateLimiter holds a token bucket per client and request class. Clients are
+// told apart by their account, or by their IP address when anonymous.
+type RateLimiter struct {
+	mu        sync.Mutex
+	config    internals.RateLimitConfig
+	buckets   map[bucketKey]*tokenBucket
+	lastSweep time.Time
+}
+
+func NewRateLimiter(config internals.RateLimitConfig) *RateLimiter {
+	return &RateLimiter{
+		config:    config,
+		buckets:   make(map[bucketKey]*tokenBucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// SetConfig changes the limits, such as on a configuration reload. Clients
+// keep the tokens they have, up to the new bursts.
+func (l *RateLimiter) SetConfig(config internals.RateLimitConfig) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.config = config
+}
+
+// rateDecision is the outcome of taking a token.
+type rateDecision struct {
+	enabled    bool
+	allowed    bool
+	limit      int
+	remaining  int
+	retryAfter time.Duration
+}
+
+// take takes a token from the bucket of the client for the class.
+func (l *RateLimiter) take(class, client string) rateDecision {
+	return l.use(class, client, true)
+}
+
+// peek tells whether the client has a token left for the class, without
+// taking it.
+func (l *RateLimiter) peek(class, client string) rateDecision {
+	return l.use(class, client, false)
+}
+
+func (l *RateLimiter) use(class, client string, take bool) rateDecision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.config.Enabled {
+		return rateDecision{allowed: true}
+	}
+
+	config := l.bucketConfig(class)
+	now := time.Now()
+	if now.Sub(l.lastSweep) >= rateSweepInterval {
+		l.sweep(now)
+	}
+
+	key := bucketKey{class: class, client: client}
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: float64(config.Burst), updated: now}
+		l.buckets[key] = bucket
+	}
+	bucket.tokens = math.Min(float64(config.Burst), bucket.tokens+now.Sub(bucket.updated).Seconds()*config.Rate)
+	bucket.updated = now
+
+	decision := rateDecision{enabled: true, limit: config.Burst}
+	if bucket.tokens >= 1 {
+		if take {
+			bucket.tokens--
+		}
+		decision.allowed = true
+	} else {
+		decision.retryAfter = time.Duration((1 - bucket.tokens) / config.Rate * float64(time.Second))
+	}
+	decision.remaining = int(bucket.tokens)
+	return decision
+}
+
+func (l *RateLimiter) bucketConfig(class string) internals.BucketConfig {
+	switch class {
+	case rateClassOrders:
+		return l.config.Orders
+	case rateClassCancels:
+		return l.config.Cancels
+	case rateClassAuthentication:
+		return l.config.Authentication
+	default:
+		return l.config.MarketData
+	}
+}
+
+// sweep drops the buckets that are full again, since a new bucket starts
+// full.
+func (l *RateLimiter) sweep(now time.Time) {
+	for key, bucket := range l.buckets {
+		config := l.bucketConfig(key.class)
+		if bucket.tokens+now.Sub(bucket.updated).Seconds()*config.Rate >= float64(config.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+// RateLimitMiddleware limits the order entry, cancel and market data
+// requests of every client. It must come after AccountMiddleware so that
+// authenticated clients are limited by account rather than by address.
+// AccountMiddleware limits the requests with unknown API keys itself.
+// Limited responses carry X-RateLimit-Limit and X-RateLimit-Remaining, and
+// rejected ones a Retry-After in seconds.
+func RateLimitMiddleware(limiter *RateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		class, ok := rateClasses[c.Request.Method+" "+c.FullPath()]
+		if !ok {
+			c.Next()
+			return
+		}
+
+		client := "ip:" + c.ClientIP()
+		if account := requestAccount(c); account != nil {
+			client = "account:" + account.ID.String()
+		}
+
+		decision := limiter.take(class, client)
+		if !decision.enabled {
+			c.Next()
+			return
+		}
+		c.Header("X-RateLimit-Limit", strconv.Itoa(decision.limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(decision.remaining))
+		if !decision.allowed {
+			rejectRateLimited(c, decision, fmt.Sprintf("Too many requests: %s rate limit exceeded", strings.ReplaceAll(class, "_", " ")))
+			return
+		}
+		c.Next()
+	}
+}
+
+// rejectRateLimited answers a request refused for lack of a token.
+func rejectRateLimited(c *gin.Context, decision rateDecision, message string) {
+	retryAfter := int(math.Ceil(decision.retryAfter.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+	respondError(c, http.StatusTooManyRequests, CodeRateLimited, message)
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	requestIDHeader = "X-Request-ID"
+	requestIDKey    = "request_id"
+)
+
+// RequestIDMiddleware gives every request an ID, taken from the X-Request-ID
+// header when the client sent one, echoes it in the response and logs the
+// request once it is served.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestIDHeader)
+		if id == "" || len(id) > 128 {
+			id = uuid.NewString()
+		}
+		c.Set(requestIDKey, id)
+		c.Header(requestIDHeader, id)
+
+		start := time.Now()
+		c.Next()
+
+		level := slog.LevelInfo
+		if c.Writer.Status() >= 500 {
+			level = slog.LevelError
+		}
+		requestLogger(c).Log(c.Request.Context(), level, "request served",
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"route", c.FullPath(),
+			"status", c.Writer.Status(),
+			"duration_ms", float64(time.Since(start).Microseconds())/1000,
+			"client_ip", c.ClientIP(),
+		)
+	}
+}
+
+// requestID returns the ID RequestIDMiddleware gave the request.
+func requestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
+
+// requestLogger returns the default logger with the request ID attached,
+// and the trace ID once TracingMiddleware started a recorded trace.
+func requestLogger(c *gin.Context) *slog.Logger {
+	logger := slog.With("request_id", requestID(c))
+	if span := trace.SpanContextFromContext(c.Request.Context()); span.IsSampled() {
+		logger = logger.With("trace_id", span.TraceID().String())
+	}
+	return logger
+}
@@ -1,7 +1,6 @@
 package api
 
 import (
-	"fmt"
 	"net/http"
 	"strings"
 
@@ -33,7 +32,7 @@ func AddTradeRoute(r *gin.Engine, db *sqlx.DB) {
 	r.GET("/trades", func(c *gin.Context) {
 		symbol := strings.ToUpper(c.Query("symbol"))
 		if symbol == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Symbol parameter is required"})
+			respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Symbol parameter is required")
 			return
 		}
 
@@ -42,7 +41,7 @@ func AddTradeRoute(r *gin.Engine, db *sqlx.DB) {
 
 		rows, err := db.Query(query, symbol)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to fetch trades: %v", err)})
+			respondInternalError(c, "Failed to fetch trades", err)
 			return
 		}
 		defer rows.Close()
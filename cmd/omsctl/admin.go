@@ -0,0 +1,323 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bartick/golang-order-matching-system/models"
+	"github.com/google/uuid"
+)
+
+type orderLimits struct {
+	MaxQuantity float64 `json:"max_quantity"`
+	MaxNotional float64 `json:"max_notional"`
+}
+
+type snapshotResponse struct {
+	TakenAt   time.Time        `json:"taken_at"`
+	Levels    int              `json:"levels"`
+	Sequences map[string]int64 `json:"sequences"`
+}
+
+type internalsResponse struct {
+	Symbols []struct {
+		Symbol          string `json:"symbol"`
+		LastSequence    int64  `json:"last_sequence"`
+		RestingBids     int    `json:"resting_bids"`
+		RestingAsks     int    `json:"resting_asks"`
+		PendingOrders   int    `json:"pending_orders"`
+		Subscribers     int    `json:"subscribers"`
+		MarketDataQueue int    `json:"market_data_queue"`
+	} `json:"symbols"`
+	Monitors []struct {
+		Name       string    `json:"name"`
+		LastRound  time.Time `json:"last_round"`
+		Responsive bool      `json:"responsive"`
+	} `json:"monitors"`
+	DeadMansSwitches int `json:"dead_mans_switches"`
+	MaxBatchSize     int `json:"max_batch_size"`
+	Database         struct {
+		OpenConnections int `json:"open_connections"`
+		InUse           int `json:"in_use"`
+	} `json:"database"`
+}
+
+func adminCommand(c *cli, args []string) error {
+	return subcommands{
+		"halt":              func(c *cli, args []string) error { return setSymbolState(c, args, "halt") },
+		"resume":            func(c *cli, args []string) error { return setSymbolState(c, args, "resume") },
+		"cancel-all":        adminCancelOrders,
+		"enable-account":    func(c *cli, args []string) error { return setAccountEnabled(c, args, true) },
+		"disable-account":   func(c *cli, args []string) error { return setAccountEnabled(c, args, false) },
+		"risk-limits":       getRiskLimits,
+		"set-risk-limits":   setRiskLimits,
+		"reset-risk-limits": resetRiskLimits,
+		"snapshot":          takeSnapshot,
+		"internals":         showInternals,
+		"audit":             showAudit,
+		"order":             adminOrder,
+		"orders":            adminOrders,
+		"order-history":     adminOrderHistory,
+	}.run(c, "admin", args)
+}
+
+// setSymbolState halts or resumes a symbol.
+func setSymbolState(c *cli, args []string, action string) error {
+	flags := c.flags("admin "+action, "SYMBOL")
+	args, err := c.parse(flags, args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	var instrument models.Instrument
+	path := "/admin/symbols/" + url.PathEscape(strings.ToUpper(args[0])) + "/" + action
+	if err := c.client.do(c.ctx, http.MethodPost, path, nil, nil, &instrument); err != nil {
+		return err
+	}
+	return c.render(instrument, instrumentTable(&instrument))
+}
+
+func adminCancelOrders(c *cli, args []string) error {
+	flags := c.flags("admin cancel-all", "-symbol SYMBOL | -account ACCOUNT_ID [-symbol SYMBOL] [-side buy|sell]")
+	symbol := flags.String("symbol", "", "cancel the orders of this symbol")
+	account := flags.String("account", "", "cancel the orders of this account")
+	side := flags.String("side", "", "only the orders of this side")
+	if _, err := c.parse(flags, args, 0, 0); err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	setQuery(query, "side", *side)
+	var path string
+	switch {
+	case *account != "":
+		if _, err := uuid.Parse(*account); err != nil {
+			return usageErrorf("invalid account ID %q", *account)
+		}
+		path = "/admin/accounts/" + *account + "/cancel-all"
+		setQuery(query, "symbol", *symbol)
+	case *symbol != "":
+		path = "/admin/symbols/" + url.PathEscape(strings.ToUpper(*symbol)) + "/cancel-all"
+	default:
+		return usageErrorf("admin cancel-all needs -symbol or -account")
+	}
+
+	var resp cancelResponse
+	if err := c.client.do(c.ctx, http.MethodPost, path, query, nil, &resp); err != nil {
+		return err
+	}
+	return c.render(resp, ordersTable(resp.Orders))
+}
+
+func setAccountEnabled(c *cli, args []string, enabled bool) error {
+	name := "admin disable-account"
+	if enabled {
+		name = "admin enable-account"
+	}
+	flags := c.flags(name, "ACCOUNT_ID")
+	args, err := c.parse(flags, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	if _, err := uuid.Parse(args[0]); err != nil {
+		return usageErrorf("invalid account ID %q", args[0])
+	}
+
+	var account models.Account
+	body := map[string]bool{"enabled": enabled}
+	if err := c.client.do(c.ctx, http.MethodPut, "/admin/accounts/"+args[0]+"/enabled", nil, body, &account); err != nil {
+		return err
+	}
+	t := table{header: []string{"ID", "NAME", "ENABLED", "CREATED"}}
+	t.add(account.ID.String(), account.Name, strconv.FormatBool(account.Enabled), formatTime(account.CreatedAt))
+	return c.render(account, t)
+}
+
+func getRiskLimits(c *cli, args []string) error {
+	flags := c.flags("admin risk-limits", "")
+	if _, err := c.parse(flags, args, 0, 0); err != nil {
+		return err
+	}
+
+	var resp struct {
+		OrderLimits map[string]orderLimits `json:"order_limits"`
+	}
+	if err := c.client.do(c.ctx, http.MethodGet, "/admin/risk-limits", nil, nil, &resp); err != nil {
+		return err
+	}
+	symbols := make([]string, 0, len(resp.OrderLimits))
+	for symbol := range resp.OrderLimits {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	t := table{header: []string{"SYMBOL", "MAX QUANTITY", "MAX NOTIONAL"}}
+	for _, symbol := range symbols {
+		limits := resp.OrderLimits[symbol]
+		t.add(symbol, formatLimit(limits.MaxQuantity), formatLimit(limits.MaxNotional))
+	}
+	return c.render(resp, t)
+}
+
+func setRiskLimits(c *cli, args []string) error {
+	var limits orderLimits
+	flags := c.flags("admin set-risk-limits", "[-max-quantity QUANTITY] [-max-notional NOTIONAL] SYMBOL")
+	flags.Float64Var(&limits.MaxQuantity, "max-quantity", 0, "largest quantity of an order, 0 for unlimited")
+	flags.Float64Var(&limits.MaxNotional, "max-notional", 0, "largest notional of an order, 0 for unlimited")
+	args, err := c.parse(flags, args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	var resp struct {
+		Symbol      string      `json:"symbol"`
+		OrderLimits orderLimits `json:"order_limits"`
+	}
+	path := "/admin/risk-limits/" + url.PathEscape(strings.ToUpper(args[0]))
+	if err := c.client.do(c.ctx, http.MethodPut, path, nil, limits, &resp); err != nil {
+		return err
+	}
+	t := table{header: []string{"SYMBOL", "MAX QUANTITY", "MAX NOTIONAL"}}
+	t.add(resp.Symbol, formatLimit(resp.OrderLimits.MaxQuantity), formatLimit(resp.OrderLimits.MaxNotional))
+	return c.render(resp, t)
+}
+
+// resetRiskLimits gives a symbol its configured order limits back.
+func resetRiskLimits(c *cli, args []string) error {
+	flags := c.flags("admin reset-risk-limits", "SYMBOL")
+	args, err := c.parse(flags, args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	var resp struct {
+		Symbol      string       `json:"symbol"`
+		OrderLimits *orderLimits `json:"order_limits"`
+	}
+	path := "/admin/risk-limits/" + url.PathEscape(strings.ToUpper(args[0]))
+	if err := c.client.do(c.ctx, http.MethodDelete, path, nil, nil, &resp); err != nil {
+		return err
+	}
+	limits := orderLimits{}
+	if resp.OrderLimits != nil {
+		limits = *resp.OrderLimits
+	}
+	t := table{header: []string{"SYMBOL", "MAX QUANTITY", "MAX NOTIONAL"}}
+	t.add(resp.Symbol, formatLimit(limits.MaxQuantity), formatLimit(limits.MaxNotional))
+	return c.render(resp, t)
+}
+
+func formatLimit(limit float64) string {
+	if limit == 0 {
+		return "unlimited"
+	}
+	return formatFloat(limit)
+}
+
+func adminOrder(c *cli, args []string) error {
+	return showOrder(c, args, "admin order", "/admin/orders/")
+}
+
+func adminOrders(c *cli, args []string) error {
+	return showOrders(c, args, "admin orders", "/admin/orders")
+}
+
+func adminOrderHistory(c *cli, args []string) error {
+	return showOrderHistory(c, args, "admin order-history", "/admin/orders/")
+}
+
+func takeSnapshot(c *cli, args []string) error {
+	flags := c.flags("admin snapshot", "")
+	if _, err := c.parse(flags, args, 0, 0); err != nil {
+		return err
+	}
+
+	var snapshot snapshotResponse
+	if err := c.client.do(c.ctx, http.MethodPost, "/admin/snapshot", nil, nil, &snapshot); err != nil {
+		return err
+	}
+	symbols := make([]string, 0, len(snapshot.Sequences))
+	for symbol := range snapshot.Sequences {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	summary := table{header: []string{"TAKEN AT", "LEVELS"}}
+	summary.add(formatTime(snapshot.TakenAt), strconv.Itoa(snapshot.Levels))
+	sequences := table{header: []string{"SYMBOL", "SEQUENCE"}}
+	for _, symbol := range symbols {
+		sequences.add(symbol, formatInt(snapshot.Sequences[symbol]))
+	}
+	return c.render(snapshot, summary, sequences)
+}
+
+func showInternals(c *cli, args []string) error {
+	flags := c.flags("admin internals", "")
+	if _, err := c.parse(flags, args, 0, 0); err != nil {
+		return err
+	}
+
+	// The JSON output is the full response, not just what the tables show
+	var raw json.RawMessage
+	if err := c.client.do(c.ctx, http.MethodGet, "/admin/internals", nil, nil, &raw); err != nil {
+		return err
+	}
+	var internals internalsResponse
+	if err := json.Unmarshal(raw, &internals); err != nil {
+		return err
+	}
+
+	symbols := table{header: []string{"SYMBOL", "SEQUENCE", "BIDS", "ASKS", "PENDING", "SUBSCRIBERS", "QUEUED"}}
+	for _, symbol := range internals.Symbols {
+		symbols.add(symbol.Symbol, formatInt(symbol.LastSequence), strconv.Itoa(symbol.RestingBids), strconv.Itoa(symbol.RestingAsks),
+			strconv.Itoa(symbol.PendingOrders), strconv.Itoa(symbol.Subscribers), strconv.Itoa(symbol.MarketDataQueue))
+	}
+	monitors := table{header: []string{"MONITOR", "LAST ROUND", "RESPONSIVE"}}
+	for _, monitor := range internals.Monitors {
+		monitors.add(monitor.Name, formatTime(monitor.LastRound), strconv.FormatBool(monitor.Responsive))
+	}
+	engine := table{header: []string{"DEAD MAN'S SWITCHES", "MAX BATCH SIZE", "DB CONNECTIONS", "DB IN USE"}}
+	engine.add(strconv.Itoa(internals.DeadMansSwitches), strconv.Itoa(internals.MaxBatchSize),
+		strconv.Itoa(internals.Database.OpenConnections), strconv.Itoa(internals.Database.InUse))
+	return c.render(raw, symbols, monitors, engine)
+}
+
+func showAudit(c *cli, args []string) error {
+	flags := c.flags("admin audit", "[-limit COUNT] [-before ID]")
+	limit := flags.Int("limit", 50, "entries shown, latest first")
+	before := flags.Int64("before", 0, "only the entries older than this ID")
+	if _, err := c.parse(flags, args, 0, 0); err != nil {
+		return err
+	}
+
+	query := url.Values{"limit": {strconv.Itoa(*limit)}}
+	if *before > 0 {
+		query.Set("before", formatInt(*before))
+	}
+	var resp struct {
+		Actions []models.AdminAction `json:"actions"`
+	}
+	if err := c.client.do(c.ctx, http.MethodGet, "/admin/audit", query, nil, &resp); err != nil {
+		return err
+	}
+
+	t := table{header: []string{"ID", "TIME", "ADMIN", "ACTION", "TARGET", "STATUS", "DETAILS"}}
+	for _, action := range resp.Actions {
+		details := "-"
+		if len(action.Details) > 0 {
+			details = string(action.Details)
+		}
+		status := "pending"
+		if action.Status != nil {
+			status = strconv.Itoa(*action.Status)
+		}
+		t.add(formatInt(action.ID), formatTime(action.CreatedAt), action.AdminName, action.Action,
+			formatOptional(action.Target, formatString), status, details)
+	}
+	return c.render(resp, t)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const requestTimeout = 30 * time.Second
+
+// client calls the HTTP API of one environment.
+type client struct {
+	server   string
+	apiKey   string
+	adminKey string
+	http     *http.Client
+}
+
+func newClient(profile Profile) *client {
+	return &client{
+		server:   strings.TrimRight(profile.Server, "/"),
+		apiKey:   profile.APIKey,
+		adminKey: profile.AdminKey,
+		http:     &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// apiError is an error response of the API.
+type apiError struct {
+	Status     int
+	Message    string `json:"error"`
+	Code       string `json:"code"`
+	RequestID  string `json:"request_id"`
+	RetryAfter string
+}
+
+func (e *apiError) Error() string {
+	message := fmt.Sprintf("%s (%d %s", e.Message, e.Status, e.Code)
+	if e.RequestID != "" {
+		message += ", request " + e.RequestID
+	}
+	if e.RetryAfter != "" {
+		message += ", retry after " + e.RetryAfter + "s"
+	}
+	return message + ")"
+}
+
+// do sends a request with body, if not nil, as JSON and decodes the
+// response into out, if not nil. The admin key is only sent to the admin
+// API.
+func (c *client) do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	target := c.server + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+	if strings.HasPrefix(path, "/admin/") {
+		if c.adminKey == "" {
+			return fmt.Errorf("the admin API needs an admin key: set admin_key in the profile, OMSCTL_ADMIN_KEY or -admin-key")
+		}
+		req.Header.Set("X-Admin-Key", c.adminKey)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		apiErr := &apiError{Status: resp.StatusCode, RetryAfter: resp.Header.Get("Retry-After")}
+		if err := json.NewDecoder(resp.Body).Decode(apiErr); err != nil || apiErr.Message == "" {
+			apiErr.Message = http.StatusText(resp.StatusCode)
+		}
+		return apiErr
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode the response of %s %s: %w", method, path, err)
+	}
+	return nil
+}
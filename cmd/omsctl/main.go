@@ -0,0 +1,223 @@
+// omsctl is a command line client for the HTTP API of the order matching
+// system. It places and manages orders, watches the order book and the
+// trades of a symbol, and drives the admin API, against the environments
+// kept as profiles in its configuration file.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+)
+
+const usage = `usage: omsctl [flags] <command> [subcommand] [flags] [arguments]
+
+Commands:
+  orders place|cancel|amend|get|list|history   manage orders
+  book <symbol>                                show the order book, -watch to keep it refreshed
+  trades <symbol>                              show the latest trades, -follow to tail them
+  instrument <symbol>                          show the trading state of a symbol
+  admin <subcommand>                           drive the admin API
+  profile list|show|set|use|delete             manage the profiles of the configuration file
+
+Flags, accepted before the command or after the subcommand:
+`
+
+// options are the flags every command accepts.
+type options struct {
+	config   string
+	profile  string
+	server   string
+	apiKey   string
+	adminKey string
+	output   string
+}
+
+func (o *options) register(flags *flag.FlagSet) {
+	flags.StringVar(&o.config, "config", o.config, "configuration file (env OMSCTL_CONFIG)")
+	flags.StringVar(&o.profile, "profile", o.profile, "profile to use (env OMSCTL_PROFILE)")
+	flags.StringVar(&o.server, "server", o.server, "base URL of the API (env OMSCTL_SERVER)")
+	flags.StringVar(&o.apiKey, "api-key", o.apiKey, "account API key (env OMSCTL_API_KEY)")
+	flags.StringVar(&o.adminKey, "admin-key", o.adminKey, "admin API key (env OMSCTL_ADMIN_KEY)")
+	flags.StringVar(&o.output, "o", o.output, "output format: table, json or csv")
+}
+
+// usageError is a mistake in the command line, reported with exit status 2.
+type usageError struct {
+	message string
+}
+
+func (e *usageError) Error() string {
+	return e.message
+}
+
+func usageErrorf(format string, args ...any) error {
+	return &usageError{message: fmt.Sprintf(format, args...)}
+}
+
+// cli is the state shared by the commands.
+type cli struct {
+	ctx    context.Context
+	opts   options
+	stdout io.Writer
+	stderr io.Writer
+
+	// profile and client are set by parse.
+	profile Profile
+	client  *client
+}
+
+type command func(c *cli, args []string) error
+
+var commands = map[string]command{
+	"orders":     ordersCommand,
+	"book":       bookCommand,
+	"trades":     tradesCommand,
+	"instrument": instrumentCommand,
+	"admin":      adminCommand,
+	"profile":    profileCommand,
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	os.Exit(run(ctx, os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	c := &cli{ctx: ctx, stdout: stdout, stderr: stderr}
+
+	global := flag.NewFlagSet("omsctl", flag.ContinueOnError)
+	global.SetOutput(stderr)
+	c.opts.register(global)
+	global.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		global.PrintDefaults()
+	}
+	if err := global.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	args = global.Args()
+	if len(args) == 0 {
+		global.Usage()
+		return 2
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "omsctl: unknown command %q\n", args[0])
+		global.Usage()
+		return 2
+	}
+
+	err := cmd(c, args[1:])
+	var usageErr *usageError
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.As(err, &usageErr):
+		fmt.Fprintln(stderr, "omsctl:", err)
+		return 2
+	case errors.Is(err, errFlagParse):
+		return 2
+	default:
+		fmt.Fprintln(stderr, "omsctl:", err)
+		return 1
+	}
+}
+
+// errFlagParse is returned once the flag package has reported a bad flag.
+var errFlagParse = errors.New("invalid flags")
+
+// flags returns the flag set of a subcommand, which also accepts the flags
+// of every command.
+func (c *cli) flags(name, arguments string) *flag.FlagSet {
+	flags := flag.NewFlagSet("omsctl "+name, flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	c.opts.register(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(c.stderr, "usage: omsctl %s [flags] %s\n", name, arguments)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// parse parses the flags of a subcommand, checks that it got between min
+// and max arguments (max < 0 for no limit) and connects to the server of
+// the profile.
+func (c *cli) parse(flags *flag.FlagSet, args []string, min, max int) ([]string, error) {
+	args, err := parseArgs(flags, args, min, max)
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := profilesPath(c.opts.config)
+	if err != nil {
+		return nil, err
+	}
+	profiles, err := loadProfiles(path)
+	if err != nil {
+		return nil, err
+	}
+	_, profile, err := profiles.resolve(&c.opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := validOutput(profile.Output); err != nil {
+		return nil, &usageError{message: err.Error()}
+	}
+	c.profile = profile
+	c.client = newClient(profile)
+	return args, nil
+}
+
+// parseArgs parses flags and checks that between min and max arguments
+// (max < 0 for no limit) are left.
+func parseArgs(flags *flag.FlagSet, args []string, min, max int) ([]string, error) {
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil, err
+		}
+		return nil, errFlagParse
+	}
+	args = flags.Args()
+	if len(args) < min || max >= 0 && len(args) > max {
+		flags.Usage()
+		return nil, errFlagParse
+	}
+	return args, nil
+}
+
+func (c *cli) render(value any, tables ...table) error {
+	return render(c.stdout, c.profile.Output, value, tables...)
+}
+
+// subcommands dispatches the subcommands of a command.
+type subcommands map[string]command
+
+func (s subcommands) run(c *cli, name string, args []string) error {
+	names := make([]string, 0, len(s))
+	for subcommand := range s {
+		names = append(names, subcommand)
+	}
+	sort.Strings(names)
+
+	if len(args) == 0 {
+		return usageErrorf("%s needs a subcommand: %s", name, strings.Join(names, ", "))
+	}
+	cmd, ok := s[args[0]]
+	if !ok {
+		return usageErrorf("unknown %s subcommand %q, expected one of %s", name, args[0], strings.Join(names, ", "))
+	}
+	return cmd(c, args[1:])
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bartick/golang-order-matching-system/models"
+)
+
+// clearScreen moves the cursor home and clears the terminal.
+const clearScreen = "\033[H\033[2J"
+
+func bookCommand(c *cli, args []string) error {
+	flags := c.flags("book", "[-watch] [-interval DURATION] [-depth LEVELS] SYMBOL")
+	watch := flags.Bool("watch", false, "keep refreshing the book until interrupted")
+	interval := flags.Duration("interval", time.Second, "refresh interval of -watch")
+	depth := flags.Int("depth", 10, "price levels shown on each side, 0 for all")
+	args, err := c.parse(flags, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	if *interval <= 0 {
+		return usageErrorf("-interval must be positive")
+	}
+	symbol := strings.ToUpper(args[0])
+
+	if !*watch {
+		book, err := c.orderBook(symbol)
+		if err != nil {
+			return err
+		}
+		return c.render(book, ladder(book, *depth))
+	}
+
+	// The table is redrawn in place; the other formats print every
+	// refresh after the previous one.
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		book, err := c.orderBook(symbol)
+		if err == nil {
+			switch c.profile.Output {
+			case outputTable:
+				fmt.Fprint(c.stdout, clearScreen)
+				fmt.Fprintf(c.stdout, "%s  %s  every %s, Ctrl-C to quit\n\n", symbol, time.Now().Format(time.TimeOnly), *interval)
+				err = c.render(book, ladder(book, *depth))
+			case outputJSON:
+				err = json.NewEncoder(c.stdout).Encode(book)
+			default:
+				err = c.render(book, ladder(book, *depth))
+			}
+		}
+		if err != nil && c.ctx.Err() == nil {
+			fmt.Fprintln(c.stderr, "omsctl:", err)
+		}
+
+		select {
+		case <-c.ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *cli) orderBook(symbol string) (*models.OrderBook, error) {
+	var book models.OrderBook
+	err := c.client.do(c.ctx, http.MethodGet, "/orderbook", url.Values{"symbol": {symbol}}, nil, &book)
+	return &book, err
+}
+
+// ladder shows the book as a depth ladder: the asks above the bids, both
+// around a price column, best prices in the middle.
+func ladder(book *models.OrderBook, depth int) table {
+	bids, asks := book.Bids, book.Asks
+	if depth > 0 {
+		bids, asks = bids[:min(depth, len(bids))], asks[:min(depth, len(asks))]
+	}
+
+	t := table{header: []string{"BID ORDERS", "BID QTY", "PRICE", "ASK QTY", "ASK ORDERS"}}
+	for i := len(asks) - 1; i >= 0; i-- {
+		level := asks[i]
+		t.add("", "", formatFloat(level.Price), formatFloat(level.TotalQuantity), strconv.Itoa(level.OrderCount))
+	}
+	for _, level := range bids {
+		t.add(strconv.Itoa(level.OrderCount), formatFloat(level.TotalQuantity), formatFloat(level.Price), "", "")
+	}
+	return t
+}
+
+func tradesCommand(c *cli, args []string) error {
+	flags := c.flags("trades", "[-follow] [-interval DURATION] [-n COUNT] SYMBOL")
+	follow := flags.Bool("follow", false, "keep printing new trades until interrupted")
+	interval := flags.Duration("interval", time.Second, "polling interval of -follow")
+	count := flags.Int("n", 20, "latest trades shown first, at most 100")
+	args, err := c.parse(flags, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	if *interval <= 0 {
+		return usageErrorf("-interval must be positive")
+	}
+	symbol := strings.ToUpper(args[0])
+
+	trades, err := c.latestTrades(symbol)
+	if err != nil {
+		return err
+	}
+	shown := trades[max(len(trades)-*count, 0):]
+	if !*follow {
+		return c.render(map[string][]models.Trade{"trades": shown}, tradesTable(shown))
+	}
+
+	// Trades are polled: only the latest 100 are returned, so a symbol
+	// trading faster than that per interval shows gaps.
+	tail := newTradeTail(c.stdout, c.profile.Output)
+	if err := tail.write(shown); err != nil {
+		return err
+	}
+	seen := tradeIDs(trades)
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		trades, err := c.latestTrades(symbol)
+		if err != nil {
+			if c.ctx.Err() == nil {
+				fmt.Fprintln(c.stderr, "omsctl:", err)
+			}
+			continue
+		}
+		var fresh []models.Trade
+		for _, trade := range trades {
+			if !seen[trade.ID.String()] {
+				fresh = append(fresh, trade)
+			}
+		}
+		if err := tail.write(fresh); err != nil {
+			return err
+		}
+		seen = tradeIDs(trades)
+	}
+}
+
+// latestTrades returns the latest trades of a symbol, oldest first.
+func (c *cli) latestTrades(symbol string) ([]models.Trade, error) {
+	var resp struct {
+		Trades []models.Trade `json:"trades"`
+	}
+	if err := c.client.do(c.ctx, http.MethodGet, "/trades", url.Values{"symbol": {symbol}}, nil, &resp); err != nil {
+		return nil, err
+	}
+	slices.Reverse(resp.Trades)
+	return resp.Trades, nil
+}
+
+func tradeIDs(trades []models.Trade) map[string]bool {
+	ids := make(map[string]bool, len(trades))
+	for _, trade := range trades {
+		ids[trade.ID.String()] = true
+	}
+	return ids
+}
+
+func tradesTable(trades []models.Trade) table {
+	t := table{header: tradeHeader}
+	for _, trade := range trades {
+		t.add(tradeRow(trade)...)
+	}
+	return t
+}
+
+var tradeHeader = []string{"TIME", "SYMBOL", "PRICE", "QUANTITY", "ID", "BUY ORDER", "SELL ORDER"}
+
+func tradeRow(trade models.Trade) []string {
+	return []string{formatTime(trade.ExecutedAt), trade.Symbol, formatFloat(trade.Price), formatFloat(trade.Quantity),
+		trade.ID.String(), trade.BuyOrderID.String(), trade.SellOrderID.String()}
+}
+
+// tradeTail prints trades as they come: JSON as one object per line, the
+// table and CSV under a header written once.
+type tradeTail struct {
+	format string
+	json   *json.Encoder
+	csv    *csv.Writer
+	table  *tabwriter.Writer
+	header bool
+}
+
+func newTradeTail(w io.Writer, format string) *tradeTail {
+	return &tradeTail{
+		format: format,
+		json:   json.NewEncoder(w),
+		csv:    csv.NewWriter(w),
+		// A minimum width keeps the columns aligned from one write to the
+		// next.
+		table: tabwriter.NewWriter(w, 12, 0, 2, ' ', 0),
+	}
+}
+
+func (t *tradeTail) write(trades []models.Trade) error {
+	if t.format == outputJSON {
+		for _, trade := range trades {
+			if err := t.json.Encode(trade); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	rows := make([][]string, 0, len(trades)+1)
+	if !t.header {
+		rows = append(rows, tradeHeader)
+		t.header = true
+	}
+	for _, trade := range trades {
+		rows = append(rows, tradeRow(trade))
+	}
+	if t.format == outputCSV {
+		t.csv.WriteAll(rows)
+		return t.csv.Error()
+	}
+	for _, row := range rows {
+		fmt.Fprintln(t.table, strings.Join(row, "\t"))
+	}
+	return t.table.Flush()
+}
+
+func instrumentCommand(c *cli, args []string) error {
+	flags := c.flags("instrument", "SYMBOL")
+	args, err := c.parse(flags, args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	var instrument models.Instrument
+	if err := c.client.do(c.ctx, http.MethodGet, "/instruments/"+url.PathEscape(strings.ToUpper(args[0])), nil, nil, &instrument); err != nil {
+		return err
+	}
+	return c.render(instrument, instrumentTable(&instrument))
+}
+
+func instrumentTable(instrument *models.Instrument) table {
+	t := table{header: []string{"SYMBOL", "TYPE", "STATE", "AUCTION", "MANUAL", "BASE", "QUOTE", "MATCHING", "HALT ENDS"}}
+	t.add(instrument.Symbol, instrument.Type, instrument.State, formatOptional(instrument.Auction, formatString),
+		strconv.FormatBool(instrument.Manual), instrument.BaseAsset, instrument.QuoteAsset, instrument.MatchingAlgorithm,
+		formatOptional(instrument.HaltEndsAt, formatTime))
+	return t
+}
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/bartick/golang-order-matching-system/models"
+	"github.com/google/uuid"
+)
+
+// optionalFloat is a flag that is nil unless given.
+type optionalFloat struct {
+	value *float64
+}
+
+func (f *optionalFloat) String() string {
+	if f.value == nil {
+		return ""
+	}
+	return formatFloat(*f.value)
+}
+
+func (f *optionalFloat) Set(s string) error {
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return err
+	}
+	f.value = &value
+	return nil
+}
+
+// orderRequest is the body of POST /orders.
+type orderRequest struct {
+	ClientOrderID   string   `json:"client_order_id,omitempty"`
+	Symbol          string   `json:"symbol"`
+	Side            string   `json:"side"`
+	Type            string   `json:"type"`
+	Price           *float64 `json:"price,omitempty"`
+	Quantity        float64  `json:"quantity"`
+	DisplayQuantity *float64 `json:"display_quantity,omitempty"`
+	PostOnly        bool     `json:"post_only,omitempty"`
+	PostOnlyReprice bool     `json:"post_only_reprice,omitempty"`
+	Hidden          bool     `json:"hidden,omitempty"`
+	StopPrice       *float64 `json:"stop_price,omitempty"`
+	TrailAmount     *float64 `json:"trail_amount,omitempty"`
+	TrailPercent    *float64 `json:"trail_percent,omitempty"`
+	PegReference    string   `json:"peg_reference,omitempty"`
+	PegOffset       *float64 `json:"peg_offset,omitempty"`
+	PegLimitPrice   *float64 `json:"peg_limit_price,omitempty"`
+}
+
+// amendRequest is the body of PATCH /orders/:id.
+type amendRequest struct {
+	Price         *float64 `json:"price,omitempty"`
+	Quantity      *float64 `json:"quantity,omitempty"`
+	ClientOrderID string   `json:"client_order_id,omitempty"`
+}
+
+type orderResponse struct {
+	Order  models.Order   `json:"order"`
+	Trades []models.Trade `json:"trades,omitempty"`
+}
+
+type cancelResponse struct {
+	Message string         `json:"message"`
+	Order   *models.Order  `json:"order,omitempty"`
+	Orders  []models.Order `json:"orders,omitempty"`
+}
+
+type batchResponse struct {
+	Results []struct {
+		Index int           `json:"index"`
+		Order *models.Order `json:"order,omitempty"`
+		Error string        `json:"error,omitempty"`
+	} `json:"results"`
+}
+
+type historyResponse struct {
+	OrderID uuid.UUID           `json:"order_id"`
+	Events  []models.OrderEvent `json:"events"`
+}
+
+func ordersCommand(c *cli, args []string) error {
+	return subcommands{
+		"place":   placeOrder,
+		"cancel":  cancelOrders,
+		"amend":   amendOrder,
+		"get":     getOrder,
+		"list":    listOrders,
+		"history": orderHistory,
+	}.run(c, "orders", args)
+}
+
+func placeOrder(c *cli, args []string) error {
+	var req orderRequest
+	var price, displayQuantity, stopPrice, trailAmount, trailPercent, pegOffset, pegLimitPrice optionalFloat
+	flags := c.flags("orders place", "-symbol SYMBOL -side buy|sell -quantity QUANTITY [-price PRICE]")
+	flags.StringVar(&req.Symbol, "symbol", "", "symbol to trade (required)")
+	flags.StringVar(&req.Side, "side", "", "buy or sell (required)")
+	flags.StringVar(&req.Type, "type", "limit", "limit, market, stop, stop_limit, peg, market_on_open or market_on_close")
+	flags.Float64Var(&req.Quantity, "quantity", 0, "quantity (required)")
+	flags.Var(&price, "price", "limit price")
+	flags.StringVar(&req.ClientOrderID, "client-order-id", "", "client order ID")
+	flags.Var(&displayQuantity, "display-quantity", "quantity shown in the book at a time (iceberg)")
+	flags.BoolVar(&req.PostOnly, "post-only", false, "reject the order rather than trade on arrival")
+	flags.BoolVar(&req.PostOnlyReprice, "post-only-reprice", false, "with -post-only, move the order behind the book instead")
+	flags.BoolVar(&req.Hidden, "hidden", false, "keep the order out of the order book")
+	flags.Var(&stopPrice, "stop-price", "trigger price of stop orders")
+	flags.Var(&trailAmount, "trail-amount", "trailing stop offset")
+	flags.Var(&trailPercent, "trail-percent", "trailing stop offset in percent")
+	flags.StringVar(&req.PegReference, "peg-reference", "", "bid, ask or mid for peg orders")
+	flags.Var(&pegOffset, "peg-offset", "offset from the peg reference")
+	flags.Var(&pegLimitPrice, "peg-limit-price", "price a peg order never goes beyond")
+	if _, err := c.parse(flags, args, 0, 0); err != nil {
+		return err
+	}
+	if req.Symbol == "" || req.Side == "" || req.Quantity <= 0 {
+		return usageErrorf("orders place needs -symbol, -side and a positive -quantity")
+	}
+	req.Price, req.DisplayQuantity, req.StopPrice = price.value, displayQuantity.value, stopPrice.value
+	req.TrailAmount, req.TrailPercent = trailAmount.value, trailPercent.value
+	req.PegOffset, req.PegLimitPrice = pegOffset.value, pegLimitPrice.value
+
+	var resp orderResponse
+	if err := c.client.do(c.ctx, http.MethodPost, "/orders", nil, req, &resp); err != nil {
+		return err
+	}
+	return c.render(resp, orderTables(&resp.Order, resp.Trades)...)
+}
+
+// cancelOrders cancels the orders given by ID, or with -all every open
+// order of the account of the profile.
+func cancelOrders(c *cli, args []string) error {
+	flags := c.flags("orders cancel", "ORDER_ID... | -all [-symbol SYMBOL] [-side buy|sell]")
+	all := flags.Bool("all", false, "cancel every open order of the account")
+	symbol := flags.String("symbol", "", "with -all, only the orders of this symbol")
+	side := flags.String("side", "", "with -all, only the orders of this side")
+	args, err := c.parse(flags, args, 0, -1)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case *all:
+		if len(args) > 0 {
+			return usageErrorf("orders cancel takes order IDs or -all, not both")
+		}
+		query := url.Values{}
+		setQuery(query, "symbol", *symbol)
+		setQuery(query, "side", *side)
+		var resp cancelResponse
+		if err := c.client.do(c.ctx, http.MethodDelete, "/orders", query, nil, &resp); err != nil {
+			return err
+		}
+		return c.render(resp, ordersTable(resp.Orders))
+
+	case len(args) == 0:
+		return usageErrorf("orders cancel needs order IDs or -all")
+
+	case len(args) == 1:
+		if err := checkOrderIDs(args); err != nil {
+			return err
+		}
+		var resp cancelResponse
+		if err := c.client.do(c.ctx, http.MethodDelete, "/orders/"+args[0], nil, nil, &resp); err != nil {
+			return err
+		}
+		return c.render(resp, ordersTable([]models.Order{*resp.Order}))
+	}
+
+	if err := checkOrderIDs(args); err != nil {
+		return err
+	}
+	var resp batchResponse
+	body := map[string][]string{"order_ids": args}
+	if err := c.client.do(c.ctx, http.MethodPost, "/orders/cancel", nil, body, &resp); err != nil {
+		return err
+	}
+	t := table{header: []string{"ORDER", "STATUS", "ERROR"}}
+	for _, result := range resp.Results {
+		if result.Order != nil {
+			t.add(result.Order.ID.String(), result.Order.Status, "-")
+		} else {
+			t.add(args[result.Index], "-", result.Error)
+		}
+	}
+	return c.render(resp, t)
+}
+
+func amendOrder(c *cli, args []string) error {
+	var price, quantity optionalFloat
+	var req amendRequest
+	flags := c.flags("orders amend", "[-price PRICE] [-quantity QUANTITY] [-client-order-id ID] ORDER_ID")
+	flags.Var(&price, "price", "new limit price")
+	flags.Var(&quantity, "quantity", "new total quantity")
+	flags.StringVar(&req.ClientOrderID, "client-order-id", "", "new client order ID")
+	args, err := c.parse(flags, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	if err := checkOrderIDs(args); err != nil {
+		return err
+	}
+	if price.value == nil && quantity.value == nil {
+		return usageErrorf("orders amend needs -price and/or -quantity")
+	}
+	req.Price, req.Quantity = price.value, quantity.value
+
+	var resp orderResponse
+	if err := c.client.do(c.ctx, http.MethodPatch, "/orders/"+args[0], nil, req, &resp); err != nil {
+		return err
+	}
+	return c.render(resp, orderTables(&resp.Order, resp.Trades)...)
+}
+
+func getOrder(c *cli, args []string) error {
+	return showOrder(c, args, "orders get", "/orders/")
+}
+
+// showOrder prints an order read from pathPrefix, the account route or the
+// admin one.
+func showOrder(c *cli, args []string, name, pathPrefix string) error {
+	flags := c.flags(name, "ORDER_ID")
+	args, err := c.parse(flags, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	if err := checkOrderIDs(args); err != nil {
+		return err
+	}
+
+	var order models.Order
+	if err := c.client.do(c.ctx, http.MethodGet, pathPrefix+args[0], nil, nil, &order); err != nil {
+		return err
+	}
+	return c.render(order, ordersTable([]models.Order{order}))
+}
+
+func listOrders(c *cli, args []string) error {
+	return showOrders(c, args, "orders list", "/orders")
+}
+
+// showOrders prints the latest orders read from path, the account route or
+// the admin one.
+func showOrders(c *cli, args []string, name, path string) error {
+	flags := c.flags(name, "")
+	symbol := flags.String("symbol", "", "only the orders of this symbol")
+	side := flags.String("side", "", "only the orders of this side")
+	status := flags.String("status", "", "only the orders with this status")
+	limit := flags.Int("limit", 0, "at most this many orders (server default 100)")
+	if _, err := c.parse(flags, args, 0, 0); err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	setQuery(query, "symbol", *symbol)
+	setQuery(query, "side", *side)
+	setQuery(query, "status", *status)
+	if *limit > 0 {
+		query.Set("limit", strconv.Itoa(*limit))
+	}
+	var resp struct {
+		Orders []models.Order `json:"orders"`
+	}
+	if err := c.client.do(c.ctx, http.MethodGet, path, query, nil, &resp); err != nil {
+		return err
+	}
+	return c.render(resp, ordersTable(resp.Orders))
+}
+
+func orderHistory(c *cli, args []string) error {
+	return showOrderHistory(c, args, "orders history", "/orders/")
+}
+
+// showOrderHistory prints the events of an order read from pathPrefix, the
+// account route or the admin one.
+func showOrderHistory(c *cli, args []string, name, pathPrefix string) error {
+	flags := c.flags(name, "ORDER_ID")
+	args, err := c.parse(flags, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	if err := checkOrderIDs(args); err != nil {
+		return err
+	}
+
+	var resp historyResponse
+	if err := c.client.do(c.ctx, http.MethodGet, pathPrefix+args[0]+"/history", nil, nil, &resp); err != nil {
+		return err
+	}
+
+	t := table{header: []string{"TIME", "SEQUENCE", "EVENT", "REASON", "ACTOR", "STATUS", "REMAINING", "DETAILS"}}
+	for _, event := range resp.Events {
+		var after struct {
+			Status            string   `json:"status"`
+			RemainingQuantity *float64 `json:"remaining_quantity"`
+		}
+		_ = json.Unmarshal(event.After, &after)
+		details := "-"
+		if len(event.Details) > 0 {
+			details = string(event.Details)
+		}
+		t.add(formatTime(event.CreatedAt), formatOptional(event.Sequence, formatInt),
+			event.EventType, formatOptional(event.Reason, formatString), event.Actor,
+			firstOf(after.Status, "-"), formatOptional(after.RemainingQuantity, formatFloat), details)
+	}
+	return c.render(resp, t)
+}
+
+func ordersTable(orders []models.Order) table {
+	t := table{header: []string{"ID", "SYMBOL", "SIDE", "TYPE", "PRICE", "QUANTITY", "REMAINING", "STATUS", "CLIENT ORDER ID", "CREATED"}}
+	for _, order := range orders {
+		t.add(order.ID.String(), order.Symbol, order.Side, order.Type, formatOptional(order.Price, formatFloat),
+			formatFloat(order.InitialQuantity), formatFloat(order.RemainingQuantity), order.Status,
+			formatOptional(order.ClientOrderID, formatString), formatTime(order.CreatedAt))
+	}
+	return t
+}
+
+// orderTables shows an order followed by the trades it produced, if any.
+func orderTables(order *models.Order, trades []models.Trade) []table {
+	tables := []table{ordersTable([]models.Order{*order})}
+	if len(trades) > 0 {
+		tables = append(tables, tradesTable(trades))
+	}
+	return tables
+}
+
+func checkOrderIDs(ids []string) error {
+	for _, id := range ids {
+		if _, err := uuid.Parse(id); err != nil {
+			return usageErrorf("invalid order ID %q", id)
+		}
+	}
+	return nil
+}
+
+func setQuery(query url.Values, key, value string) {
+	if value != "" {
+		query.Set(key, value)
+	}
+}
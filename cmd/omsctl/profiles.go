@@ -0,0 +1,309 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultProfile = "default"
+	defaultServer  = "http://localhost:8080"
+)
+
+// Profile is how to reach one environment.
+type Profile struct {
+	Server   string `yaml:"server,omitempty"`
+	APIKey   string `yaml:"api_key,omitempty"`
+	AdminKey string `yaml:"admin_key,omitempty"`
+	Output   string `yaml:"output,omitempty"`
+}
+
+// Profiles is the configuration file of omsctl: the profile of every
+// environment, and the one used when -profile is not given.
+type Profiles struct {
+	Current  string             `yaml:"current,omitempty"`
+	Profiles map[string]Profile `yaml:"profiles"`
+}
+
+// profilesPath is the configuration file given by -config or
+// OMSCTL_CONFIG, or else omsctl/config.yaml in the user configuration
+// directory.
+func profilesPath(flagValue string) (string, error) {
+	if flagValue != "" {
+		return flagValue, nil
+	}
+	if path := os.Getenv("OMSCTL_CONFIG"); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "omsctl", "config.yaml"), nil
+}
+
+// loadProfiles reads the configuration file; a missing file has no
+// profiles.
+func loadProfiles(path string) (*Profiles, error) {
+	profiles := &Profiles{Profiles: make(map[string]Profile)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return profiles, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, profiles); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if profiles.Profiles == nil {
+		profiles.Profiles = make(map[string]Profile)
+	}
+	return profiles, nil
+}
+
+// save writes the configuration file, readable by the user only since it
+// holds API keys.
+func (p *Profiles) save(path string) error {
+	data, err := yaml.Marshal(p)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+func (p *Profiles) names() []string {
+	names := make([]string, 0, len(p.Profiles))
+	for name := range p.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// resolve picks the profile to use: the one named by -profile or
+// OMSCTL_PROFILE, or else the current one. Its settings are overridden by
+// the OMSCTL_SERVER, OMSCTL_API_KEY and OMSCTL_ADMIN_KEY environment
+// variables, then by the flags.
+func (p *Profiles) resolve(opts *options) (string, Profile, error) {
+	name := firstOf(opts.profile, os.Getenv("OMSCTL_PROFILE"), p.Current, defaultProfile)
+	profile, ok := p.Profiles[name]
+	if !ok && (opts.profile != "" || os.Getenv("OMSCTL_PROFILE") != "") {
+		return "", Profile{}, fmt.Errorf("unknown profile %q", name)
+	}
+
+	profile.Server = firstOf(opts.server, os.Getenv("OMSCTL_SERVER"), profile.Server, defaultServer)
+	profile.APIKey = firstOf(opts.apiKey, os.Getenv("OMSCTL_API_KEY"), profile.APIKey)
+	profile.AdminKey = firstOf(opts.adminKey, os.Getenv("OMSCTL_ADMIN_KEY"), profile.AdminKey)
+	profile.Output = firstOf(opts.output, profile.Output, outputTable)
+	return name, profile, nil
+}
+
+func firstOf(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// profileView shows a profile without its keys.
+type profileView struct {
+	Name        string `json:"name"`
+	Current     bool   `json:"current"`
+	Server      string `json:"server,omitempty"`
+	APIKeySet   bool   `json:"api_key_set"`
+	AdminKeySet bool   `json:"admin_key_set"`
+	Output      string `json:"output,omitempty"`
+}
+
+func (p *Profiles) view(name string) profileView {
+	profile := p.Profiles[name]
+	return profileView{
+		Name:        name,
+		Current:     name == p.Current,
+		Server:      profile.Server,
+		APIKeySet:   profile.APIKey != "",
+		AdminKeySet: profile.AdminKey != "",
+		Output:      profile.Output,
+	}
+}
+
+func profilesTable(views []profileView) table {
+	t := table{header: []string{"CURRENT", "NAME", "SERVER", "API KEY", "ADMIN KEY", "OUTPUT"}}
+	for _, view := range views {
+		current := ""
+		if view.Current {
+			current = "*"
+		}
+		t.add(current, view.Name, firstOf(view.Server, "-"), keyState(view.APIKeySet), keyState(view.AdminKeySet), firstOf(view.Output, "-"))
+	}
+	return t
+}
+
+func keyState(set bool) string {
+	if set {
+		return "set"
+	}
+	return "-"
+}
+
+func profileCommand(c *cli, args []string) error {
+	return subcommands{
+		"list":   listProfiles,
+		"show":   showProfile,
+		"set":    setProfile,
+		"use":    useProfile,
+		"delete": deleteProfile,
+	}.run(c, "profile", args)
+}
+
+// openProfiles parses the flags of a profile subcommand and loads the
+// configuration file.
+func (c *cli) openProfiles(flags *flag.FlagSet, args []string, min, max int) ([]string, string, *Profiles, error) {
+	args, err := parseArgs(flags, args, min, max)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	c.profile.Output = firstOf(c.opts.output, outputTable)
+	if err := validOutput(c.profile.Output); err != nil {
+		return nil, "", nil, &usageError{message: err.Error()}
+	}
+	path, err := profilesPath(c.opts.config)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	profiles, err := loadProfiles(path)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return args, path, profiles, nil
+}
+
+func listProfiles(c *cli, args []string) error {
+	_, _, profiles, err := c.openProfiles(c.flags("profile list", ""), args, 0, 0)
+	if err != nil {
+		return err
+	}
+
+	views := []profileView{}
+	for _, name := range profiles.names() {
+		views = append(views, profiles.view(name))
+	}
+	return c.render(views, profilesTable(views))
+}
+
+func showProfile(c *cli, args []string) error {
+	args, _, profiles, err := c.openProfiles(c.flags("profile show", "[NAME]"), args, 0, 1)
+	if err != nil {
+		return err
+	}
+
+	name := firstOf(c.opts.profile, os.Getenv("OMSCTL_PROFILE"), profiles.Current, defaultProfile)
+	if len(args) == 1 {
+		name = args[0]
+	}
+	if _, ok := profiles.Profiles[name]; !ok {
+		return fmt.Errorf("unknown profile %q", name)
+	}
+	view := profiles.view(name)
+	return c.render(view, profilesTable([]profileView{view}))
+}
+
+// setProfile creates or changes a profile. Only the settings given as
+// flags change; an empty value removes one. The first profile becomes the
+// current one.
+func setProfile(c *cli, args []string) error {
+	flags := flag.NewFlagSet("omsctl profile set", flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	flags.StringVar(&c.opts.config, "config", c.opts.config, "configuration file (env OMSCTL_CONFIG)")
+	var profile Profile
+	flags.StringVar(&profile.Server, "server", "", "base URL of the API")
+	flags.StringVar(&profile.APIKey, "api-key", "", "account API key")
+	flags.StringVar(&profile.AdminKey, "admin-key", "", "admin API key")
+	flags.StringVar(&profile.Output, "output", "", "default output format: table, json or csv")
+	flags.Usage = func() {
+		fmt.Fprintln(c.stderr, "usage: omsctl profile set [-server URL] [-api-key KEY] [-admin-key KEY] [-output FORMAT] NAME")
+		flags.PrintDefaults()
+	}
+	args, path, profiles, err := c.openProfiles(flags, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	if profile.Output != "" {
+		if err := validOutput(profile.Output); err != nil {
+			return &usageError{message: err.Error()}
+		}
+	}
+
+	name := args[0]
+	updated := profiles.Profiles[name]
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "server":
+			updated.Server = profile.Server
+		case "api-key":
+			updated.APIKey = profile.APIKey
+		case "admin-key":
+			updated.AdminKey = profile.AdminKey
+		case "output":
+			updated.Output = profile.Output
+		}
+	})
+	profiles.Profiles[name] = updated
+	if profiles.Current == "" {
+		profiles.Current = name
+	}
+	if err := profiles.save(path); err != nil {
+		return err
+	}
+	view := profiles.view(name)
+	return c.render(view, profilesTable([]profileView{view}))
+}
+
+func useProfile(c *cli, args []string) error {
+	args, path, profiles, err := c.openProfiles(c.flags("profile use", "NAME"), args, 1, 1)
+	if err != nil {
+		return err
+	}
+	if _, ok := profiles.Profiles[args[0]]; !ok {
+		return fmt.Errorf("unknown profile %q", args[0])
+	}
+
+	profiles.Current = args[0]
+	if err := profiles.save(path); err != nil {
+		return err
+	}
+	view := profiles.view(args[0])
+	return c.render(view, profilesTable([]profileView{view}))
+}
+
+func deleteProfile(c *cli, args []string) error {
+	args, path, profiles, err := c.openProfiles(c.flags("profile delete", "NAME"), args, 1, 1)
+	if err != nil {
+		return err
+	}
+	if _, ok := profiles.Profiles[args[0]]; !ok {
+		return fmt.Errorf("unknown profile %q", args[0])
+	}
+
+	delete(profiles.Profiles, args[0])
+	if profiles.Current == args[0] {
+		profiles.Current = ""
+	}
+	if err := profiles.save(path); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stderr, "profile %q deleted\n", args[0])
+	return nil
+}
@@ -1,25 +1,35 @@
 package db
 
 import (
-	"log"
+	"fmt"
+	"log/slog"
+	"os"
 
 	"github.com/bartick/golang-order-matching-system/internals"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
 )
 
-func ConnectDatabase(c internals.Config) *sqlx.DB {
-	db, err := sqlx.Connect("postgres", "user="+c.DBUser+" dbname="+c.DBName+" sslmode=disable password="+c.DBPassword+" host="+c.DBHost+" port="+c.DBPort)
+func ConnectDatabase(c internals.DatabaseConfig) *sqlx.DB {
+	dsn := fmt.Sprintf("user=%s dbname=%s sslmode=%s password=%s host=%s port=%d connect_timeout=%d",
+		c.User, c.Name, c.SSLMode, c.Password, c.Host, c.Port, int(c.ConnectTimeout.Seconds()))
+	db, err := sqlx.Connect("postgres", dsn)
 	if err != nil {
-		log.Fatalln(err)
+		slog.Error("failed to connect to the database", "error", err)
+		os.Exit(1)
 	}
 
+	db.SetMaxOpenConns(c.MaxOpenConns)
+	db.SetMaxIdleConns(c.MaxIdleConns)
+	db.SetConnMaxLifetime(c.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(c.ConnMaxIdleTime)
+
 	// Test the connection to the database
 	if err := db.Ping(); err != nil {
-		log.Fatal(err)
-	} else {
-		log.Println("Successfully Connected")
+		slog.Error("failed to ping the database", "error", err)
+		os.Exit(1)
 	}
+	slog.Info("connected to the database", "host", c.Host, "port", c.Port, "name", c.Name)
 
 	return db
 }
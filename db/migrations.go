@@ -0,0 +1,19 @@
+package db
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// SchemaVersion is the migration this code expects the database to be at.
+// It goes up with every new migrations/mNN.sql file.
+const SchemaVersion = 24
+
+// CurrentSchemaVersion returns the latest migration recorded in the
+// database.
+func CurrentSchemaVersion(ctx context.Context, db *sqlx.DB) (int, error) {
+	var version int
+	err := db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	return version, err
+}
@@ -0,0 +1,46 @@
+package engine
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+
+	"github.com/bartick/golang-order-matching-system/models"
+	"github.com/google/uuid"
+)
+
+// GetAccountByAPIKey returns the account the API key belongs to, whether
+// it is enabled or not.
+func (e *Engine) GetAccountByAPIKey(apiKey string) (*models.Account, error) {
+	var account models.Account
+	query := `SELECT id, name, enabled, created_at FROM accounts WHERE api_key_hash = $1`
+	err := e.db.QueryRow(query, hashAPIKey(apiKey)).Scan(&account.ID, &account.Name, &account.Enabled, &account.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrAccountNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// SetAccountEnabled enables or disables an account. A disabled account can
+// no longer authenticate; its open orders are left alone.
+func (e *Engine) SetAccountEnabled(accountID uuid.UUID, enabled bool) (*models.Account, error) {
+	var account models.Account
+	query := `UPDATE accounts SET enabled = $2 WHERE id = $1 RETURNING id, name, enabled, created_at`
+	err := e.db.QueryRow(query, accountID, enabled).Scan(&account.ID, &account.Name, &account.Enabled, &account.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrAccountNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// hashAPIKey returns the SHA-256 hex digest stored in place of an API key.
+func hashAPIKey(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:])
+}
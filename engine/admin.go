@@ -0,0 +1,267 @@
+package engine
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/bartick/golang-order-matching-system/models"
+)
+
+// maxAdminActions bounds the number of audit log entries returned at once.
+const maxAdminActions = 500
+
+// GetAdminByAPIKey returns the administrator the API key belongs to.
+func (e *Engine) GetAdminByAPIKey(apiKey string) (*models.Admin, error) {
+	var admin models.Admin
+	err := e.db.Get(&admin, `SELECT id, name, created_at FROM admins WHERE api_key_hash = $1`, hashAPIKey(apiKey))
+	if err == sql.ErrNoRows {
+		return nil, ErrAdminNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &admin, nil
+}
+
+// StartAdminAction appends a pending entry to the admin audit log before
+// the action runs, and returns its ID for FinishAdminAction. The database
+// keeps entries from ever being changed once they are finished.
+func (e *Engine) StartAdminAction(action models.AdminAction) (int64, error) {
+	var id int64
+	query := `INSERT INTO admin_audit_log (admin_id, admin_name, action, target, details, status, request_id)
+			  VALUES ($1, $2, $3, $4, $5, NULL, $6) RETURNING id`
+	err := e.db.QueryRow(query, action.AdminID, action.AdminName, action.Action, action.Target,
+		auditDetails(action.Details), action.RequestID).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record admin action: %w", err)
+	}
+	return id, nil
+}
+
+// FinishAdminAction records the status the pending entry id was answered
+// with, and its details when it had none.
+func (e *Engine) FinishAdminAction(id int64, status int, details json.RawMessage) error {
+	query := `UPDATE admin_audit_log SET status = $2, details = COALESCE(details, $3)
+			  WHERE id = $1 AND status IS NULL`
+	result, err := e.db.Exec(query, id, status, auditDetails(details))
+	if err != nil {
+		return fmt.Errorf("failed to record admin action: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return fmt.Errorf("admin action %d is not pending", id)
+	}
+	return nil
+}
+
+// auditDetails stores details as JSON, or as NULL when there are none.
+func auditDetails(details json.RawMessage) sql.NullString {
+	if len(details) == 0 {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: string(details), Valid: true}
+}
+
+// GetAdminActions returns the latest entries of the admin audit log, most
+// recent first. A positive before only returns the entries older than that
+// ID, to page through the log.
+func (e *Engine) GetAdminActions(limit int, before int64) ([]models.AdminAction, error) {
+	if limit <= 0 || limit > maxAdminActions {
+		limit = maxAdminActions
+	}
+	actions := []models.AdminAction{}
+	err := e.db.Select(&actions, `SELECT id, admin_id, admin_name, action, target, details, status, request_id, created_at
+		FROM admin_audit_log WHERE ($1 <= 0 OR id < $1) ORDER BY id DESC LIMIT $2`, before, limit)
+	return actions, err
+}
+
+// AdminCancelOrders cancels, on behalf of an administrator, every open
+// order of a symbol, of an account, or of an account in a symbol.
+func (e *Engine) AdminCancelOrders(filter CancelFilter) ([]models.Order, error) {
+	if filter.AccountID == nil && filter.Symbol == "" {
+		return nil, newValidationError("an account or a symbol is required")
+	}
+	filter.Reason = ReasonAdmin
+	return e.cancelOrders(filter)
+}
+
+// Snapshot is the outcome of SnapshotOrderBooks.
+type Snapshot struct {
+	TakenAt time.Time `json:"taken_at"`
+	Levels  int       `json:"levels"`
+
+	// Sequences holds the last sequence number of every symbol the
+	// snapshot was taken at.
+	Sequences map[string]int64 `json:"sequences"`
+}
+
+// SnapshotOrderBooks replaces order_book_snapshots with the price levels of
+// every book, counted like GetOrderBook but without a depth limit. The
+// sequences of every symbol are locked while the snapshot is taken, so no
+// command is halfway through when it is.
+func (e *Engine) SnapshotOrderBooks() (*Snapshot, error) {
+	tx, err := e.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var sequences []struct {
+		Symbol       string `db:"symbol"`
+		LastSequence int64  `db:"last_sequence"`
+	}
+	if err := tx.Select(&sequences, `SELECT symbol, last_sequence FROM symbol_sequences ORDER BY symbol FOR SHARE`); err != nil {
+		return nil, fmt.Errorf("failed to lock the sequences: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM order_book_snapshots`); err != nil {
+		return nil, fmt.Errorf("failed to clear the previous snapshot: %w", err)
+	}
+	result, err := tx.Exec(`INSERT INTO order_book_snapshots (symbol, side, price, total_quantity, order_count, sequence, updated_at)
+		SELECT o.symbol, o.side, o.price, SUM(COALESCE(o.visible_quantity, o.remaining_quantity)), COUNT(*),
+		       s.last_sequence, CURRENT_TIMESTAMP
+		FROM orders o LEFT JOIN symbol_sequences s ON s.symbol = o.symbol
+		WHERE o.status IN ('open', 'partially_filled') AND NOT o.hidden AND o.price IS NOT NULL
+		GROUP BY o.symbol, o.side, o.price, s.last_sequence`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot the order books: %w", err)
+	}
+	levels, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	snapshot := &Snapshot{TakenAt: time.Now(), Levels: int(levels), Sequences: make(map[string]int64, len(sequences))}
+	for _, sequence := range sequences {
+		snapshot.Sequences[sequence.Symbol] = sequence.LastSequence
+	}
+	return snapshot, nil
+}
+
+// SymbolInternals describes the queues of one symbol.
+type SymbolInternals struct {
+	Symbol       string `json:"symbol"`
+	LastSequence int64  `json:"last_sequence"`
+
+	// RestingBids and RestingAsks are the orders queued on each side of
+	// the book, PendingOrders the stops waiting to be triggered.
+	RestingBids   int `json:"resting_bids"`
+	RestingAsks   int `json:"resting_asks"`
+	PendingOrders int `json:"pending_orders"`
+
+	Subscribers int `json:"subscribers"`
+	// MarketDataQueue is the most events a subscriber has yet to consume.
+	MarketDataQueue int `json:"market_data_queue"`
+}
+
+type DatabaseStats struct {
+	OpenConnections int     `json:"open_connections"`
+	InUse           int     `json:"in_use"`
+	Idle            int     `json:"idle"`
+	WaitCount       int64   `json:"wait_count"`
+	WaitMs          float64 `json:"wait_ms"`
+}
+
+// Internals is what an operator needs to see of the running engine.
+type Internals struct {
+	Symbols []SymbolInternals `json:"symbols"`
+
+	// AllSymbolSubscribers receive the events of every symbol.
+	AllSymbolSubscribers int `json:"all_symbol_subscribers"`
+	AllSymbolQueue       int `json:"all_symbol_queue"`
+
+	Monitors         []MonitorStatus        `json:"monitors"`
+	DeadMansSwitches int                    `json:"dead_mans_switches"`
+	MaxBatchSize     int                    `json:"max_batch_size"`
+	OrderLimits      map[string]OrderLimits `json:"order_limits"`
+	Database         DatabaseStats          `json:"database"`
+}
+
+// Internals reports the queues of every symbol that has a sequence number
+// or open orders, along with the state of the engine itself.
+func (e *Engine) Internals() (*Internals, error) {
+	symbols := make(map[string]*SymbolInternals)
+	symbolInternals := func(symbol string) *SymbolInternals {
+		if symbols[symbol] == nil {
+			symbols[symbol] = &SymbolInternals{Symbol: symbol}
+		}
+		return symbols[symbol]
+	}
+
+	var sequences []struct {
+		Symbol       string `db:"symbol"`
+		LastSequence int64  `db:"last_sequence"`
+	}
+	if err := e.db.Select(&sequences, `SELECT symbol, last_sequence FROM symbol_sequences`); err != nil {
+		return nil, fmt.Errorf("failed to read the sequences: %w", err)
+	}
+	for _, sequence := range sequences {
+		symbolInternals(sequence.Symbol).LastSequence = sequence.LastSequence
+	}
+
+	var counts []struct {
+		Symbol string `db:"symbol"`
+		Side   string `db:"side"`
+		Status string `db:"status"`
+		Count  int    `db:"count"`
+	}
+	err := e.db.Select(&counts, `SELECT symbol, side, status, COUNT(*) AS count FROM orders
+		WHERE status IN ('pending', 'open', 'partially_filled') GROUP BY symbol, side, status`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count the open orders: %w", err)
+	}
+	for _, count := range counts {
+		internals := symbolInternals(count.Symbol)
+		switch {
+		case count.Status == "pending":
+			internals.PendingOrders += count.Count
+		case count.Side == "buy":
+			internals.RestingBids += count.Count
+		default:
+			internals.RestingAsks += count.Count
+		}
+	}
+
+	internals := &Internals{
+		Monitors:     e.Monitors(),
+		MaxBatchSize: e.maxBatchSize(),
+		OrderLimits:  e.OrderLimits(),
+	}
+	depths := e.marketData.QueueDepths()
+	for symbol, subscribers := range e.marketData.SubscriberCounts() {
+		if symbol == allSymbols {
+			internals.AllSymbolSubscribers, internals.AllSymbolQueue = subscribers, depths[symbol]
+			continue
+		}
+		symbolInternals(symbol).Subscribers = subscribers
+		symbolInternals(symbol).MarketDataQueue = depths[symbol]
+	}
+
+	internals.Symbols = make([]SymbolInternals, 0, len(symbols))
+	for _, symbol := range symbols {
+		internals.Symbols = append(internals.Symbols, *symbol)
+	}
+	sort.Slice(internals.Symbols, func(i, j int) bool {
+		return internals.Symbols[i].Symbol < internals.Symbols[j].Symbol
+	})
+
+	e.deadMansSwitches.mu.Lock()
+	internals.DeadMansSwitches = len(e.deadMansSwitches.switches)
+	e.deadMansSwitches.mu.Unlock()
+
+	stats := e.db.Stats()
+	internals.Database = DatabaseStats{
+		OpenConnections: stats.OpenConnections,
+		InUse:           stats.InUse,
+		Idle:            stats.Idle,
+		WaitCount:       stats.WaitCount,
+		WaitMs:          float64(stats.WaitDuration.Microseconds()) / 1000,
+	}
+	return internals, nil
+}
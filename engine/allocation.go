@@ -0,0 +1,141 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bartick/golang-order-matching-system/models"
+)
+
+// Matching algorithms, chosen per instrument.
+const (
+	AlgorithmFIFO        = "fifo"
+	AlgorithmProRata     = "pro_rata"
+	AlgorithmFIFOProRata = "fifo_pro_rata"
+)
+
+// priceLevels splits resting orders, sorted by priority, into runs of the
+// same price.
+func priceLevels(orders []*models.Order) [][]*models.Order {
+	var levels [][]*models.Order
+	for i, order := range orders {
+		if i == 0 || *order.Price != *orders[i-1].Price {
+			levels = append(levels, nil)
+		}
+		levels[len(levels)-1] = append(levels[len(levels)-1], order)
+	}
+	return levels
+}
+
+// allocate splits quantity among the resting orders of one price level,
+// given in time priority, with the instrument's matching algorithm. Each
+// order can take up to its available quantity. The result holds the
+// quantity of each order.
+func allocate(instrument *models.Instrument, quantity float64, level []*models.Order) []float64 {
+	allocations := make([]float64, len(level))
+	if instrument.MatchingAlgorithm != AlgorithmProRata && instrument.MatchingAlgorithm != AlgorithmFIFOProRata {
+		sizes := make([]float64, len(level))
+		for i, order := range level {
+			sizes[i] = availableQuantity(order)
+		}
+		allocateFIFO(allocations, sizes, quantity)
+		return allocations
+	}
+
+	// Hidden orders do not take part in the pro-rata split: the displayed
+	// orders are allocated first, and the hidden ones share what is left in
+	// time priority.
+	var displayed, hidden []int
+	for i, order := range level {
+		if order.Hidden {
+			hidden = append(hidden, i)
+		} else {
+			displayed = append(displayed, i)
+		}
+	}
+
+	sizes := make([]float64, len(displayed))
+	for i, index := range displayed {
+		sizes[i] = availableQuantity(level[index])
+	}
+	shares := make([]float64, len(displayed))
+	if instrument.MatchingAlgorithm == AlgorithmFIFOProRata && len(sizes) > 0 {
+		// The first displayed order of the level is filled first
+		shares[0] = min(quantity, sizes[0])
+		rest := RoundQuantity(quantity - shares[0])
+		allocateProRata(shares[1:], sizes[1:], rest, instrument.MinAllocation, instrument.QuantityPrecision)
+	} else {
+		allocateProRata(shares, sizes, quantity, instrument.MinAllocation, instrument.QuantityPrecision)
+	}
+	for i, index := range displayed {
+		allocations[index] = shares[i]
+		quantity = RoundQuantity(quantity - shares[i])
+	}
+
+	sizes = make([]float64, len(hidden))
+	for i, index := range hidden {
+		sizes[i] = availableQuantity(level[index])
+	}
+	shares = make([]float64, len(hidden))
+	allocateFIFO(shares, sizes, quantity)
+	for i, index := range hidden {
+		allocations[index] = shares[i]
+	}
+	return allocations
+}
+
+// allocateFIFO fills the orders in time priority.
+func allocateFIFO(allocations, sizes []float64, quantity float64) {
+	for i, size := range sizes {
+		fill := min(quantity, RoundQuantity(size-allocations[i]))
+		allocations[i] = RoundQuantity(allocations[i] + fill)
+		quantity = RoundQuantity(quantity - fill)
+	}
+}
+
+// allocateProRata gives each order its share of quantity in proportion to
+// its size, rounded down to the instrument's quantity precision. Shares
+// below minAllocation are dropped. What is left after rounding goes to the
+// orders in time priority, so the result only depends on the sizes and
+// their order.
+func allocateProRata(allocations, sizes []float64, quantity, minAllocation float64, precision int) {
+	total := 0.0
+	for _, size := range sizes {
+		total = RoundQuantity(total + size)
+	}
+	if quantity >= total {
+		copy(allocations, sizes)
+		return
+	}
+
+	allocated := 0.0
+	for i, size := range sizes {
+		share := floorToLot(quantity*size/total, precision)
+		if share < minAllocation {
+			share = 0
+		}
+		allocations[i] = share
+		allocated = RoundQuantity(allocated + share)
+	}
+	allocateFIFO(allocations, sizes, RoundQuantity(quantity-allocated))
+}
+
+// SetMatchingAlgorithm chooses how the resting orders of a price level of
+// a symbol share an incoming order.
+func (e *Engine) SetMatchingAlgorithm(symbol, algorithm string, minAllocation float64) (*models.Instrument, error) {
+	if algorithm != AlgorithmFIFO && algorithm != AlgorithmProRata && algorithm != AlgorithmFIFOProRata {
+		return nil, newValidationError("algorithm must be 'fifo', 'pro_rata' or 'fifo_pro_rata'")
+	}
+	if minAllocation <= 0 {
+		return nil, newValidationError("min_allocation must be positive")
+	}
+
+	symbol = strings.ToUpper(symbol)
+	query := `INSERT INTO instruments (symbol, matching_algorithm, min_allocation) VALUES ($1, $2, $3)
+			  ON CONFLICT (symbol) DO UPDATE
+			  SET matching_algorithm = EXCLUDED.matching_algorithm, min_allocation = EXCLUDED.min_allocation`
+	if _, err := e.db.Exec(query, symbol, algorithm, minAllocation); err != nil {
+		return nil, fmt.Errorf("failed to set matching algorithm: %w", err)
+	}
+	return e.GetInstrument(symbol)
+}
@@ -0,0 +1,81 @@
+package engine
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/bartick/golang-order-matching-system/models"
+)
+
+func TestAllocateProRata(t *testing.T) {
+	tests := []struct {
+		name          string
+		sizes         []float64
+		quantity      float64
+		minAllocation float64
+		precision     int
+		want          []float64
+	}{
+		{"proportional", []float64{10, 30}, 20, 1, 0, []float64{5, 15}},
+		{"fills every order", []float64{10, 30}, 50, 1, 0, []float64{10, 30}},
+		{"remainder in time priority", []float64{10, 10, 10}, 10, 1, 0, []float64{4, 3, 3}},
+		// The share of 2 of the second order is under the floor, so the
+		// remainder goes to the first order
+		{"min allocation floor", []float64{96, 4}, 50, 5, 0, []float64{50, 0}},
+		{"rounded to precision", []float64{10, 10, 10}, 10, 0.01, 2, []float64{3.34, 3.33, 3.33}},
+		{"remainder capped by size", []float64{1, 1, 10}, 8, 1, 0, []float64{1, 1, 6}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allocations := make([]float64, len(tt.sizes))
+			allocateProRata(allocations, tt.sizes, tt.quantity, tt.minAllocation, tt.precision)
+			if !slices.Equal(allocations, tt.want) {
+				t.Fatalf("got %v, want %v", allocations, tt.want)
+			}
+		})
+	}
+}
+
+func restingOrder(quantity float64) *models.Order {
+	return &models.Order{RemainingQuantity: quantity}
+}
+
+func hiddenOrder(quantity float64) *models.Order {
+	return &models.Order{RemainingQuantity: quantity, Hidden: true}
+}
+
+func icebergOrder(visible, quantity float64) *models.Order {
+	return &models.Order{RemainingQuantity: quantity, VisibleQuantity: &visible}
+}
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		name      string
+		algorithm string
+		quantity  float64
+		level     []*models.Order
+		want      []float64
+	}{
+		{"fifo", AlgorithmFIFO, 8, []*models.Order{restingOrder(5), restingOrder(10)}, []float64{5, 3}},
+		// Levels come with hidden orders last; FIFO keeps the order it is given
+		{"fifo follows the level order", AlgorithmFIFO, 6, []*models.Order{hiddenOrder(5), restingOrder(5)}, []float64{5, 1}},
+		{"fifo fills hidden orders after displayed ones", AlgorithmFIFO, 12, []*models.Order{restingOrder(5), restingOrder(5), hiddenOrder(10)}, []float64{5, 5, 2}},
+		{"pro rata", AlgorithmProRata, 20, []*models.Order{restingOrder(10), restingOrder(30)}, []float64{5, 15}},
+		{"pro rata by displayed slice", AlgorithmProRata, 10, []*models.Order{icebergOrder(5, 50), restingOrder(15)}, []float64{3, 7}},
+		{"top order share", AlgorithmFIFOProRata, 20, []*models.Order{restingOrder(10), restingOrder(20), restingOrder(20)}, []float64{10, 5, 5}},
+		{"top order takes everything", AlgorithmFIFOProRata, 20, []*models.Order{restingOrder(30), restingOrder(10)}, []float64{20, 0}},
+		{"hidden orders get nothing while displayed ones are open", AlgorithmProRata, 20, []*models.Order{restingOrder(10), restingOrder(30), hiddenOrder(40)}, []float64{5, 15, 0}},
+		{"hidden orders get the residual", AlgorithmProRata, 50, []*models.Order{restingOrder(10), restingOrder(30), hiddenOrder(5), hiddenOrder(40)}, []float64{10, 30, 5, 5}},
+		{"top order is the first displayed one", AlgorithmFIFOProRata, 15, []*models.Order{hiddenOrder(40), restingOrder(10)}, []float64{5, 10}},
+		{"only hidden orders", AlgorithmProRata, 15, []*models.Order{hiddenOrder(10), hiddenOrder(10)}, []float64{10, 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instrument := &models.Instrument{MatchingAlgorithm: tt.algorithm, MinAllocation: 1}
+			allocations := allocate(instrument, tt.quantity, tt.level)
+			if !slices.Equal(allocations, tt.want) {
+				t.Fatalf("got %v, want %v", allocations, tt.want)
+			}
+		})
+	}
+}
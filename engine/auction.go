@@ -0,0 +1,337 @@
+package engine
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bartick/golang-order-matching-system/models"
+	"github.com/lib/pq"
+)
+
+// Auctions called by an instrument in the "auction" state.
+const (
+	AuctionOpening = "opening"
+	AuctionClosing = "closing"
+)
+
+// ReasonAuctionUnexecuted is recorded for market orders canceled because
+// they found no counterparty in their auction.
+const ReasonAuctionUnexecuted = "auction_unexecuted"
+
+// auctionOrderTypes are the types of the pending orders that take part in
+// the given auction: market orders, and the market-on-open or
+// market-on-close orders of the opening or closing auction. The reopening
+// auction after a volatility halt leaves those for their own auction.
+func auctionOrderTypes(auction string) []string {
+	switch auction {
+	case AuctionOpening:
+		return []string{"market", "market_on_open"}
+	case AuctionClosing:
+		return []string{"market", "market_on_close"}
+	default:
+		return []string{"market"}
+	}
+}
+
+func isAuctionOrderType(orderType string) bool {
+	return orderType == "market_on_open" || orderType == "market_on_close"
+}
+
+// pendingAuction is the auction the orders collected by an instrument take
+// part in: the one being called, or the opening auction before the open.
+func pendingAuction(instrument *models.Instrument) string {
+	if instrument.Auction != nil {
+		return *instrument.Auction
+	}
+	return AuctionOpening
+}
+
+// StartAuction starts the call phase of an opening or closing auction.
+// Orders are then collected without matching until RunAuction.
+func (e *Engine) StartAuction(symbol, auction string) (*models.AuctionState, error) {
+	if auction != AuctionOpening && auction != AuctionClosing {
+		return nil, newValidationError("auction type must be 'opening' or 'closing'")
+	}
+
+	_, err := e.transition(symbol, true, func(instrument *models.Instrument) (string, string, error) {
+		if instrument.State == StateAuction {
+			return "", "", ErrAuctionInProgress
+		}
+		return StateAuction, auction, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return e.GetAuctionState(symbol)
+}
+
+// GetAuctionState returns the indicative uncross of a symbol that is
+// collecting orders for an auction.
+func (e *Engine) GetAuctionState(symbol string) (*models.AuctionState, error) {
+	symbol = strings.ToUpper(symbol)
+
+	tx, err := e.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	instrument, err := getInstrument(tx, symbol)
+	if err != nil {
+		return nil, err
+	}
+	if !collectsOrders(instrument.State) {
+		return nil, ErrNoAuction
+	}
+	return auctionState(tx, symbol, pendingAuction(instrument))
+}
+
+// RunAuction uncrosses the book of a symbol in its call phase at the single
+// price that executes the most volume and cancels the market orders left
+// unexecuted. The symbol then trades continuously after an opening auction
+// and closes after a closing auction.
+func (e *Engine) RunAuction(symbol string) (*models.AuctionResult, error) {
+	change, err := e.transition(symbol, true, func(instrument *models.Instrument) (string, string, error) {
+		if instrument.State != StateAuction {
+			return "", "", ErrNoAuction
+		}
+		if *instrument.Auction == AuctionClosing {
+			return StateClosed, "", nil
+		}
+		return StateContinuous, "", nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return change.result, nil
+}
+
+// uncrossBook runs the given auction on the orders collected by a symbol
+// and cancels the market orders it left unexecuted.
+func uncrossBook(tx *sql.Tx, symbol, auction string) (*models.AuctionResult, []models.Order, error) {
+	instrument, err := getInstrument(tx, symbol)
+	if err != nil {
+		return nil, nil, err
+	}
+	buys, sells, err := auctionOrders(tx, symbol, auction)
+	if err != nil {
+		return nil, nil, err
+	}
+	reference, err := lastTradePrice(tx, symbol)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := &models.AuctionResult{Symbol: symbol, Auction: auction, Trades: []models.Trade{}}
+	if u := findUncross(buys, sells, reference); u != nil && u.volume > 0 {
+		result.Price = &u.price
+		result.Volume = u.volume
+		if result.Trades, err = executeUncross(tx, instrument, buys, sells, u); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	canceled, err := cancelUnexecuted(tx, symbol, auctionOrderTypes(auction)...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	slog.Info("auction uncrossed", "symbol", symbol, "auction", auction, "volume", result.Volume, "price", result.Price)
+	return result, canceled, nil
+}
+
+// auctionOrders returns the orders taking part in the given auction, in
+// execution priority: market orders first, then by price and time.
+func auctionOrders(tx *sql.Tx, symbol, auction string) (buys, sells []*models.Order, err error) {
+	where := `WHERE symbol = $1 AND side = $2
+			    AND (status IN ('open', 'partially_filled') OR (status = 'pending' AND type = ANY($3)))`
+
+	buys, err = queryOrders(tx, `SELECT `+orderColumns+` FROM orders `+where+`
+								 ORDER BY price IS NULL DESC, price DESC, queued_at ASC`,
+		symbol, "buy", pq.Array(auctionOrderTypes(auction)))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load auction orders: %w", err)
+	}
+	sells, err = queryOrders(tx, `SELECT `+orderColumns+` FROM orders `+where+`
+								  ORDER BY price IS NULL DESC, price ASC, queued_at ASC`,
+		symbol, "sell", pq.Array(auctionOrderTypes(auction)))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load auction orders: %w", err)
+	}
+	return buys, sells, nil
+}
+
+func auctionState(tx *sql.Tx, symbol, auction string) (*models.AuctionState, error) {
+	buys, sells, err := auctionOrders(tx, symbol, auction)
+	if err != nil {
+		return nil, err
+	}
+	reference, err := lastTradePrice(tx, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &models.AuctionState{Symbol: symbol, Auction: auction}
+	if u := findUncross(buys, sells, reference); u != nil && u.volume > 0 {
+		state.Price = &u.price
+		state.Volume = u.volume
+		state.Imbalance = RoundQuantity(u.buyVolume - u.sellVolume)
+		switch {
+		case state.Imbalance > 0:
+			state.ImbalanceSide = "buy"
+		case state.Imbalance < 0:
+			state.ImbalanceSide = "sell"
+			state.Imbalance = -state.Imbalance
+		}
+	}
+	return state, nil
+}
+
+type uncross struct {
+	price      float64
+	volume     float64
+	buyVolume  float64
+	sellVolume float64
+}
+
+// findUncross picks the auction price among the limit prices of the
+// orders. It maximises the executed volume, then minimises the imbalance
+// left over, then stays closest to the reference price (the last trade),
+// and finally prefers the lower price. With market orders only, the
+// reference price is the sole candidate.
+func findUncross(buys, sells []*models.Order, reference *float64) *uncross {
+	seen := make(map[float64]bool)
+	var candidates []float64
+	for _, order := range append(append([]*models.Order{}, buys...), sells...) {
+		if order.Price != nil && !seen[*order.Price] {
+			seen[*order.Price] = true
+			candidates = append(candidates, *order.Price)
+		}
+	}
+	if len(candidates) == 0 && reference != nil {
+		candidates = append(candidates, *reference)
+	}
+	sort.Float64s(candidates)
+
+	var best *uncross
+	for _, price := range candidates {
+		u := &uncross{price: price}
+		for _, buy := range buys {
+			if buy.Price == nil || *buy.Price >= price {
+				u.buyVolume = RoundQuantity(u.buyVolume + buy.RemainingQuantity)
+			}
+		}
+		for _, sell := range sells {
+			if sell.Price == nil || *sell.Price <= price {
+				u.sellVolume = RoundQuantity(u.sellVolume + sell.RemainingQuantity)
+			}
+		}
+		u.volume = min(u.buyVolume, u.sellVolume)
+
+		if best == nil || betterUncross(u, best, reference) {
+			best = u
+		}
+	}
+	return best
+}
+
+func betterUncross(u, best *uncross, reference *float64) bool {
+	if u.volume != best.volume {
+		return u.volume > best.volume
+	}
+	imbalance := RoundQuantity(math.Abs(u.buyVolume - u.sellVolume))
+	bestImbalance := RoundQuantity(math.Abs(best.buyVolume - best.sellVolume))
+	if imbalance != bestImbalance {
+		return imbalance < bestImbalance
+	}
+	if reference != nil {
+		distance, bestDistance := math.Abs(u.price-*reference), math.Abs(best.price-*reference)
+		if distance != bestDistance {
+			return distance < bestDistance
+		}
+	}
+	return u.price < best.price
+}
+
+// executeUncross trades the auction volume at the auction price, pairing
+// buys and sells in priority order. Iceberg orders take part with their
+// full quantity.
+func executeUncross(tx *sql.Tx, instrument *models.Instrument, buys, sells []*models.Order, u *uncross) ([]models.Trade, error) {
+	var trades []models.Trade
+	remaining := u.volume
+	i, j := 0, 0
+	for remaining > 0 && i < len(buys) && j < len(sells) {
+		buy, sell := buys[i], sells[j]
+		quantity := min(remaining, buy.RemainingQuantity, sell.RemainingQuantity)
+
+		trade, err := createTrade(context.Background(), tx, instrument, buy, sell, u.price, quantity)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create trade: %w", err)
+		}
+		trades = append(trades, *trade)
+		remaining = RoundQuantity(remaining - quantity)
+
+		for _, order := range []*models.Order{buy, sell} {
+			before := stateOf(order)
+			order.RemainingQuantity = RoundQuantity(order.RemainingQuantity - quantity)
+			resetVisible(order)
+			if err := updateOrderQuantity(context.Background(), tx, order, false); err != nil {
+				return nil, fmt.Errorf("failed to update order quantity: %w", err)
+			}
+			if err := recordFill(tx, order, before, trade); err != nil {
+				return nil, err
+			}
+		}
+		if buy.RemainingQuantity == 0 {
+			i++
+		}
+		if sell.RemainingQuantity == 0 {
+			j++
+		}
+	}
+	return trades, nil
+}
+
+// resetVisible shows a fresh slice of an iceberg order that traded past
+// its displayed quantity.
+func resetVisible(order *models.Order) {
+	if order.VisibleQuantity == nil {
+		return
+	}
+	if *order.VisibleQuantity == 0 || *order.VisibleQuantity > order.RemainingQuantity {
+		visible := min(*order.DisplayQuantity, order.RemainingQuantity)
+		order.VisibleQuantity = &visible
+	}
+}
+
+// cancelUnexecuted cancels what is left of the orders of the given types
+// once their auction is over: market orders cannot rest in the book.
+func cancelUnexecuted(tx *sql.Tx, symbol string, orderTypes ...string) ([]models.Order, error) {
+	return cancelSymbolOrders(tx, symbol, ReasonAuctionUnexecuted, orderTypes...)
+}
+
+// cancelSymbolOrders cancels the active orders of a symbol of the given
+// types, or of every type when none is given, and records why.
+func cancelSymbolOrders(tx *sql.Tx, symbol, reason string, orderTypes ...string) ([]models.Order, error) {
+	query := `SELECT ` + orderColumns + ` FROM orders
+			  WHERE symbol = $1 AND status IN ('pending', 'open', 'partially_filled')
+			    AND (cardinality($2::text[]) = 0 OR type = ANY($2))`
+	return cancelSelected(tx, reason, query, symbol, pq.Array(append([]string{}, orderTypes...)))
+}
+
+func formatPrice(price *float64) string {
+	if price == nil {
+		return "no price"
+	}
+	return strconv.FormatFloat(*price, 'f', -1, 64)
+}
+
+func formatQuantity(quantity float64) string {
+	return strconv.FormatFloat(quantity, 'f', -1, 64)
+}
@@ -0,0 +1,71 @@
+package engine
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/bartick/golang-order-matching-system/models"
+	"github.com/google/uuid"
+)
+
+// settleTrade updates the positions of the accounts of a trade's orders
+// and moves its assets between them: the buyer receives the base asset and
+// pays the quote asset, the seller the other way round. Futures only change
+// positions. Orders without an account settle nothing.
+func settleTrade(tx *sql.Tx, instrument *models.Instrument, trade *models.Trade, buy, sell *models.Order) error {
+	if err := settlePositions(tx, instrument, trade, buy, sell); err != nil {
+		return err
+	}
+	if instrument.Type == InstrumentFuture {
+		return nil
+	}
+
+	notional := RoundQuantity(trade.Price * trade.Quantity)
+	for _, movement := range []struct {
+		accountID *uuid.UUID
+		asset     string
+		amount    float64
+	}{
+		{buy.AccountID, instrument.BaseAsset, trade.Quantity},
+		{buy.AccountID, instrument.QuoteAsset, -notional},
+		{sell.AccountID, instrument.BaseAsset, -trade.Quantity},
+		{sell.AccountID, instrument.QuoteAsset, notional},
+	} {
+		if movement.accountID == nil {
+			continue
+		}
+		if err := adjustBalance(tx, *movement.accountID, movement.asset, movement.amount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func adjustBalance(tx *sql.Tx, accountID uuid.UUID, asset string, amount float64) error {
+	query := `INSERT INTO balances (account_id, asset, amount) VALUES ($1, $2, $3)
+			  ON CONFLICT (account_id, asset) DO UPDATE SET amount = balances.amount + EXCLUDED.amount`
+	if _, err := tx.Exec(query, accountID, asset, amount); err != nil {
+		return fmt.Errorf("failed to settle %s balance: %w", asset, err)
+	}
+	return nil
+}
+
+// GetBalances returns the balances of an account, by asset.
+func (e *Engine) GetBalances(accountID uuid.UUID) ([]models.Balance, error) {
+	query := `SELECT account_id, asset, amount, updated_at FROM balances WHERE account_id = $1 ORDER BY asset`
+	rows, err := e.db.Query(query, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load balances: %w", err)
+	}
+	defer rows.Close()
+
+	balances := []models.Balance{}
+	for rows.Next() {
+		var balance models.Balance
+		if err := rows.Scan(&balance.AccountID, &balance.Asset, &balance.Amount, &balance.UpdatedAt); err != nil {
+			return nil, err
+		}
+		balances = append(balances, balance)
+	}
+	return balances, rows.Err()
+}
@@ -0,0 +1,397 @@
+package engine
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bartick/golang-order-matching-system/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// BatchResult is the outcome of one order of a batch. Exactly one of Order
+// and Err is set.
+type BatchResult struct {
+	Order  *models.Order
+	Trades []models.Trade
+	Err    error
+
+	// settled holds the trades of the stops and pegs the order set off.
+	settled []models.Trade
+}
+
+// CancelFilter selects the open orders to mass cancel: those of an account,
+// those that came in through one source, or both. Empty Symbol and Side
+// match everything.
+type CancelFilter struct {
+	AccountID *uuid.UUID
+	Source    string
+	Symbol    string
+	Side      string
+
+	// Reason is recorded in the order events; it defaults to
+	// ReasonMassCancel.
+	Reason string
+
+	// Actor is recorded in the order events as who canceled the orders. It
+	// defaults to the monitor behind the reason, or else to the account or
+	// the source of the filter.
+	Actor string
+}
+
+func (f CancelFilter) actor() string {
+	switch {
+	case f.Actor != "":
+		return f.Actor
+	case f.Reason == ReasonDeadMansSwitch:
+		return ActorDeadMansSwitch
+	case f.Reason == ReasonLiquidation:
+		return ActorMarginMonitor
+	case f.AccountID != nil:
+		return AccountActor(*f.AccountID)
+	}
+	return f.Source
+}
+
+// PlaceOrders places a batch of orders as a single command. Every symbol of
+// the batch is sequenced up front, so no other command can interleave with
+// the batch, and orders are matched in the order they were given.
+//
+// When allOrNone is false each order succeeds or fails on its own. When it
+// is true, any failure rejects the whole batch: nothing is stored, the
+// results carry the errors of the failed orders and ErrBatchRejected is
+// returned.
+func (e *Engine) PlaceOrders(ctx context.Context, reqs []OrderRequest, allOrNone bool) (_ []BatchResult, err error) {
+	ctx, span := startSpan(ctx, "PlaceOrders", attribute.Int("oms.orders", len(reqs)), attribute.Bool("oms.all_or_none", allOrNone))
+	defer func() { endSpan(span, err) }()
+
+	if err := e.checkBatchSize(len(reqs)); err != nil {
+		return nil, err
+	}
+
+	results := make([]BatchResult, len(reqs))
+	defer func() {
+		for i := range results {
+			if results[i].Err != nil {
+				e.recordRejection(nil, reqs[i].Symbol, EventRejected, orderActor(&reqs[i]), results[i].Err, reqs[i])
+			}
+		}
+	}()
+	symbols := make([]string, 0, len(reqs))
+	rejected := false
+	valid := make([]bool, len(reqs))
+	ratios := make(map[uuid.UUID]error)
+	for i := range reqs {
+		err := validateOrderRequest(&reqs[i])
+		if err == nil {
+			err = e.checkOrderLimits(reqs[i].Symbol, reqs[i].Quantity, reqs[i].Price)
+		}
+		if accountID := reqs[i].AccountID; err == nil && accountID != nil {
+			// The ratio is checked once per account of the batch
+			ratio, checked := ratios[*accountID]
+			if !checked {
+				ratio = e.checkOrderToTradeRatio(accountID)
+				ratios[*accountID] = ratio
+			}
+			err = ratio
+		}
+		if err != nil {
+			symbol, side, orderType := e.rejectionLabels(&reqs[i])
+			e.metrics.OrderPlaced(symbol, side, orderType, OrderRejected)
+			logOrder(orderLogger(ctx, &reqs[i]), &reqs[i], nil, nil, err)
+			results[i].Err = err
+			rejected = true
+			continue
+		}
+		valid[i] = true
+		symbols = append(symbols, reqs[i].Symbol)
+	}
+	if allOrNone && rejected {
+		return results, ErrBatchRejected
+	}
+
+	tx, err := e.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := sequenceCommand(tx, symbols...); err != nil {
+		return nil, err
+	}
+
+	for i, req := range reqs {
+		if results[i].Err != nil {
+			continue
+		}
+		if err := setActor(tx, orderActor(&req)); err != nil {
+			return nil, err
+		}
+
+		if allOrNone {
+			result, err := placeBatchOrder(ctx, tx, req)
+			if err != nil {
+				e.metrics.OrderPlaced(req.Symbol, req.Side, req.Type, orderResult(err))
+				logOrder(orderLogger(ctx, &req), &req, nil, nil, err)
+				return rejectBatch(results, i, err), ErrBatchRejected
+			}
+			results[i] = result
+			continue
+		}
+
+		// A savepoint per order keeps a failed insert from aborting the
+		// rest of the batch.
+		if _, err := tx.Exec(`SAVEPOINT batch_order`); err != nil {
+			return nil, err
+		}
+		result, err := placeBatchOrder(ctx, tx, req)
+		if err != nil {
+			if _, rbErr := tx.Exec(`ROLLBACK TO SAVEPOINT batch_order`); rbErr != nil {
+				return nil, rbErr
+			}
+			results[i].Err = err
+			continue
+		}
+		if _, err := tx.Exec(`RELEASE SAVEPOINT batch_order`); err != nil {
+			return nil, err
+		}
+		results[i] = result
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	for i, result := range results {
+		if valid[i] {
+			e.metrics.OrderPlaced(reqs[i].Symbol, reqs[i].Side, reqs[i].Type, orderResult(result.Err))
+			logOrder(orderLogger(ctx, &reqs[i]), &reqs[i], result.Order, result.Trades, result.Err)
+		}
+	}
+	for _, result := range results {
+		if result.Order != nil {
+			e.publishTrades(result.Order.Symbol, result.Order.ID, result.Trades)
+			e.publishTrades(result.Order.Symbol, uuid.Nil, result.settled)
+		}
+	}
+	for _, symbol := range uniqueSymbols(symbols) {
+		e.publishOrderBook(symbol)
+	}
+
+	return results, nil
+}
+
+func placeBatchOrder(ctx context.Context, tx *sql.Tx, req OrderRequest) (BatchResult, error) {
+	order, trades, err := placeOrder(ctx, tx, req)
+	if err != nil {
+		return BatchResult{}, err
+	}
+	settled, err := settleBook(ctx, tx, order.Symbol, trades)
+	if err != nil {
+		return BatchResult{}, err
+	}
+	return BatchResult{Order: order, Trades: trades, settled: settled}, nil
+}
+
+// rejectBatch reports the failure of order i of an all-or-none batch. The
+// orders placed before it are rolled back, so their results are cleared.
+func rejectBatch(results []BatchResult, i int, err error) []BatchResult {
+	for j := range results {
+		results[j] = BatchResult{}
+	}
+	results[i].Err = err
+	return results
+}
+
+// CancelOrders cancels every open order matching the filter as a single
+// command and returns the canceled orders.
+func (e *Engine) CancelOrders(filter CancelFilter) ([]models.Order, error) {
+	if filter.AccountID == nil && filter.Source == "" {
+		return nil, newValidationError("an account or a source is required")
+	}
+	return e.cancelOrders(filter)
+}
+
+func (e *Engine) cancelOrders(filter CancelFilter) ([]models.Order, error) {
+	if filter.Side != "" && filter.Side != "buy" && filter.Side != "sell" {
+		return nil, newValidationError("side must be 'buy' or 'sell'")
+	}
+	symbol := strings.ToUpper(filter.Symbol)
+	reason := filter.Reason
+	if reason == "" {
+		reason = ReasonMassCancel
+	}
+
+	tx, err := e.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	symbols, err := querySymbols(tx, `SELECT DISTINCT symbol FROM orders
+		WHERE ($1::uuid IS NULL OR account_id = $1) AND ($2 = '' OR source = $2)
+		  AND status IN ('pending', 'open', 'partially_filled')
+		  AND ($3 = '' OR symbol = $3) AND ($4 = '' OR side = $4)`,
+		filter.AccountID, filter.Source, symbol, filter.Side)
+	if err != nil {
+		return nil, err
+	}
+	if len(symbols) == 0 {
+		return []models.Order{}, nil
+	}
+
+	if err := sequenceCommand(tx, symbols...); err != nil {
+		return nil, err
+	}
+	if err := setActor(tx, filter.actor()); err != nil {
+		return nil, err
+	}
+
+	// Only the symbols sequenced above are touched; orders on other books
+	// that were placed in the meantime are left alone.
+	query := `SELECT ` + orderColumns + ` FROM orders
+			  WHERE ($1::uuid IS NULL OR account_id = $1) AND ($2 = '' OR source = $2)
+			    AND status IN ('pending', 'open', 'partially_filled')
+			    AND symbol = ANY($3) AND ($4 = '' OR side = $4)`
+	orders, err := cancelSelected(tx, reason, query, filter.AccountID, filter.Source, pq.Array(symbols), filter.Side)
+	if err != nil {
+		return nil, err
+	}
+
+	settled, err := settleBooks(tx, symbols)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	for i := range orders {
+		e.publishCancel(&orders[i])
+	}
+	e.publishSettled(symbols, settled)
+	return orders, nil
+}
+
+// CancelOrdersByID cancels a batch of orders on behalf of actor as a single
+// command. Each order succeeds or fails on its own; the results are in the
+// order of ids. Like CancelOrder, a non-nil accountID only cancels the
+// orders of that account.
+func (e *Engine) CancelOrdersByID(ids []uuid.UUID, accountID *uuid.UUID, actor string) ([]BatchResult, error) {
+	if err := e.checkBatchSize(len(ids)); err != nil {
+		return nil, err
+	}
+
+	tx, err := e.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	idStrings := make([]string, len(ids))
+	for i, id := range ids {
+		idStrings[i] = id.String()
+	}
+	symbols, err := querySymbols(tx, `SELECT DISTINCT symbol FROM orders
+		WHERE id = ANY($1::uuid[]) AND ($2::uuid IS NULL OR account_id = $2)`, pq.Array(idStrings), accountID)
+	if err != nil {
+		return nil, err
+	}
+	if err := sequenceCommand(tx, symbols...); err != nil {
+		return nil, err
+	}
+	if err := setActor(tx, actor); err != nil {
+		return nil, err
+	}
+
+	results := make([]BatchResult, len(ids))
+	for i, id := range ids {
+		order, err := cancelOrder(tx, id, accountID, ReasonUserRequest)
+		var validationErr *ValidationError
+		if err == ErrOrderNotFound || err == ErrOrderNotCancelable || errors.As(err, &validationErr) {
+			results[i].Err = err
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		results[i].Order = order
+	}
+
+	settled, err := settleBooks(tx, symbols)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	e.publishSettled(symbols, settled)
+	return results, nil
+}
+
+// settleBooks settles every book touched by a command that did not trade
+// by itself, such as a cancel, and returns the resulting trades by symbol.
+func settleBooks(tx *sql.Tx, symbols []string) (map[string][]models.Trade, error) {
+	settled := make(map[string][]models.Trade)
+	for _, symbol := range symbols {
+		trades, err := settleBook(context.Background(), tx, symbol, nil)
+		if err != nil {
+			return nil, err
+		}
+		settled[symbol] = trades
+	}
+	return settled, nil
+}
+
+func (e *Engine) publishSettled(symbols []string, settled map[string][]models.Trade) {
+	for _, symbol := range symbols {
+		e.publish(symbol, uuid.Nil, settled[symbol])
+	}
+}
+
+func (e *Engine) checkBatchSize(size int) error {
+	if size == 0 {
+		return newValidationError("batch must contain at least one command")
+	}
+	if maxSize := e.maxBatchSize(); size > maxSize {
+		return newValidationError(fmt.Sprintf("batch must contain at most %d commands", maxSize))
+	}
+	return nil
+}
+
+func querySymbols(tx *sql.Tx, query string, args ...interface{}) ([]string, error) {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var symbols []string
+	for rows.Next() {
+		var symbol string
+		if err := rows.Scan(&symbol); err != nil {
+			return nil, err
+		}
+		symbols = append(symbols, symbol)
+	}
+	return symbols, rows.Err()
+}
+
+func uniqueSymbols(symbols []string) []string {
+	seen := make(map[string]bool, len(symbols))
+	unique := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		if !seen[symbol] {
+			seen[symbol] = true
+			unique = append(unique, symbol)
+		}
+	}
+	return unique
+}
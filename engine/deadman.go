@@ -0,0 +1,87 @@
+package engine
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// MaxCancelAllAfter bounds how far in the future a dead man's switch may
+// be set.
+const MaxCancelAllAfter = time.Hour
+
+// deadMansSwitches holds the armed switches, one per account or source.
+type deadMansSwitches struct {
+	mu       sync.Mutex
+	switches map[string]*deadMansSwitch
+}
+
+type deadMansSwitch struct {
+	filter CancelFilter
+	timer  *time.Timer
+}
+
+func newDeadMansSwitches() *deadMansSwitches {
+	return &deadMansSwitches{switches: make(map[string]*deadMansSwitch)}
+}
+
+// CancelAllAfter arms a dead man's switch: unless it is called again
+// within timeout, every open order matching filter is canceled with the
+// reason ReasonDeadMansSwitch. There is one switch per account (or per
+// source when no account is given); calling CancelAllAfter again replaces
+// its filter and deadline, and a zero timeout disarms it. The returned
+// deadline is the zero time when the switch is disarmed.
+func (e *Engine) CancelAllAfter(filter CancelFilter, timeout time.Duration) (time.Time, error) {
+	if filter.AccountID == nil && filter.Source == "" {
+		return time.Time{}, newValidationError("an account or a source is required")
+	}
+	if timeout < 0 || timeout > MaxCancelAllAfter {
+		return time.Time{}, newValidationError(fmt.Sprintf("timeout must be between 0 and %s", MaxCancelAllAfter))
+	}
+
+	key := "source:" + filter.Source
+	if filter.AccountID != nil {
+		key = "account:" + filter.AccountID.String()
+	}
+	filter.Reason = ReasonDeadMansSwitch
+
+	d := e.deadMansSwitches
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if armed, ok := d.switches[key]; ok {
+		armed.timer.Stop()
+		delete(d.switches, key)
+	}
+	if timeout == 0 {
+		return time.Time{}, nil
+	}
+
+	armed := &deadMansSwitch{filter: filter}
+	armed.timer = time.AfterFunc(timeout, func() {
+		e.fireDeadMansSwitch(key, armed)
+	})
+	d.switches[key] = armed
+
+	return time.Now().Add(timeout), nil
+}
+
+func (e *Engine) fireDeadMansSwitch(key string, armed *deadMansSwitch) {
+	d := e.deadMansSwitches
+	d.mu.Lock()
+	if d.switches[key] != armed {
+		// Re-armed or disarmed while the timer was firing.
+		d.mu.Unlock()
+		return
+	}
+	delete(d.switches, key)
+	d.mu.Unlock()
+
+	orders, err := e.CancelOrders(armed.filter)
+	if err != nil {
+		slog.Error("dead man's switch failed to cancel orders", "key", key, "error", err)
+		return
+	}
+	slog.Info("dead man's switch fired", "key", key, "canceled", len(orders))
+}
@@ -0,0 +1,42 @@
+package engine
+
+import (
+	"github.com/jmoiron/sqlx"
+)
+
+// Engine owns the order placement and matching path. Every order entry
+// protocol (HTTP, gRPC, ...) goes through the same Engine so that
+// validation, matching and market data publication stay identical.
+type Engine struct {
+	db                *sqlx.DB
+	marketData        *MarketData
+	deadMansSwitches  *deadMansSwitches
+	schedule          *Schedule
+	haltMonitorStop   chan struct{}
+	expiryMonitorStop chan struct{}
+	marginMonitorStop chan struct{}
+	metrics           Metrics
+	heartbeats        *heartbeats
+	limits            limits
+}
+
+const defaultMaxBatchSize = 50
+
+func NewEngine(db *sqlx.DB) *Engine {
+	return &Engine{
+		db:               db,
+		marketData:       NewMarketData(),
+		deadMansSwitches: newDeadMansSwitches(),
+		metrics:          noMetrics{},
+		heartbeats:       newHeartbeats(),
+		limits:           limits{maxBatchSize: defaultMaxBatchSize},
+	}
+}
+
+func (e *Engine) DB() *sqlx.DB {
+	return e.db
+}
+
+func (e *Engine) MarketData() *MarketData {
+	return e.marketData
+}
@@ -0,0 +1,39 @@
+package engine
+
+import "errors"
+
+var (
+	ErrOrderNotFound      = errors.New("order not found")
+	ErrOrderNotCancelable = errors.New("cannot cancel filled or already canceled order")
+	ErrOrderNotAmendable  = errors.New("cannot amend filled, canceled, market or stop order")
+	ErrAccountNotFound    = errors.New("account not found")
+	ErrAdminNotFound      = errors.New("admin not found")
+	ErrBatchRejected      = errors.New("batch rejected: at least one order failed")
+	ErrAuctionInProgress  = errors.New("symbol is already in an auction call phase")
+	ErrNoAuction          = errors.New("symbol is not in an auction call phase")
+	ErrNotAFuture         = errors.New("symbol is not a future")
+	ErrNoSettlementPrice  = errors.New("no trades to compute a settlement price from")
+	ErrPositionNotFound   = errors.New("position not found")
+
+	// ErrPostOnlyWouldCross is a ValidationError so that every entry point
+	// reports it like any other rejected order.
+	ErrPostOnlyWouldCross = newValidationError("post-only order would cross the book")
+
+	// ErrOrderToTradeRatio is returned for the orders of an account that
+	// placed too many orders per trade recently.
+	ErrOrderToTradeRatio = newValidationError("order-to-trade ratio exceeded: too many orders without trades")
+)
+
+// ValidationError is returned when a request is rejected before it reaches
+// the database. Its message is safe to return to clients as is.
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+func newValidationError(message string) error {
+	return &ValidationError{Message: message}
+}
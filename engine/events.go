@@ -0,0 +1,223 @@
+package engine
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/bartick/golang-order-matching-system/models"
+	"github.com/google/uuid"
+)
+
+// Order event types.
+const (
+	EventAccepted      = "accepted"
+	EventRejected      = "rejected"
+	EventFill          = "fill"
+	EventAmended       = "amended"
+	EventAmendRejected = "amend_rejected"
+	EventRepriced      = "repriced"
+	EventCanceled      = "canceled"
+	EventExpired       = "expired"
+	EventTriggered     = "triggered"
+	EventTrailAdjusted = "trail_adjusted"
+)
+
+// Reasons recorded with order events.
+const (
+	ReasonUserRequest    = "user_request"
+	ReasonMassCancel     = "mass_cancel"
+	ReasonDeadMansSwitch = "dead_mans_switch"
+	ReasonAdmin          = "admin"
+	ReasonPostOnly       = "post_only"
+	ReasonPeg            = "peg"
+
+	// ReasonMarketRemainder is recorded when what a market order could not
+	// fill, because the book ran out or the symbol was halted, expires.
+	ReasonMarketRemainder = "market_remainder"
+)
+
+// Actors recorded with order events besides the accounts and the gateways
+// (see orderActor). The engine is the actor of whatever it does on its own,
+// such as triggering stops.
+const (
+	ActorEngine         = "engine"
+	ActorMarginMonitor  = "margin_monitor"
+	ActorDeadMansSwitch = "dead_mans_switch"
+)
+
+func AccountActor(accountID uuid.UUID) string {
+	return "account:" + accountID.String()
+}
+
+func AdminActor(name string) string {
+	return "admin:" + name
+}
+
+// orderActor is the actor of the commands of an order request: the actor it
+// names, its account, or else the gateway it came through.
+func orderActor(req *OrderRequest) string {
+	switch {
+	case req.Actor != "":
+		return req.Actor
+	case req.Liquidation:
+		return ActorMarginMonitor
+	case req.AccountID != nil:
+		return AccountActor(*req.AccountID)
+	case req.Source != "":
+		return req.Source
+	}
+	return ActorEngine
+}
+
+// setActor sets who the order events recorded by the rest of the
+// transaction are attributed to.
+func setActor(tx *sql.Tx, actor string) error {
+	if _, err := tx.Exec(`SELECT set_config('oms.actor', $1, true)`, actor); err != nil {
+		return fmt.Errorf("failed to set actor: %w", err)
+	}
+	return nil
+}
+
+// orderState is what order events record of an order before and after
+// them.
+type orderState struct {
+	Status            string   `json:"status"`
+	Price             *float64 `json:"price,omitempty"`
+	InitialQuantity   float64  `json:"initial_quantity"`
+	RemainingQuantity float64  `json:"remaining_quantity"`
+	VisibleQuantity   *float64 `json:"visible_quantity,omitempty"`
+	StopPrice         *float64 `json:"stop_price,omitempty"`
+	ClientOrderID     *string  `json:"client_order_id,omitempty"`
+}
+
+// stateOf takes a copy of the state of an order, which does not change
+// with the order.
+func stateOf(order *models.Order) orderState {
+	return orderState{
+		Status:            order.Status,
+		Price:             copyPointer(order.Price),
+		InitialQuantity:   order.InitialQuantity,
+		RemainingQuantity: order.RemainingQuantity,
+		VisibleQuantity:   copyPointer(order.VisibleQuantity),
+		StopPrice:         copyPointer(order.StopPrice),
+		ClientOrderID:     copyPointer(order.ClientOrderID),
+	}
+}
+
+func copyPointer[T any](value *T) *T {
+	if value == nil {
+		return nil
+	}
+	v := *value
+	return &v
+}
+
+// recordOrderEvent appends an event to the history of an order, with the
+// state of the order before the event (nil for its first event) and after
+// it. details, if not nil, is stored as JSON. The event is attributed to
+// the actor set on the transaction, or to the engine.
+func recordOrderEvent(tx *sql.Tx, order *models.Order, eventType, reason string, before *orderState, details interface{}) error {
+	var beforeJSON sql.NullString
+	if before != nil {
+		var err error
+		if beforeJSON, err = jsonValue(*before); err != nil {
+			return err
+		}
+	}
+	afterJSON, err := jsonValue(stateOf(order))
+	if err != nil {
+		return err
+	}
+	detailsJSON, err := jsonValue(details)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO order_events (order_id, symbol, sequence, event_type, reason, actor, before_values, after_values, details)
+			  VALUES ($1, $2, (SELECT last_sequence FROM symbol_sequences WHERE symbol = $2), $3, NULLIF($4, ''),
+					  COALESCE(NULLIF(current_setting('oms.actor', true), ''), 'engine'), $5, $6, $7)`
+	if _, err := tx.Exec(query, order.ID, order.Symbol, eventType, reason, beforeJSON, afterJSON, detailsJSON); err != nil {
+		return fmt.Errorf("failed to record order event: %w", err)
+	}
+	return nil
+}
+
+// recordFill records the fill of an order by a trade.
+func recordFill(tx *sql.Tx, order *models.Order, before orderState, trade *models.Trade) error {
+	details := map[string]interface{}{"trade_id": trade.ID, "price": trade.Price, "quantity": trade.Quantity}
+	return recordOrderEvent(tx, order, EventFill, "", &before, details)
+}
+
+// cancelEvent is the event type of a cancel for the given reason: orders
+// canceled because their instrument expired expire rather than cancel.
+func cancelEvent(reason string) string {
+	if reason == ReasonInstrumentExpired {
+		return EventExpired
+	}
+	return EventCanceled
+}
+
+// recordRejection records a request the engine rejected. Its transaction
+// was rolled back, so the event is recorded on its own, and failing to do
+// so is only logged. Only validation errors are recorded: other errors are
+// failures of the engine, not rejects. orderID is nil for a new order.
+func (e *Engine) recordRejection(orderID *uuid.UUID, symbol, eventType, actor string, err error, request interface{}) {
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) && !errors.Is(err, ErrOrderNotAmendable) {
+		return
+	}
+
+	details, jsonErr := jsonValue(map[string]interface{}{"error": err.Error(), "request": request})
+	if jsonErr != nil {
+		slog.Error("failed to record rejection", "event_type", eventType, "error", jsonErr)
+		return
+	}
+	// A rejected command is not sequenced, so the event has no sequence
+	query := `INSERT INTO order_events (order_id, symbol, event_type, actor, details)
+			  VALUES ($1, NULLIF($2, ''), $3, $4, $5)`
+	if _, dbErr := e.db.Exec(query, orderID, symbol, eventType, actor, details); dbErr != nil {
+		slog.Error("failed to record rejection", "event_type", eventType, "error", dbErr)
+	}
+}
+
+// GetOrderHistory returns every event of an order, oldest first. With an
+// accountID, orders of other accounts are not found.
+func (e *Engine) GetOrderHistory(orderID uuid.UUID, accountID *uuid.UUID) ([]models.OrderEvent, error) {
+	if _, err := orderSymbol(e.db, orderID, accountID); err != nil {
+		return nil, err
+	}
+
+	events := []models.OrderEvent{}
+	err := e.db.Select(&events, `SELECT id, order_id, symbol, sequence, event_type, reason, actor,
+			before_values, after_values, details, created_at
+		FROM order_events WHERE order_id = $1 ORDER BY id`, orderID)
+	return events, err
+}
+
+// SourceOrderEvents returns the events of the orders placed through source
+// that were recorded on symbol by the commands with sequence numbers after
+// after and up to through, in the order they were recorded.
+func (e *Engine) SourceOrderEvents(source, symbol string, after, through int64) ([]models.OrderEvent, error) {
+	events := []models.OrderEvent{}
+	err := e.db.Select(&events, `SELECT e.id, e.order_id, e.symbol, e.sequence, e.event_type, e.reason, e.actor,
+			e.before_values, e.after_values, e.details, e.created_at
+		FROM order_events e JOIN orders o ON o.id = e.order_id
+		WHERE e.symbol = $1 AND e.sequence > $2 AND e.sequence <= $3 AND o.source = $4
+		ORDER BY e.sequence, e.id`, symbol, after, through, source)
+	return events, err
+}
+
+// jsonValue stores value as JSON, or as NULL when it is nil.
+func jsonValue(value interface{}) (sql.NullString, error) {
+	if value == nil {
+		return sql.NullString{}, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
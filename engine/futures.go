@@ -0,0 +1,261 @@
+package engine
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bartick/golang-order-matching-system/models"
+)
+
+// Instrument types.
+const (
+	InstrumentSpot   = "spot"
+	InstrumentFuture = "future"
+)
+
+// ReasonInstrumentExpired is recorded for the orders canceled when a future
+// is delisted.
+const ReasonInstrumentExpired = "instrument_expired"
+
+// defaultSettlementWindow is the closing window of futures without their
+// own: the daily settlement price is the VWAP of the trades in the window
+// ending with the last trade of the day.
+const defaultSettlementWindow = 30 * time.Minute
+
+// expiryMonitorInterval is how often futures are checked for the end of
+// their last trading day and their expiry.
+const expiryMonitorInterval = time.Minute
+
+// tradingOver reports whether a future is past its last trading day.
+func tradingOver(instrument *models.Instrument, now time.Time) bool {
+	return instrument.LastTradingDay != nil && now.Format(time.DateOnly) > instrument.LastTradingDay.Format(time.DateOnly)
+}
+
+// expired reports whether a future is past its expiry date.
+func expired(instrument *models.Instrument, now time.Time) bool {
+	return instrument.ExpiryDate != nil && now.Format(time.DateOnly) > instrument.ExpiryDate.Format(time.DateOnly)
+}
+
+// SetFuture makes a symbol a dated future: it trades until the end of
+// lastTradingDay, is delisted after expiryDate, and its positions are worth
+// multiplier times their quantity. settlementWindow is the length of the
+// closing window in minutes; nil keeps the engine default.
+func (e *Engine) SetFuture(symbol string, multiplier float64, expiryDate, lastTradingDay time.Time, settlementWindow *int) (*models.Instrument, error) {
+	if multiplier <= 0 {
+		return nil, newValidationError("contract_multiplier must be positive")
+	}
+	if lastTradingDay.After(expiryDate) {
+		return nil, newValidationError("last_trading_day must not be after expiry_date")
+	}
+	if settlementWindow != nil && *settlementWindow < 1 {
+		return nil, newValidationError("settlement_window_minutes must be at least 1")
+	}
+
+	symbol = strings.ToUpper(symbol)
+	query := `INSERT INTO instruments (symbol, instrument_type, contract_multiplier, expiry_date, last_trading_day, settlement_window_minutes)
+			  VALUES ($1, 'future', $2, $3, $4, $5)
+			  ON CONFLICT (symbol) DO UPDATE
+			  SET instrument_type = EXCLUDED.instrument_type, contract_multiplier = EXCLUDED.contract_multiplier,
+				  expiry_date = EXCLUDED.expiry_date, last_trading_day = EXCLUDED.last_trading_day,
+				  settlement_window_minutes = EXCLUDED.settlement_window_minutes`
+	_, err := e.db.Exec(query, symbol, multiplier, expiryDate.Format(time.DateOnly), lastTradingDay.Format(time.DateOnly), settlementWindow)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set future: %w", err)
+	}
+	return e.GetInstrument(symbol)
+}
+
+// SettleFuture computes and stores the settlement price of a future for a
+// trading day. It is also computed when the future closes.
+func (e *Engine) SettleFuture(symbol string, day time.Time) (*models.SettlementPrice, error) {
+	tx, err := e.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	instrument, err := getInstrument(tx, strings.ToUpper(symbol))
+	if err != nil {
+		return nil, err
+	}
+	if instrument.Type != InstrumentFuture {
+		return nil, ErrNotAFuture
+	}
+	settlement, err := settleDay(tx, instrument, day)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return settlement, nil
+}
+
+// settleDay computes the settlement price of a future for the trading day
+// of day: the VWAP of its trades in the closing window, or the previous
+// settlement price when it did not trade that day.
+func settleDay(tx *sql.Tx, instrument *models.Instrument, day time.Time) (*models.SettlementPrice, error) {
+	window := defaultSettlementWindow
+	if instrument.SettlementWindowMinutes != nil {
+		window = time.Duration(*instrument.SettlementWindowMinutes) * time.Minute
+	}
+	date := day.Format(time.DateOnly)
+
+	var price, volume sql.NullFloat64
+	var windowStart, windowEnd sql.NullTime
+	query := `WITH closing AS (
+				SELECT MAX(executed_at) AS window_end FROM trades WHERE symbol = $1 AND executed_at::date = $2::date
+			  )
+			  SELECT SUM(t.price * t.quantity) / SUM(t.quantity), SUM(t.quantity),
+					 closing.window_end - make_interval(secs => $3), closing.window_end
+			  FROM closing
+			  LEFT JOIN trades t ON t.symbol = $1
+				AND t.executed_at > closing.window_end - make_interval(secs => $3) AND t.executed_at <= closing.window_end
+			  GROUP BY closing.window_end`
+	err := tx.QueryRow(query, instrument.Symbol, date, window.Seconds()).Scan(&price, &volume, &windowStart, &windowEnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute settlement price: %w", err)
+	}
+
+	settlement := &models.SettlementPrice{Symbol: instrument.Symbol}
+	if price.Valid {
+		settlement.Price = roundToTick(price.Float64, instrument.PricePrecision)
+		settlement.Volume = RoundQuantity(volume.Float64)
+		settlement.WindowStart = &windowStart.Time
+		settlement.WindowEnd = &windowEnd.Time
+	} else {
+		query := `SELECT price FROM settlement_prices WHERE symbol = $1 AND trading_date < $2::date
+				  ORDER BY trading_date DESC LIMIT 1`
+		err := tx.QueryRow(query, instrument.Symbol, date).Scan(&settlement.Price)
+		if err == sql.ErrNoRows {
+			return nil, ErrNoSettlementPrice
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to load previous settlement price: %w", err)
+		}
+	}
+
+	query = `INSERT INTO settlement_prices (symbol, trading_date, price, volume, window_start, window_end)
+			 VALUES ($1, $2::date, $3, $4, $5, $6)
+			 ON CONFLICT (symbol, trading_date) DO UPDATE
+			 SET price = EXCLUDED.price, volume = EXCLUDED.volume, window_start = EXCLUDED.window_start,
+				 window_end = EXCLUDED.window_end, created_at = CURRENT_TIMESTAMP
+			 RETURNING trading_date, created_at`
+	err = tx.QueryRow(query, instrument.Symbol, date, settlement.Price, settlement.Volume, settlement.WindowStart, settlement.WindowEnd).
+		Scan(&settlement.TradingDate, &settlement.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store settlement price: %w", err)
+	}
+
+	slog.Info("future settled", "symbol", instrument.Symbol, "date", date, "price", settlement.Price)
+	return settlement, nil
+}
+
+// GetSettlementPrices returns the daily settlement prices of a future, most
+// recent first.
+func (e *Engine) GetSettlementPrices(symbol string) ([]models.SettlementPrice, error) {
+	query := `SELECT symbol, trading_date, price, volume, window_start, window_end, created_at
+			  FROM settlement_prices WHERE symbol = $1 ORDER BY trading_date DESC`
+	rows, err := e.db.Query(query, strings.ToUpper(symbol))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load settlement prices: %w", err)
+	}
+	defer rows.Close()
+
+	settlements := []models.SettlementPrice{}
+	for rows.Next() {
+		var settlement models.SettlementPrice
+		err := rows.Scan(&settlement.Symbol, &settlement.TradingDate, &settlement.Price, &settlement.Volume,
+			&settlement.WindowStart, &settlement.WindowEnd, &settlement.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		settlements = append(settlements, settlement)
+	}
+	return settlements, rows.Err()
+}
+
+// StartExpiryMonitor closes futures after their last trading day and
+// delists them after their expiry until StopExpiryMonitor is called.
+func (e *Engine) StartExpiryMonitor() {
+	e.expiryMonitorStop = make(chan struct{})
+	e.heartbeats.start(MonitorExpiries, expiryMonitorInterval)
+
+	go func() {
+		ticker := time.NewTicker(expiryMonitorInterval)
+		defer ticker.Stop()
+
+		for {
+			if err := e.checkExpiries(); err != nil {
+				slog.Error("failed to check future expiries", "error", err)
+			}
+			e.heartbeats.beat(MonitorExpiries)
+
+			select {
+			case <-e.expiryMonitorStop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (e *Engine) StopExpiryMonitor() {
+	if e.expiryMonitorStop != nil {
+		close(e.expiryMonitorStop)
+		e.heartbeats.stop(MonitorExpiries)
+	}
+}
+
+// checkExpiries moves the futures that stopped trading to the closed state,
+// or to the delisted state once they expired.
+func (e *Engine) checkExpiries() error {
+	rows, err := e.db.Query(`SELECT symbol FROM instruments WHERE instrument_type = 'future' AND state <> 'delisted'`)
+	if err != nil {
+		return err
+	}
+	var symbols []string
+	for rows.Next() {
+		var symbol string
+		if err := rows.Scan(&symbol); err != nil {
+			rows.Close()
+			return err
+		}
+		symbols = append(symbols, symbol)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, symbol := range symbols {
+		instrument, err := e.GetInstrument(symbol)
+		if err != nil {
+			return err
+		}
+		if !tradingOver(instrument, now) {
+			continue
+		}
+
+		// The expiry keeps the symbol's override, if any.
+		change, err := e.transition(symbol, instrument.Manual, func(instrument *models.Instrument) (string, string, error) {
+			if expired(instrument, now) {
+				return StateDelisted, "", nil
+			}
+			return StateClosed, "", nil
+		})
+		if err != nil {
+			slog.Error("failed to expire future", "symbol", symbol, "error", err)
+			continue
+		}
+		if change != nil {
+			slog.Info("future state changed", "symbol", symbol, "state", change.instrument.State)
+		}
+	}
+	return nil
+}
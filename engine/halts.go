@@ -0,0 +1,254 @@
+package engine
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bartick/golang-order-matching-system/models"
+)
+
+// AuctionReopening is the auction that resumes trading after a volatility
+// halt.
+const AuctionReopening = "reopening"
+
+// Causes recorded with trading halts.
+const (
+	HaltCauseVolatility = "volatility"
+	HaltCauseManual     = "manual"
+)
+
+// Circuit breaker defaults for instruments without their own settings.
+const (
+	defaultPriceBandPercent = 5.0
+	defaultVolatilityHalt   = 5 * time.Minute
+)
+
+// haltMonitorInterval is how often volatility halts are checked for their
+// end. It also bounds how late a halt is announced to market data clients,
+// as the halt happens inside the command that caused it.
+const haltMonitorInterval = time.Second
+
+// priceBand is the range trades of a symbol may execute in, around the
+// last trade price.
+type priceBand struct {
+	instrument *models.Instrument
+	reference  float64
+	low, high  float64
+}
+
+func (b *priceBand) contains(price float64) bool {
+	return price >= b.low && price <= b.high
+}
+
+// loadPriceBand returns the price band of an instrument, or nil when its
+// circuit breaker is disabled or it has not traded yet.
+func loadPriceBand(tx *sql.Tx, instrument *models.Instrument) (*priceBand, error) {
+	percent := defaultPriceBandPercent
+	if instrument.PriceBandPercent != nil {
+		percent = *instrument.PriceBandPercent
+	}
+	if percent == 0 {
+		return nil, nil
+	}
+
+	reference, err := lastTradePrice(tx, instrument.Symbol)
+	if err != nil || reference == nil {
+		return nil, err
+	}
+	return &priceBand{
+		instrument: instrument,
+		reference:  *reference,
+		low:        roundToTick(*reference*(1-percent/100), instrument.PricePrecision),
+		high:       roundToTick(*reference*(1+percent/100), instrument.PricePrecision),
+	}, nil
+}
+
+// volatilityHalt stops trading in a symbol after order tried to trade at
+// price, outside the band. The symbol collects orders for a re-opening
+// auction until the halt ends.
+func volatilityHalt(tx *sql.Tx, band *priceBand, order *models.Order, price float64) error {
+	duration := defaultVolatilityHalt
+	if band.instrument.VolatilityHaltSeconds != nil {
+		duration = time.Duration(*band.instrument.VolatilityHaltSeconds) * time.Second
+	}
+
+	var endsAt time.Time
+	query := `INSERT INTO instruments (symbol, state, auction, halt_ends_at)
+			  VALUES ($1, 'auction', 'reopening', clock_timestamp() + make_interval(secs => $2))
+			  ON CONFLICT (symbol) DO UPDATE
+			  SET state = EXCLUDED.state, auction = EXCLUDED.auction, halt_ends_at = EXCLUDED.halt_ends_at
+			  RETURNING halt_ends_at`
+	if err := tx.QueryRow(query, order.Symbol, duration.Seconds()).Scan(&endsAt); err != nil {
+		return fmt.Errorf("failed to halt trading: %w", err)
+	}
+
+	return recordHalt(tx, &models.TradingHalt{
+		Symbol:         order.Symbol,
+		Cause:          HaltCauseVolatility,
+		OrderID:        &order.ID,
+		ReferencePrice: &band.reference,
+		TriggerPrice:   &price,
+		BandLow:        &band.low,
+		BandHigh:       &band.high,
+		EndsAt:         &endsAt,
+	})
+}
+
+func recordHalt(tx *sql.Tx, halt *models.TradingHalt) error {
+	query := `INSERT INTO trading_halts (symbol, cause, order_id, reference_price, trigger_price, band_low, band_high, ends_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+	_, err := tx.Exec(query, halt.Symbol, halt.Cause, halt.OrderID, halt.ReferencePrice, halt.TriggerPrice,
+		halt.BandLow, halt.BandHigh, halt.EndsAt)
+	if err != nil {
+		return fmt.Errorf("failed to record trading halt: %w", err)
+	}
+
+	if halt.TriggerPrice != nil {
+		slog.Warn("trading halted", "symbol", halt.Symbol, "cause", halt.Cause,
+			"trigger_price", halt.TriggerPrice, "band_low", halt.BandLow, "band_high", halt.BandHigh)
+	} else {
+		slog.Warn("trading halted", "symbol", halt.Symbol, "cause", halt.Cause)
+	}
+	return nil
+}
+
+// GetTradingHalts returns the halts of a symbol, most recent first.
+func (e *Engine) GetTradingHalts(symbol string) ([]models.TradingHalt, error) {
+	query := `SELECT id, symbol, cause, order_id, reference_price, trigger_price, band_low, band_high, started_at, ends_at
+			  FROM trading_halts WHERE symbol = $1 ORDER BY started_at DESC, id DESC`
+	rows, err := e.db.Query(query, strings.ToUpper(symbol))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load trading halts: %w", err)
+	}
+	defer rows.Close()
+
+	halts := []models.TradingHalt{}
+	for rows.Next() {
+		var halt models.TradingHalt
+		err := rows.Scan(&halt.ID, &halt.Symbol, &halt.Cause, &halt.OrderID, &halt.ReferencePrice, &halt.TriggerPrice,
+			&halt.BandLow, &halt.BandHigh, &halt.StartedAt, &halt.EndsAt)
+		if err != nil {
+			return nil, err
+		}
+		halts = append(halts, halt)
+	}
+	return halts, rows.Err()
+}
+
+// SetPriceBand changes the circuit breaker of a symbol: the width of its
+// price band in percent of the last trade price (0 disables it) and how
+// long a volatility halt lasts. nil keeps the engine default.
+func (e *Engine) SetPriceBand(symbol string, percent *float64, haltSeconds *int) (*models.Instrument, error) {
+	if percent != nil && (*percent < 0 || *percent >= 100) {
+		return nil, newValidationError("price band must be between 0 and 100 percent")
+	}
+	if haltSeconds != nil && *haltSeconds < 1 {
+		return nil, newValidationError("halt_seconds must be at least 1")
+	}
+
+	symbol = strings.ToUpper(symbol)
+	query := `INSERT INTO instruments (symbol, price_band_percent, volatility_halt_seconds) VALUES ($1, $2, $3)
+			  ON CONFLICT (symbol) DO UPDATE
+			  SET price_band_percent = EXCLUDED.price_band_percent, volatility_halt_seconds = EXCLUDED.volatility_halt_seconds`
+	if _, err := e.db.Exec(query, symbol, percent, haltSeconds); err != nil {
+		return nil, fmt.Errorf("failed to set price band: %w", err)
+	}
+	return e.GetInstrument(symbol)
+}
+
+// StartHaltMonitor announces volatility halts and ends them through their
+// re-opening auction until StopHaltMonitor is called.
+func (e *Engine) StartHaltMonitor() {
+	e.haltMonitorStop = make(chan struct{})
+	e.heartbeats.start(MonitorHalts, haltMonitorInterval)
+
+	go func() {
+		ticker := time.NewTicker(haltMonitorInterval)
+		defer ticker.Stop()
+
+		announced := make(map[string]time.Time)
+		for {
+			select {
+			case <-e.haltMonitorStop:
+				return
+			case <-ticker.C:
+				if err := e.checkHalts(announced); err != nil {
+					slog.Error("failed to check volatility halts", "error", err)
+				}
+				e.heartbeats.beat(MonitorHalts)
+			}
+		}
+	}()
+}
+
+func (e *Engine) StopHaltMonitor() {
+	if e.haltMonitorStop != nil {
+		close(e.haltMonitorStop)
+		e.heartbeats.stop(MonitorHalts)
+	}
+}
+
+// checkHalts publishes the volatility halts that have not been announced
+// yet and resumes the symbols whose halt is over. announced holds the end
+// of every halt already published.
+func (e *Engine) checkHalts(announced map[string]time.Time) error {
+	rows, err := e.db.Query(`SELECT symbol, halt_ends_at, halt_ends_at <= LOCALTIMESTAMP FROM instruments
+		WHERE state = 'auction' AND auction = 'reopening' AND halt_ends_at IS NOT NULL`)
+	if err != nil {
+		return err
+	}
+	halts := make(map[string]time.Time)
+	over := make(map[string]bool)
+	for rows.Next() {
+		var symbol string
+		var endsAt time.Time
+		var ended bool
+		if err := rows.Scan(&symbol, &endsAt, &ended); err != nil {
+			rows.Close()
+			return err
+		}
+		halts[symbol] = endsAt
+		over[symbol] = ended
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for symbol := range announced {
+		if _, ok := halts[symbol]; !ok {
+			delete(announced, symbol)
+		}
+	}
+
+	for symbol, endsAt := range halts {
+		instrument, err := e.GetInstrument(symbol)
+		if err != nil {
+			return err
+		}
+		if !announced[symbol].Equal(endsAt) {
+			e.publishInstrument(instrument)
+			announced[symbol] = endsAt
+		}
+		if !over[symbol] {
+			continue
+		}
+
+		// The halt keeps the symbol's override, if any.
+		_, err = e.transition(symbol, instrument.Manual, func(instrument *models.Instrument) (string, string, error) {
+			if instrument.State != StateAuction || pointerValue(instrument.Auction) != AuctionReopening {
+				return instrument.State, pointerValue(instrument.Auction), nil
+			}
+			return StateContinuous, "", nil
+		})
+		if err != nil {
+			slog.Error("failed to resume trading", "symbol", symbol, "error", err)
+			continue
+		}
+		slog.Info("trading resumed after re-opening auction", "symbol", symbol)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package engine
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Background monitor names, as reported by Monitors.
+const (
+	MonitorHalts    = "halts"
+	MonitorExpiries = "expiries"
+	MonitorMargin   = "margin"
+	MonitorSchedule = "schedule"
+)
+
+// monitorGrace is how late a monitor may be on top of a few of its
+// intervals before it is considered stuck: a round waiting on a slow query
+// is late, not dead.
+const monitorGrace = 5 * time.Second
+
+// MonitorStatus tells whether a background monitor still completes its
+// rounds.
+type MonitorStatus struct {
+	Name       string    `json:"name"`
+	LastRound  time.Time `json:"last_round"`
+	Responsive bool      `json:"responsive"`
+}
+
+type heartbeat struct {
+	interval time.Duration
+	last     time.Time
+}
+
+// heartbeats records when every running monitor last completed a round.
+type heartbeats struct {
+	mu       sync.Mutex
+	monitors map[string]*heartbeat
+}
+
+func newHeartbeats() *heartbeats {
+	return &heartbeats{monitors: make(map[string]*heartbeat)}
+}
+
+func (h *heartbeats) start(name string, interval time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.monitors[name] = &heartbeat{interval: interval, last: time.Now()}
+}
+
+func (h *heartbeats) beat(name string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if monitor, ok := h.monitors[name]; ok {
+		monitor.last = time.Now()
+	}
+}
+
+func (h *heartbeats) stop(name string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.monitors, name)
+}
+
+// Monitors reports the background monitors that are running. A monitor is
+// unresponsive when it has not completed a round for three of its
+// intervals.
+func (e *Engine) Monitors() []MonitorStatus {
+	e.heartbeats.mu.Lock()
+	defer e.heartbeats.mu.Unlock()
+
+	now := time.Now()
+	statuses := make([]MonitorStatus, 0, len(e.heartbeats.monitors))
+	for name, monitor := range e.heartbeats.monitors {
+		statuses = append(statuses, MonitorStatus{
+			Name:       name,
+			LastRound:  monitor.last,
+			Responsive: now.Sub(monitor.last) <= 3*monitor.interval+monitorGrace,
+		})
+	}
+	return statuses
+}
+
+// LoadBooks reads the book of every symbol with orders in it. It is run at
+// startup, before the system reports itself ready, to make sure every book
+// left behind by the previous run can be served. It returns the number of
+// books loaded.
+func (e *Engine) LoadBooks() (int, error) {
+	var symbols []string
+	err := e.db.Select(&symbols, `SELECT DISTINCT symbol FROM orders
+		WHERE status IN ('pending', 'open', 'partially_filled') ORDER BY symbol`)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, symbol := range symbols {
+		if _, err := e.GetOrderBook(symbol); err != nil {
+			return 0, fmt.Errorf("failed to load the book of %s: %w", symbol, err)
+		}
+	}
+	return len(symbols), nil
+}
@@ -0,0 +1,345 @@
+package engine
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bartick/golang-order-matching-system/models"
+	"github.com/google/uuid"
+)
+
+// Instrument states. An instrument without a row trades continuously.
+const (
+	StatePreOpen    = "pre_open"
+	StateAuction    = "auction"
+	StateContinuous = "continuous"
+	StateHalted     = "halted"
+	StateClosed     = "closed"
+	StateDelisted   = "delisted"
+)
+
+// Actions restricted by the instrument state.
+const (
+	actionPlace  = "place"
+	actionAmend  = "amend"
+	actionCancel = "cancel"
+	actionMatch  = "match"
+)
+
+// stateActions lists what each state allows. Before the open and during an
+// auction orders are collected without matching; a halted or closed
+// instrument only lets orders be canceled. A delisted instrument has no
+// orders left.
+var stateActions = map[string][]string{
+	StatePreOpen:    {actionPlace, actionAmend, actionCancel},
+	StateAuction:    {actionPlace, actionAmend, actionCancel},
+	StateContinuous: {actionPlace, actionAmend, actionCancel, actionMatch},
+	StateHalted:     {actionCancel},
+	StateClosed:     {actionCancel},
+	StateDelisted:   {},
+}
+
+func allows(state, action string) bool {
+	for _, allowed := range stateActions[state] {
+		if allowed == action {
+			return true
+		}
+	}
+	return false
+}
+
+// collectsOrders reports whether a state gathers orders for an auction.
+func collectsOrders(state string) bool {
+	return state == StatePreOpen || state == StateAuction
+}
+
+// canMatch reports whether a symbol trades continuously. It is checked
+// again while a command settles the book, as a volatility halt may stop
+// trading half way through.
+func canMatch(q queryer, symbol string) (bool, error) {
+	instrument, err := getInstrument(q, symbol)
+	if err != nil {
+		return false, err
+	}
+	return allows(instrument.State, actionMatch), nil
+}
+
+// checkState rejects an action the instrument's state does not allow. The
+// error is a ValidationError so that every entry point reports it like any
+// other rejected order.
+func checkState(q queryer, symbol, action string) (*models.Instrument, error) {
+	instrument, err := getInstrument(q, symbol)
+	if err != nil {
+		return nil, err
+	}
+	if !allows(instrument.State, action) {
+		return nil, newValidationError(fmt.Sprintf("%s is %s: cannot %s orders", symbol, instrument.State, action))
+	}
+	return instrument, nil
+}
+
+func getInstrument(q queryer, symbol string) (*models.Instrument, error) {
+	// Precisions of -1 are filled in by defaultPair
+	instrument := &models.Instrument{Symbol: symbol, Type: InstrumentSpot, MatchingAlgorithm: AlgorithmFIFO, MinAllocation: 1,
+		PricePrecision: -1, QuantityPrecision: -1}
+	var baseAsset, quoteAsset, auction sql.NullString
+	var pricePrecision, quantityPrecision sql.NullInt64
+	var multiplier sql.NullFloat64
+	var expiryDate, lastTradingDay sql.NullTime
+	var settlementWindow sql.NullInt64
+	var initialMargin, maintenanceMargin sql.NullFloat64
+	var bandPercent sql.NullFloat64
+	var haltSeconds sql.NullInt64
+	var haltEndsAt sql.NullTime
+	query := `SELECT base_asset, quote_asset, price_precision, quantity_precision,
+					 instrument_type, contract_multiplier, expiry_date, last_trading_day, settlement_window_minutes,
+					 initial_margin_percent, maintenance_margin_percent,
+					 state, auction, manual, price_band_percent, volatility_halt_seconds, halt_ends_at,
+					 matching_algorithm, min_allocation, updated_at
+			  FROM instruments WHERE symbol = $1`
+	err := q.QueryRow(query, symbol).
+		Scan(&baseAsset, &quoteAsset, &pricePrecision, &quantityPrecision,
+			&instrument.Type, &multiplier, &expiryDate, &lastTradingDay, &settlementWindow,
+			&initialMargin, &maintenanceMargin,
+			&instrument.State, &auction, &instrument.Manual, &bandPercent, &haltSeconds, &haltEndsAt,
+			&instrument.MatchingAlgorithm, &instrument.MinAllocation, &instrument.UpdatedAt)
+	if err == sql.ErrNoRows {
+		instrument.State = StateContinuous
+		defaultPair(instrument)
+		return instrument, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load instrument: %w", err)
+	}
+
+	instrument.BaseAsset, instrument.QuoteAsset = baseAsset.String, quoteAsset.String
+	if pricePrecision.Valid {
+		instrument.PricePrecision = int(pricePrecision.Int64)
+	}
+	if quantityPrecision.Valid {
+		instrument.QuantityPrecision = int(quantityPrecision.Int64)
+	}
+	defaultPair(instrument)
+
+	if multiplier.Valid {
+		instrument.ContractMultiplier = &multiplier.Float64
+	}
+	if expiryDate.Valid {
+		instrument.ExpiryDate = &expiryDate.Time
+	}
+	if lastTradingDay.Valid {
+		instrument.LastTradingDay = &lastTradingDay.Time
+	}
+	if settlementWindow.Valid {
+		minutes := int(settlementWindow.Int64)
+		instrument.SettlementWindowMinutes = &minutes
+	}
+	if initialMargin.Valid {
+		instrument.InitialMarginPercent = &initialMargin.Float64
+		instrument.MaintenanceMarginPercent = &maintenanceMargin.Float64
+	}
+	if auction.Valid {
+		instrument.Auction = &auction.String
+	}
+	if bandPercent.Valid {
+		instrument.PriceBandPercent = &bandPercent.Float64
+	}
+	if haltSeconds.Valid {
+		seconds := int(haltSeconds.Int64)
+		instrument.VolatilityHaltSeconds = &seconds
+	}
+	if haltEndsAt.Valid {
+		instrument.HaltEndsAt = &haltEndsAt.Time
+	}
+	return instrument, nil
+}
+
+// GetInstrument returns the definition and trading state of a symbol.
+func (e *Engine) GetInstrument(symbol string) (*models.Instrument, error) {
+	return getInstrument(e.db, strings.ToUpper(symbol))
+}
+
+// SetInstrumentState moves a symbol to a new state as an administrator
+// override: the trading schedule leaves the symbol alone until
+// ReleaseInstrument is called. auction is required for the "auction"
+// state.
+func (e *Engine) SetInstrumentState(symbol, state, auction string) (*models.Instrument, error) {
+	if _, ok := stateActions[state]; !ok || state == StateDelisted {
+		return nil, newValidationError("state must be 'pre_open', 'auction', 'continuous', 'halted' or 'closed'")
+	}
+	if state == StateAuction && auction != AuctionOpening && auction != AuctionClosing {
+		return nil, newValidationError("auction type must be 'opening' or 'closing'")
+	}
+	if state != StateAuction && auction != "" {
+		return nil, newValidationError("auction is only allowed with the 'auction' state")
+	}
+
+	change, err := e.transition(symbol, true, func(*models.Instrument) (string, string, error) {
+		return state, auction, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if change == nil {
+		return e.GetInstrument(symbol)
+	}
+	return change.instrument, nil
+}
+
+// ReleaseInstrument ends an administrator override and hands the symbol
+// back to the trading schedule, which applies its current state straight
+// away.
+func (e *Engine) ReleaseInstrument(symbol string) (*models.Instrument, error) {
+	symbol = strings.ToUpper(symbol)
+	if _, err := e.db.Exec(`UPDATE instruments SET manual = FALSE WHERE symbol = $1`, symbol); err != nil {
+		return nil, fmt.Errorf("failed to release instrument: %w", err)
+	}
+	if err := e.schedule.apply(e, symbol); err != nil {
+		return nil, err
+	}
+	return e.GetInstrument(symbol)
+}
+
+// stateChange is everything a transition did, to be published once it is
+// committed.
+type stateChange struct {
+	instrument *models.Instrument
+	result     *models.AuctionResult
+	canceled   []models.Order
+	settled    []models.Trade
+	settlement *models.SettlementPrice
+}
+
+// transition moves a symbol to the state chosen by next, given its current
+// state, as a single command. Leaving a state that collects orders for the
+// continuous or closed state uncrosses the book first; closing cancels the
+// market-on-close orders that are left and settles the day of a future;
+// delisting cancels every order; entering the continuous state settles the
+// book. manual marks an administrator override; transitions of the schedule
+// pass false and leave overridden symbols alone. A delisted symbol never
+// changes again, and a future past its last trading day stays closed. A nil
+// change is returned when the symbol already was in the requested state.
+func (e *Engine) transition(symbol string, manual bool, next func(*models.Instrument) (string, string, error)) (*stateChange, error) {
+	symbol = strings.ToUpper(symbol)
+
+	tx, err := e.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := sequenceCommand(tx, symbol); err != nil {
+		return nil, err
+	}
+	instrument, err := getInstrument(tx, symbol)
+	if err != nil {
+		return nil, err
+	}
+	if instrument.Manual && !manual {
+		return nil, nil
+	}
+	state, auction, err := next(instrument)
+	if err != nil {
+		return nil, err
+	}
+	if instrument.State == StateDelisted {
+		if manual {
+			return nil, newValidationError(symbol + " is delisted")
+		}
+		return nil, nil
+	}
+	if tradingOver(instrument, time.Now()) && state != StateClosed && state != StateDelisted {
+		if manual {
+			return nil, newValidationError(fmt.Sprintf("%s stopped trading after %s", symbol,
+				instrument.LastTradingDay.Format(time.DateOnly)))
+		}
+		state, auction = StateClosed, ""
+	}
+	if state == instrument.State && auction == pointerValue(instrument.Auction) && manual == instrument.Manual {
+		return nil, nil
+	}
+
+	change := &stateChange{}
+	if collectsOrders(instrument.State) && (state == StateContinuous || state == StateClosed) {
+		change.result, change.canceled, err = uncrossBook(tx, symbol, pendingAuction(instrument))
+		if err != nil {
+			return nil, err
+		}
+	}
+	if state == StateClosed {
+		canceled, err := cancelUnexecuted(tx, symbol, "market_on_close")
+		if err != nil {
+			return nil, err
+		}
+		change.canceled = append(change.canceled, canceled...)
+	}
+	if state == StateDelisted {
+		canceled, err := cancelSymbolOrders(tx, symbol, ReasonInstrumentExpired)
+		if err != nil {
+			return nil, err
+		}
+		change.canceled = append(change.canceled, canceled...)
+	}
+
+	query := `INSERT INTO instruments (symbol, state, auction, manual) VALUES ($1, $2, NULLIF($3, ''), $4)
+			  ON CONFLICT (symbol) DO UPDATE
+			  SET state = EXCLUDED.state, auction = EXCLUDED.auction, manual = EXCLUDED.manual, halt_ends_at = NULL`
+	if _, err := tx.Exec(query, symbol, state, auction, manual); err != nil {
+		return nil, fmt.Errorf("failed to set instrument state: %w", err)
+	}
+	if state == StateHalted && instrument.State != StateHalted {
+		if err := recordHalt(tx, &models.TradingHalt{Symbol: symbol, Cause: HaltCauseManual}); err != nil {
+			return nil, err
+		}
+	}
+	if change.instrument, err = getInstrument(tx, symbol); err != nil {
+		return nil, err
+	}
+	if state == StateClosed && instrument.State != StateClosed && instrument.Type == InstrumentFuture {
+		// A future closed once its trading is over settles its last day
+		day := time.Now()
+		if tradingOver(instrument, day) {
+			day = *instrument.LastTradingDay
+		}
+		settlement, err := settleDay(tx, change.instrument, day)
+		if err != nil && !errors.Is(err, ErrNoSettlementPrice) {
+			return nil, err
+		}
+		change.settlement = settlement
+	}
+
+	if state == StateContinuous {
+		var trades []models.Trade
+		if change.result != nil {
+			trades = change.result.Trades
+		}
+		if change.settled, err = settleBook(context.Background(), tx, symbol, trades); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	for i := range change.canceled {
+		e.publishCancel(&change.canceled[i])
+	}
+	e.publishInstrument(change.instrument)
+	if change.result != nil {
+		e.publishTrades(symbol, uuid.Nil, change.result.Trades)
+	}
+	e.publish(symbol, uuid.Nil, change.settled)
+	return change, nil
+}
+
+func pointerValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
@@ -0,0 +1,236 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OrderLimits caps the size of a single order in one symbol. Zero fields
+// are unlimited. MaxNotional only applies to orders with a price.
+type OrderLimits struct {
+	MaxQuantity float64 `json:"max_quantity"`
+	MaxNotional float64 `json:"max_notional"`
+}
+
+// OrderToTradeRatio rejects the orders of an account that placed more than
+// MaxRatio orders per trade over the last Window, once it placed at least
+// MinOrders in it. A zero MaxRatio disables it.
+type OrderToTradeRatio struct {
+	MaxRatio  float64
+	MinOrders int
+	Window    time.Duration
+}
+
+// limits holds the settings that can be changed while the engine runs,
+// such as on a configuration reload.
+type limits struct {
+	mu           sync.RWMutex
+	maxBatchSize int
+	// orders are the configured order limits with those set by
+	// administrators, which are stored in order_limits, laid over them.
+	configured        map[string]OrderLimits
+	overrides         map[string]OrderLimits
+	orders            map[string]OrderLimits
+	orderToTradeRatio OrderToTradeRatio
+
+	// overridesMu orders the changes of administrators, so that the
+	// overrides match order_limits.
+	overridesMu sync.Mutex
+}
+
+// mergeOrders lays the overrides over the configured order limits. It is
+// called with mu held.
+func (l *limits) mergeOrders() {
+	orders := make(map[string]OrderLimits, len(l.configured)+len(l.overrides))
+	for symbol, limit := range l.configured {
+		orders[symbol] = limit
+	}
+	for symbol, limit := range l.overrides {
+		orders[symbol] = limit
+	}
+	l.orders = orders
+}
+
+func (e *Engine) SetMaxBatchSize(size int) {
+	e.limits.mu.Lock()
+	defer e.limits.mu.Unlock()
+	e.limits.maxBatchSize = size
+}
+
+func (e *Engine) maxBatchSize() int {
+	e.limits.mu.RLock()
+	defer e.limits.mu.RUnlock()
+	return e.limits.maxBatchSize
+}
+
+// SetOrderLimits replaces the configured order limits of every symbol;
+// symbols left out are unlimited unless an administrator set limits for
+// them.
+func (e *Engine) SetOrderLimits(orderLimits map[string]OrderLimits) {
+	normalized := make(map[string]OrderLimits, len(orderLimits))
+	for symbol, limit := range orderLimits {
+		normalized[strings.ToUpper(symbol)] = limit
+	}
+
+	e.limits.mu.Lock()
+	defer e.limits.mu.Unlock()
+	e.limits.configured = normalized
+	e.limits.mergeOrders()
+}
+
+// LoadOrderLimits reads the order limits administrators set.
+func (e *Engine) LoadOrderLimits() error {
+	e.limits.overridesMu.Lock()
+	defer e.limits.overridesMu.Unlock()
+
+	var rows []struct {
+		Symbol      string  `db:"symbol"`
+		MaxQuantity float64 `db:"max_quantity"`
+		MaxNotional float64 `db:"max_notional"`
+	}
+	if err := e.db.Select(&rows, `SELECT symbol, max_quantity, max_notional FROM order_limits`); err != nil {
+		return fmt.Errorf("failed to load order limits: %w", err)
+	}
+	overrides := make(map[string]OrderLimits, len(rows))
+	for _, row := range rows {
+		overrides[row.Symbol] = OrderLimits{MaxQuantity: row.MaxQuantity, MaxNotional: row.MaxNotional}
+	}
+
+	e.limits.mu.Lock()
+	defer e.limits.mu.Unlock()
+	e.limits.overrides = overrides
+	e.limits.mergeOrders()
+	return nil
+}
+
+// SetOrderLimit stores the order limits of one symbol in place of the
+// configured ones.
+func (e *Engine) SetOrderLimit(symbol string, limit OrderLimits) error {
+	if limit.MaxQuantity < 0 || limit.MaxNotional < 0 {
+		return newValidationError("order limits cannot be negative")
+	}
+	symbol = strings.ToUpper(symbol)
+
+	e.limits.overridesMu.Lock()
+	defer e.limits.overridesMu.Unlock()
+	query := `INSERT INTO order_limits (symbol, max_quantity, max_notional) VALUES ($1, $2, $3)
+			  ON CONFLICT (symbol) DO UPDATE
+			  SET max_quantity = EXCLUDED.max_quantity, max_notional = EXCLUDED.max_notional, updated_at = CURRENT_TIMESTAMP`
+	if _, err := e.db.Exec(query, symbol, limit.MaxQuantity, limit.MaxNotional); err != nil {
+		return fmt.Errorf("failed to store order limits: %w", err)
+	}
+
+	e.limits.mu.Lock()
+	defer e.limits.mu.Unlock()
+	overrides := make(map[string]OrderLimits, len(e.limits.overrides)+1)
+	for s, l := range e.limits.overrides {
+		overrides[s] = l
+	}
+	overrides[symbol] = limit
+	e.limits.overrides = overrides
+	e.limits.mergeOrders()
+	return nil
+}
+
+// ResetOrderLimit removes the order limits an administrator set for a
+// symbol, which gets its configured limits back. It returns those, nil
+// when the symbol is unlimited.
+func (e *Engine) ResetOrderLimit(symbol string) (*OrderLimits, error) {
+	symbol = strings.ToUpper(symbol)
+
+	e.limits.overridesMu.Lock()
+	defer e.limits.overridesMu.Unlock()
+	if _, err := e.db.Exec(`DELETE FROM order_limits WHERE symbol = $1`, symbol); err != nil {
+		return nil, fmt.Errorf("failed to remove order limits: %w", err)
+	}
+
+	e.limits.mu.Lock()
+	defer e.limits.mu.Unlock()
+	overrides := make(map[string]OrderLimits, len(e.limits.overrides))
+	for s, l := range e.limits.overrides {
+		if s != symbol {
+			overrides[s] = l
+		}
+	}
+	e.limits.overrides = overrides
+	e.limits.mergeOrders()
+
+	limit, ok := e.limits.orders[symbol]
+	if !ok {
+		return nil, nil
+	}
+	return &limit, nil
+}
+
+// OrderLimits returns the order limits of every symbol that has some.
+func (e *Engine) OrderLimits() map[string]OrderLimits {
+	e.limits.mu.RLock()
+	defer e.limits.mu.RUnlock()
+	return e.limits.orders
+}
+
+// checkOrderLimits rejects an order larger than the limits of its symbol.
+func (e *Engine) checkOrderLimits(symbol string, quantity float64, price *float64) error {
+	e.limits.mu.RLock()
+	limit, ok := e.limits.orders[symbol]
+	e.limits.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	if limit.MaxQuantity > 0 && quantity > limit.MaxQuantity {
+		return newValidationError(fmt.Sprintf("quantity of %s must be at most %s", symbol, formatQuantity(limit.MaxQuantity)))
+	}
+	if limit.MaxNotional > 0 && price != nil && quantity*(*price) > limit.MaxNotional {
+		return newValidationError(fmt.Sprintf("notional of %s orders must be at most %s", symbol, formatQuantity(limit.MaxNotional)))
+	}
+	return nil
+}
+
+func (e *Engine) SetOrderToTradeRatio(ratio OrderToTradeRatio) {
+	e.limits.mu.Lock()
+	defer e.limits.mu.Unlock()
+	e.limits.orderToTradeRatio = ratio
+}
+
+// checkOrderToTradeRatio rejects a new order of an account that places
+// many orders without trading. Anonymous orders are not limited.
+func (e *Engine) checkOrderToTradeRatio(accountID *uuid.UUID) error {
+	e.limits.mu.RLock()
+	ratio := e.limits.orderToTradeRatio
+	e.limits.mu.RUnlock()
+	if accountID == nil || ratio.MaxRatio <= 0 {
+		return nil
+	}
+
+	// The trades are counted by side, each joined on its own indexed
+	// column; those against an order of the same account count once.
+	var counts struct {
+		Orders int `db:"orders"`
+		Trades int `db:"trades"`
+	}
+	err := e.db.Get(&counts, `SELECT
+			(SELECT COUNT(*) FROM orders
+			  WHERE account_id = $1 AND created_at >= CURRENT_TIMESTAMP - make_interval(secs => $2)) AS orders,
+			(SELECT COUNT(*) FROM trades t JOIN orders o ON o.id = t.buy_order_id
+			  WHERE o.account_id = $1 AND t.executed_at >= CURRENT_TIMESTAMP - make_interval(secs => $2)) +
+			(SELECT COUNT(*) FROM trades t JOIN orders o ON o.id = t.sell_order_id
+			  WHERE o.account_id = $1 AND t.executed_at >= CURRENT_TIMESTAMP - make_interval(secs => $2)
+			    AND NOT EXISTS (SELECT 1 FROM orders b WHERE b.id = t.buy_order_id AND b.account_id = $1)) AS trades`,
+		*accountID, ratio.Window.Seconds())
+	if err != nil {
+		return fmt.Errorf("failed to count the orders and trades of the account: %w", err)
+	}
+
+	if counts.Orders < ratio.MinOrders {
+		return nil
+	}
+	if float64(counts.Orders)/float64(max(counts.Trades, 1)) > ratio.MaxRatio {
+		return ErrOrderToTradeRatio
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/bartick/golang-order-matching-system/models"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// orderLogger returns the default logger with the request ID of the order,
+// when it came with one, and the ID of the trace recording it.
+func orderLogger(ctx context.Context, req *OrderRequest) *slog.Logger {
+	logger := slog.Default()
+	if req.RequestID != "" {
+		logger = logger.With("request_id", req.RequestID)
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsSampled() {
+		logger = logger.With("trace_id", span.TraceID().String())
+	}
+	return logger
+}
+
+// logOrder logs the outcome of an order entry: the order and the trades it
+// took part in once placed, or why it was refused. Internal errors are
+// logged in full here since clients are only told that the order failed.
+func logOrder(logger *slog.Logger, req *OrderRequest, order *models.Order, trades []models.Trade, err error) {
+	var validationErr *ValidationError
+	switch {
+	case err == nil:
+		logger.Info("order placed",
+			"order_id", order.ID,
+			"symbol", order.Symbol,
+			"side", order.Side,
+			"type", order.Type,
+			"status", order.Status,
+			"trade_ids", tradeIDs(trades),
+		)
+	case errors.As(err, &validationErr):
+		logger.Info("order rejected", "symbol", req.Symbol, "side", req.Side, "type", req.Type, "reason", validationErr.Error())
+	default:
+		logger.Error("order failed", "symbol", req.Symbol, "side", req.Side, "type", req.Type, "error", err)
+	}
+}
+
+// logTrade logs a trade with the IDs of both orders and, when known, of the
+// order that took liquidity.
+func logTrade(trade *models.Trade, takerOrderID uuid.UUID) {
+	attrs := []any{
+		"trade_id", trade.ID,
+		"symbol", trade.Symbol,
+		"buy_order_id", trade.BuyOrderID,
+		"sell_order_id", trade.SellOrderID,
+		"price", trade.Price,
+		"quantity", trade.Quantity,
+	}
+	if takerOrderID != uuid.Nil {
+		attrs = append(attrs, "order_id", takerOrderID)
+	}
+	slog.Info("trade executed", attrs...)
+}
+
+func tradeIDs(trades []models.Trade) []uuid.UUID {
+	ids := make([]uuid.UUID, len(trades))
+	for i := range trades {
+		ids[i] = trades[i].ID
+	}
+	return ids
+}
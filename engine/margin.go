@@ -0,0 +1,550 @@
+package engine
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bartick/golang-order-matching-system/models"
+	"github.com/google/uuid"
+)
+
+// SourceLiquidation is the source of the orders the margin monitor places
+// to close the positions of a liquidated account.
+const SourceLiquidation = "liquidation"
+
+// ReasonLiquidation is recorded for the orders canceled when an account is
+// liquidated.
+const ReasonLiquidation = "liquidation"
+
+// Kinds of insurance fund entries.
+const (
+	InsuranceDeposit   = "deposit"
+	InsuranceShortfall = "shortfall"
+)
+
+// marginMonitorInterval is how often the margin of the accounts with
+// positions in futures traded on margin is checked.
+const marginMonitorInterval = 5 * time.Second
+
+// rowsQueryer is satisfied by both *sqlx.DB and *sql.Tx.
+type rowsQueryer interface {
+	queryer
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// SetMargin trades a future on margin: orders must be covered by
+// initialPercent of their notional, and positions by maintenancePercent or
+// the account is liquidated. Nil percentages stop trading it on margin.
+func (e *Engine) SetMargin(symbol string, initialPercent, maintenancePercent *float64) (*models.Instrument, error) {
+	if (initialPercent == nil) != (maintenancePercent == nil) {
+		return nil, newValidationError("initial_margin_percent and maintenance_margin_percent must be set together")
+	}
+	if initialPercent != nil && (*maintenancePercent <= 0 || *maintenancePercent > *initialPercent || *initialPercent > 100) {
+		return nil, newValidationError("margins must satisfy 0 < maintenance_margin_percent <= initial_margin_percent <= 100")
+	}
+
+	symbol = strings.ToUpper(symbol)
+	instrument, err := e.GetInstrument(symbol)
+	if err != nil {
+		return nil, err
+	}
+	if instrument.Type != InstrumentFuture {
+		return nil, ErrNotAFuture
+	}
+
+	query := `UPDATE instruments SET initial_margin_percent = $2, maintenance_margin_percent = $3 WHERE symbol = $1`
+	if _, err := e.db.Exec(query, symbol, initialPercent, maintenancePercent); err != nil {
+		return nil, fmt.Errorf("failed to set margin: %w", err)
+	}
+	return e.GetInstrument(symbol)
+}
+
+// GetMargins returns the margin state of an account, by asset.
+func (e *Engine) GetMargins(accountID uuid.UUID) ([]models.Margin, error) {
+	margins, err := accountMargins(e.db, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.Margin, 0, len(margins))
+	for _, margin := range margins {
+		result = append(result, *margin)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Asset < result[j].Asset })
+	return result, nil
+}
+
+// accountMargins computes the margin state of an account in every asset it
+// trades futures on margin in, and in the given assets. Positions are
+// valued at their mark price, or at their average entry price when the
+// symbol has none, and open orders at their limit or stop price.
+func accountMargins(q rowsQueryer, accountID uuid.UUID, assets ...string) (map[string]*models.Margin, error) {
+	margins := make(map[string]*models.Margin)
+	margin := func(asset string) *models.Margin {
+		if margins[asset] == nil {
+			margins[asset] = &models.Margin{AccountID: accountID, Asset: asset}
+		}
+		return margins[asset]
+	}
+	for _, asset := range assets {
+		margin(asset)
+	}
+
+	positions, err := marginPositions(q, accountID)
+	if err != nil {
+		return nil, err
+	}
+	type openOrder struct {
+		symbol   string
+		quantity float64
+		price    *float64
+	}
+	var orders []openOrder
+	rows, err := q.Query(`SELECT o.symbol, o.remaining_quantity, COALESCE(o.price, o.stop_price)
+		FROM orders o JOIN instruments i ON i.symbol = o.symbol
+		WHERE o.account_id = $1 AND o.status IN ('pending', 'open', 'partially_filled')
+		  AND i.initial_margin_percent IS NOT NULL`, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load open orders: %w", err)
+	}
+	for rows.Next() {
+		var order openOrder
+		if err := rows.Scan(&order.symbol, &order.quantity, &order.price); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	instruments := make(map[string]*models.Instrument)
+	marks := make(map[string]*float64)
+	load := func(symbol string) (*models.Instrument, *float64, error) {
+		if instrument, ok := instruments[symbol]; ok {
+			return instrument, marks[symbol], nil
+		}
+		instrument, err := getInstrument(q, symbol)
+		if err != nil {
+			return nil, nil, err
+		}
+		mark, err := markPrice(q, symbol)
+		if err != nil {
+			return nil, nil, err
+		}
+		instruments[symbol], marks[symbol] = instrument, mark
+		return instrument, mark, nil
+	}
+
+	for _, position := range positions {
+		instrument, mark, err := load(position.Symbol)
+		if err != nil {
+			return nil, err
+		}
+		price := position.AverageEntryPrice
+		if mark != nil {
+			price = *mark
+		}
+		multiplier := contractMultiplier(instrument)
+		notional := math.Abs(position.Quantity) * price * multiplier
+
+		m := margin(instrument.QuoteAsset)
+		m.RealizedPnL += position.RealizedPnL
+		m.UnrealizedPnL += position.Quantity * (price - position.AverageEntryPrice) * multiplier
+		m.InitialMargin += notional * *instrument.InitialMarginPercent / 100
+		m.MaintenanceMargin += notional * *instrument.MaintenanceMarginPercent / 100
+	}
+	for _, order := range orders {
+		instrument, mark, err := load(order.symbol)
+		if err != nil {
+			return nil, err
+		}
+		price := order.price
+		if price == nil {
+			price = mark
+		}
+		m := margin(instrument.QuoteAsset)
+		if price != nil {
+			m.InitialMargin += order.quantity * *price * contractMultiplier(instrument) * *instrument.InitialMarginPercent / 100
+		}
+	}
+
+	for asset, m := range margins {
+		err := q.QueryRow(`SELECT amount FROM balances WHERE account_id = $1 AND asset = $2`, accountID, asset).Scan(&m.Balance)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, fmt.Errorf("failed to load %s balance: %w", asset, err)
+		}
+		m.RealizedPnL = RoundQuantity(m.RealizedPnL)
+		m.UnrealizedPnL = RoundQuantity(m.UnrealizedPnL)
+		m.Equity = RoundQuantity(m.Balance + m.RealizedPnL + m.UnrealizedPnL)
+		m.InitialMargin = RoundQuantity(m.InitialMargin)
+		m.MaintenanceMargin = RoundQuantity(m.MaintenanceMargin)
+	}
+	return margins, nil
+}
+
+// marginPositions returns the positions of an account in futures traded on
+// margin.
+func marginPositions(q rowsQueryer, accountID uuid.UUID) ([]models.Position, error) {
+	rows, err := q.Query(`SELECT p.symbol, p.quantity, p.average_entry_price, p.realized_pnl
+		FROM positions p JOIN instruments i ON i.symbol = p.symbol
+		WHERE p.account_id = $1 AND i.initial_margin_percent IS NOT NULL`, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load positions: %w", err)
+	}
+	defer rows.Close()
+
+	var positions []models.Position
+	for rows.Next() {
+		position := models.Position{AccountID: accountID}
+		err := rows.Scan(&position.Symbol, &position.Quantity, &position.AverageEntryPrice, &position.RealizedPnL)
+		if err != nil {
+			return nil, err
+		}
+		positions = append(positions, position)
+	}
+	return positions, rows.Err()
+}
+
+// checkInitialMargin rejects an order in a future traded on margin when the
+// equity of its account would not cover the initial margin of the account
+// with the order added. Orders that only reduce a position, and
+// liquidation orders, are always accepted.
+func checkInitialMargin(tx *sql.Tx, instrument *models.Instrument, req *OrderRequest) error {
+	if req.AccountID == nil || req.Liquidation || instrument.InitialMarginPercent == nil {
+		return nil
+	}
+
+	var held float64
+	err := tx.QueryRow(`SELECT quantity FROM positions WHERE account_id = $1 AND symbol = $2`, *req.AccountID, req.Symbol).Scan(&held)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to load position: %w", err)
+	}
+	quantity := req.Quantity
+	if req.Side == "sell" {
+		quantity = -quantity
+	}
+	if held != 0 && (held > 0) != (quantity > 0) && math.Abs(quantity) <= math.Abs(held) {
+		return nil
+	}
+
+	price := req.Price
+	if price == nil {
+		price = req.StopPrice
+	}
+	if price == nil {
+		if price, err = markPrice(tx, req.Symbol); err != nil {
+			return err
+		}
+	}
+
+	margins, err := accountMargins(tx, *req.AccountID, instrument.QuoteAsset)
+	if err != nil {
+		return err
+	}
+	margin := margins[instrument.QuoteAsset]
+	required := margin.InitialMargin
+	if price != nil {
+		notional := req.Quantity * *price * contractMultiplier(instrument)
+		required = RoundQuantity(required + notional*(*instrument.InitialMarginPercent)/100)
+	}
+	if margin.Equity < required {
+		return newValidationError(fmt.Sprintf("insufficient margin: equity of %s %s is below the initial margin of %s",
+			formatQuantity(margin.Equity), instrument.QuoteAsset, formatQuantity(required)))
+	}
+	return nil
+}
+
+// StartMarginMonitor liquidates the accounts whose equity falls below their
+// maintenance margin until StopMarginMonitor is called.
+func (e *Engine) StartMarginMonitor() {
+	e.marginMonitorStop = make(chan struct{})
+	e.heartbeats.start(MonitorMargin, marginMonitorInterval)
+
+	go func() {
+		ticker := time.NewTicker(marginMonitorInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-e.marginMonitorStop:
+				return
+			case <-ticker.C:
+				if err := e.checkMargins(); err != nil {
+					slog.Error("failed to check margins", "error", err)
+				}
+				e.heartbeats.beat(MonitorMargin)
+			}
+		}
+	}()
+}
+
+func (e *Engine) StopMarginMonitor() {
+	if e.marginMonitorStop != nil {
+		close(e.marginMonitorStop)
+		e.heartbeats.stop(MonitorMargin)
+	}
+}
+
+type liquidation struct {
+	id        int64
+	accountID uuid.UUID
+	asset     string
+}
+
+// checkMargins follows up the liquidations in progress, then liquidates
+// the accounts that fell below their maintenance margin.
+func (e *Engine) checkMargins() error {
+	var liquidations []liquidation
+	rows, err := e.db.Query(`SELECT id, account_id, asset FROM liquidations WHERE closed_at IS NULL`)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var l liquidation
+		if err := rows.Scan(&l.id, &l.accountID, &l.asset); err != nil {
+			rows.Close()
+			return err
+		}
+		liquidations = append(liquidations, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	inProgress := make(map[liquidation]bool)
+	for _, l := range liquidations {
+		if err := e.continueLiquidation(l); err != nil {
+			slog.Error("failed to liquidate account", "account_id", l.accountID, "error", err)
+		}
+		inProgress[liquidation{accountID: l.accountID, asset: l.asset}] = true
+	}
+
+	var accounts []uuid.UUID
+	rows, err = e.db.Query(`SELECT DISTINCT p.account_id FROM positions p JOIN instruments i ON i.symbol = p.symbol
+		WHERE p.quantity <> 0 AND i.maintenance_margin_percent IS NOT NULL`)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var accountID uuid.UUID
+		if err := rows.Scan(&accountID); err != nil {
+			rows.Close()
+			return err
+		}
+		accounts = append(accounts, accountID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, accountID := range accounts {
+		margins, err := accountMargins(e.db, accountID)
+		if err != nil {
+			return err
+		}
+		for asset, margin := range margins {
+			if inProgress[liquidation{accountID: accountID, asset: asset}] {
+				continue
+			}
+			if margin.MaintenanceMargin > 0 && margin.Equity < margin.MaintenanceMargin {
+				if err := e.liquidate(margin); err != nil {
+					slog.Error("failed to liquidate account", "account_id", accountID, "error", err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// liquidate records the liquidation of an account in an asset, then
+// cancels its orders and closes its positions.
+func (e *Engine) liquidate(margin *models.Margin) error {
+	l := liquidation{accountID: margin.AccountID, asset: margin.Asset}
+	query := `INSERT INTO liquidations (account_id, asset, equity, maintenance_margin) VALUES ($1, $2, $3, $4) RETURNING id`
+	if err := e.db.QueryRow(query, l.accountID, l.asset, margin.Equity, margin.MaintenanceMargin).Scan(&l.id); err != nil {
+		return fmt.Errorf("failed to record liquidation: %w", err)
+	}
+
+	slog.Warn("liquidating account", "account_id", l.accountID, "asset", l.asset,
+		"equity", margin.Equity, "maintenance_margin", margin.MaintenanceMargin)
+	return e.closePositions(l)
+}
+
+// continueLiquidation closes what is left of the positions of a liquidated
+// account, which may not have found enough liquidity the first time. Once
+// they are all closed, the insurance fund absorbs the shortfall of the
+// account and the liquidation is over.
+func (e *Engine) continueLiquidation(l liquidation) error {
+	margins, err := accountMargins(e.db, l.accountID, l.asset)
+	if err != nil {
+		return err
+	}
+	margin := margins[l.asset]
+	if margin.MaintenanceMargin > 0 {
+		return e.closePositions(l)
+	}
+	return e.absorbShortfall(l, margin.Equity)
+}
+
+// closePositions cancels every order of a liquidated account and places
+// market orders that close its positions in the futures traded on margin
+// in the asset of the liquidation.
+func (e *Engine) closePositions(l liquidation) error {
+	if _, err := e.CancelOrders(CancelFilter{AccountID: &l.accountID, Reason: ReasonLiquidation}); err != nil {
+		return err
+	}
+
+	positions, err := marginPositions(e.db, l.accountID)
+	if err != nil {
+		return err
+	}
+	for _, position := range positions {
+		if position.Quantity == 0 {
+			continue
+		}
+		instrument, err := e.GetInstrument(position.Symbol)
+		if err != nil {
+			return err
+		}
+		if instrument.QuoteAsset != l.asset {
+			continue
+		}
+
+		side := "sell"
+		if position.Quantity < 0 {
+			side = "buy"
+		}
+		accountID := l.accountID
+		_, trades, err := e.PlaceOrder(context.Background(), OrderRequest{
+			Symbol:      position.Symbol,
+			Side:        side,
+			Type:        "market",
+			Quantity:    math.Abs(position.Quantity),
+			Source:      SourceLiquidation,
+			AccountID:   &accountID,
+			Liquidation: true,
+		})
+		if err != nil {
+			slog.Error("failed to close position", "account_id", l.accountID, "symbol", position.Symbol, "error", err)
+			continue
+		}
+		var filled float64
+		for _, trade := range trades {
+			filled += trade.Quantity
+		}
+		slog.Info("position liquidated", "account_id", l.accountID, "symbol", position.Symbol,
+			"filled", RoundQuantity(filled), "position", position.Quantity)
+	}
+	return nil
+}
+
+// absorbShortfall closes a liquidation. An account left with negative
+// equity is brought back to zero out of the insurance fund.
+func (e *Engine) absorbShortfall(l liquidation, equity float64) error {
+	tx, err := e.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var shortfall float64
+	if equity < 0 {
+		shortfall = -equity
+		if err := adjustBalance(tx, l.accountID, l.asset, shortfall); err != nil {
+			return err
+		}
+		query := `INSERT INTO insurance_fund (asset, amount, kind, account_id, liquidation_id) VALUES ($1, $2, $3, $4, $5)`
+		if _, err := tx.Exec(query, l.asset, -shortfall, InsuranceShortfall, l.accountID, l.id); err != nil {
+			return fmt.Errorf("failed to draw on the insurance fund: %w", err)
+		}
+	}
+	query := `UPDATE liquidations SET shortfall = $2, closed_at = CURRENT_TIMESTAMP WHERE id = $1`
+	if _, err := tx.Exec(query, l.id, shortfall); err != nil {
+		return fmt.Errorf("failed to close liquidation: %w", err)
+	}
+
+	var fund float64
+	err = tx.QueryRow(`SELECT COALESCE(SUM(amount), 0) FROM insurance_fund WHERE asset = $1`, l.asset).Scan(&fund)
+	if err != nil {
+		return fmt.Errorf("failed to load the insurance fund: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	slog.Warn("liquidation over with a shortfall", "account_id", l.accountID, "asset", l.asset, "shortfall", shortfall)
+	if fund < 0 {
+		slog.Error("insurance fund exhausted", "asset", l.asset, "balance", RoundQuantity(fund))
+	}
+	return nil
+}
+
+// DepositInsuranceFund adds to the insurance fund of an asset.
+func (e *Engine) DepositInsuranceFund(asset string, amount float64) (*models.InsuranceFundEntry, error) {
+	asset = strings.ToUpper(asset)
+	if !assetPattern.MatchString(asset) {
+		return nil, newValidationError("asset must be 1 to 10 letters or digits")
+	}
+	if amount <= 0 {
+		return nil, newValidationError("amount must be positive")
+	}
+
+	entry := &models.InsuranceFundEntry{Asset: asset, Amount: RoundQuantity(amount), Kind: InsuranceDeposit}
+	query := `INSERT INTO insurance_fund (asset, amount, kind) VALUES ($1, $2, $3) RETURNING id, created_at`
+	if err := e.db.QueryRow(query, entry.Asset, entry.Amount, entry.Kind).Scan(&entry.ID, &entry.CreatedAt); err != nil {
+		return nil, fmt.Errorf("failed to deposit into the insurance fund: %w", err)
+	}
+	return entry, nil
+}
+
+// GetInsuranceFund returns the balance of the insurance fund in every asset
+// and its latest entries, most recent first.
+func (e *Engine) GetInsuranceFund() (map[string]float64, []models.InsuranceFundEntry, error) {
+	balances := make(map[string]float64)
+	rows, err := e.db.Query(`SELECT asset, SUM(amount) FROM insurance_fund GROUP BY asset`)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load the insurance fund: %w", err)
+	}
+	for rows.Next() {
+		var asset string
+		var amount float64
+		if err := rows.Scan(&asset, &amount); err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+		balances[asset] = amount
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	rows, err = e.db.Query(`SELECT id, asset, amount, kind, account_id, liquidation_id, created_at
+		FROM insurance_fund ORDER BY id DESC LIMIT $1`, defaultListLimit)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load the insurance fund: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.InsuranceFundEntry{}
+	for rows.Next() {
+		var entry models.InsuranceFundEntry
+		err := rows.Scan(&entry.ID, &entry.Asset, &entry.Amount, &entry.Kind, &entry.AccountID, &entry.LiquidationID, &entry.CreatedAt)
+		if err != nil {
+			return nil, nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return balances, entries, rows.Err()
+}
@@ -0,0 +1,229 @@
+package engine
+
+import (
+	"log/slog"
+	"sync"
+
+	"github.com/bartick/golang-order-matching-system/models"
+	"github.com/google/uuid"
+)
+
+// subscriptionBuffer is how many events a subscriber may fall behind before
+// it is considered a slow consumer and disconnected.
+const subscriptionBuffer = 256
+
+// MarketDataEvent carries exactly one of its payload fields.
+type MarketDataEvent struct {
+	Symbol    string
+	Trade     *models.Trade
+	OrderBook *models.OrderBook
+
+	// CanceledOrder is an order canceled in bulk by the engine, e.g. by a
+	// mass cancel or a dead man's switch, rather than by a cancel request
+	// its owner is waiting on a response for.
+	CanceledOrder *models.Order
+
+	// Auction is the indicative uncross of a symbol collecting orders for
+	// an auction. It is published with every change to the book of the
+	// symbol.
+	Auction *models.AuctionState
+
+	// Instrument is the new state of a symbol after a transition.
+	Instrument *models.Instrument
+
+	// TakerOrderID is the incoming order that caused the trade, as opposed
+	// to the resting order it matched against. Only set with Trade.
+	TakerOrderID uuid.UUID
+}
+
+// allSymbols is the subscription key of subscribers that receive the events
+// of every symbol.
+const allSymbols = ""
+
+// Subscription receives the market data events of a single symbol. Events
+// is closed when the subscription is cancelled or the subscriber falls too
+// far behind.
+type Subscription struct {
+	Symbol string
+	Events <-chan MarketDataEvent
+
+	events chan MarketDataEvent
+}
+
+// MarketData fans out trades and order book changes to subscribers after
+// the transaction that produced them has been committed.
+type MarketData struct {
+	mu          sync.RWMutex
+	subscribers map[string]map[*Subscription]struct{}
+}
+
+func NewMarketData() *MarketData {
+	return &MarketData{
+		subscribers: make(map[string]map[*Subscription]struct{}),
+	}
+}
+
+func (md *MarketData) Subscribe(symbol string) *Subscription {
+	events := make(chan MarketDataEvent, subscriptionBuffer)
+	sub := &Subscription{
+		Symbol: symbol,
+		Events: events,
+		events: events,
+	}
+
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	if md.subscribers[symbol] == nil {
+		md.subscribers[symbol] = make(map[*Subscription]struct{})
+	}
+	md.subscribers[symbol][sub] = struct{}{}
+
+	return sub
+}
+
+// SubscribeAll returns a subscription that receives the events of every
+// symbol.
+func (md *MarketData) SubscribeAll() *Subscription {
+	return md.Subscribe(allSymbols)
+}
+
+func (md *MarketData) Unsubscribe(sub *Subscription) {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	md.remove(sub)
+}
+
+// remove must be called with md.mu held for writing.
+func (md *MarketData) remove(sub *Subscription) {
+	subs, ok := md.subscribers[sub.Symbol]
+	if !ok {
+		return
+	}
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(md.subscribers, sub.Symbol)
+	}
+	close(sub.events)
+}
+
+// SubscriberCounts returns the number of subscribers of every symbol with
+// any; those of every symbol are counted under the empty symbol.
+func (md *MarketData) SubscriberCounts() map[string]int {
+	md.mu.RLock()
+	defer md.mu.RUnlock()
+
+	counts := make(map[string]int, len(md.subscribers))
+	for symbol, subs := range md.subscribers {
+		counts[symbol] = len(subs)
+	}
+	return counts
+}
+
+// QueueDepths returns, for every symbol with subscribers, the most events
+// any of them has yet to consume. A subscriber is dropped when its queue
+// reaches subscriptionBuffer.
+func (md *MarketData) QueueDepths() map[string]int {
+	md.mu.RLock()
+	defer md.mu.RUnlock()
+
+	depths := make(map[string]int, len(md.subscribers))
+	for symbol, subs := range md.subscribers {
+		for sub := range subs {
+			depths[symbol] = max(depths[symbol], len(sub.events))
+		}
+	}
+	return depths
+}
+
+func (md *MarketData) HasSubscribers(symbol string) bool {
+	md.mu.RLock()
+	defer md.mu.RUnlock()
+	return len(md.subscribers[symbol]) > 0 || len(md.subscribers[allSymbols]) > 0
+}
+
+func (md *MarketData) Publish(event MarketDataEvent) {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+
+	for _, key := range []string{event.Symbol, allSymbols} {
+		for sub := range md.subscribers[key] {
+			select {
+			case sub.events <- event:
+			default:
+				slog.Warn("dropping slow market data subscriber", "symbol", event.Symbol)
+				md.remove(sub)
+			}
+		}
+	}
+}
+
+// publish sends the trades produced by a committed transaction followed by
+// the resulting order book of the symbol.
+func (e *Engine) publish(symbol string, takerOrderID uuid.UUID, trades []models.Trade) {
+	e.publishTrades(symbol, takerOrderID, trades)
+	e.publishOrderBook(symbol)
+}
+
+func (e *Engine) publishTrades(symbol string, takerOrderID uuid.UUID, trades []models.Trade) {
+	for i := range trades {
+		e.metrics.TradeExecuted(symbol, trades[i].Quantity, trades[i].Price*trades[i].Quantity)
+		logTrade(&trades[i], takerOrderID)
+	}
+	if !e.marketData.HasSubscribers(symbol) {
+		return
+	}
+
+	for i := range trades {
+		e.marketData.Publish(MarketDataEvent{Symbol: symbol, Trade: &trades[i], TakerOrderID: takerOrderID})
+	}
+}
+
+func (e *Engine) publishCancel(order *models.Order) {
+	if !e.marketData.HasSubscribers(order.Symbol) {
+		return
+	}
+
+	e.marketData.Publish(MarketDataEvent{Symbol: order.Symbol, CanceledOrder: order})
+}
+
+func (e *Engine) publishOrderBook(symbol string) {
+	if !e.marketData.HasSubscribers(symbol) {
+		return
+	}
+
+	orderBook, err := e.GetOrderBook(symbol)
+	if err != nil {
+		slog.Error("failed to load order book", "symbol", symbol, "error", err)
+		return
+	}
+	e.marketData.Publish(MarketDataEvent{Symbol: symbol, OrderBook: orderBook})
+
+	state, err := e.GetAuctionState(symbol)
+	if err == ErrNoAuction {
+		return
+	}
+	if err != nil {
+		slog.Error("failed to load auction state", "symbol", symbol, "error", err)
+		return
+	}
+	e.publishAuction(state)
+}
+
+func (e *Engine) publishInstrument(instrument *models.Instrument) {
+	if !e.marketData.HasSubscribers(instrument.Symbol) {
+		return
+	}
+
+	e.marketData.Publish(MarketDataEvent{Symbol: instrument.Symbol, Instrument: instrument})
+}
+
+func (e *Engine) publishAuction(state *models.AuctionState) {
+	if !e.marketData.HasSubscribers(state.Symbol) {
+		return
+	}
+
+	e.marketData.Publish(MarketDataEvent{Symbol: state.Symbol, Auction: state})
+}
@@ -0,0 +1,396 @@
+package engine
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/bartick/golang-order-matching-system/models"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func matchOrder(ctx context.Context, tx *sql.Tx, order *models.Order) (_ []models.Trade, err error) {
+	ctx, span := startSpan(ctx, "matchOrder", orderAttributes(order)...)
+	defer func() { endSpan(span, err) }()
+
+	var trades []models.Trade
+
+	// A post-only order must rest; it is rejected rather than allowed to
+	// take liquidity.
+	if order.PostOnly {
+		best, err := bestOppositePrice(tx, order)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load best price: %w", err)
+		}
+		if best != nil && crosses(order, *best) {
+			return nil, ErrPostOnlyWouldCross
+		}
+		return nil, nil
+	}
+
+	// A symbol halted earlier in the command collects the order instead
+	instrument, err := getInstrument(tx, order.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	if !allows(instrument.State, actionMatch) {
+		return nil, nil
+	}
+
+	// Trades must stay within the price band around the last trade price;
+	// the first one outside it halts the symbol instead.
+	band, err := loadPriceBand(tx, instrument)
+	if err != nil {
+		return nil, err
+	}
+	halted := false
+
+	for order.RemainingQuantity > 0 && !halted {
+		// Find matching orders
+		var matchingOrders []*models.Order
+		var err error
+
+		if order.Side == "buy" {
+			matchingOrders, err = findMatchingSellOrders(ctx, tx, order)
+		} else {
+			matchingOrders, err = findMatchingBuyOrders(ctx, tx, order)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to find matching orders: %w", err)
+		}
+
+		if len(matchingOrders) == 0 {
+			// No more matches
+			break
+		}
+
+		// Execute trades with matching orders, one price level at a time.
+		// The instrument's algorithm decides how the orders of a level
+		// share the incoming order; only the displayed slice of a resting
+		// iceberg order can be filled at its position.
+		for _, level := range priceLevels(matchingOrders) {
+			if order.RemainingQuantity == 0 || halted {
+				break
+			}
+			allocations := allocate(instrument, order.RemainingQuantity, level)
+
+			for i, matchingOrder := range level {
+				tradeQuantity := allocations[i]
+				if tradeQuantity == 0 {
+					continue
+				}
+
+				// Use the resting order's price for limit/limit matches
+				// Use the limit price for market/limit matches
+				var tradePrice float64
+				if isMarketType(order.Type) {
+					tradePrice = *matchingOrder.Price
+				} else if isMarketType(matchingOrder.Type) {
+					tradePrice = *order.Price
+				} else if isMidPeg(order) {
+					// Midpoint pegs only ever trade at the midpoint
+					tradePrice = *order.Price
+				} else {
+					// Both are limit orders - use the resting (existing) order's price
+					tradePrice = *matchingOrder.Price
+				}
+
+				if band != nil && !band.contains(tradePrice) {
+					if err := volatilityHalt(tx, band, order, tradePrice); err != nil {
+						return nil, err
+					}
+					halted = true
+					break
+				}
+
+				// Create trade
+				trade, err := createTrade(ctx, tx, instrument, order, matchingOrder, tradePrice, tradeQuantity)
+				if err != nil {
+					return nil, fmt.Errorf("failed to create trade: %w", err)
+				}
+				trades = append(trades, *trade)
+
+				// Update order quantities
+				orderBefore, matchingBefore := stateOf(order), stateOf(matchingOrder)
+				order.RemainingQuantity = RoundQuantity(order.RemainingQuantity - tradeQuantity)
+				clampVisible(order)
+				matchingOrder.RemainingQuantity = RoundQuantity(matchingOrder.RemainingQuantity - tradeQuantity)
+				replenished := consumeVisible(matchingOrder, tradeQuantity)
+
+				// Update orders in database
+				err = updateOrderQuantity(ctx, tx, order, false)
+				if err != nil {
+					return nil, fmt.Errorf("failed to update order quantity: %w", err)
+				}
+				err = updateOrderQuantity(ctx, tx, matchingOrder, replenished)
+				if err != nil {
+					return nil, fmt.Errorf("failed to update matching order quantity: %w", err)
+				}
+				if err := recordFill(tx, order, orderBefore, trade); err != nil {
+					return nil, err
+				}
+				if err := recordFill(tx, matchingOrder, matchingBefore, trade); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	// Cancel remaining quantity for market orders, whether the book ran out
+	// or the symbol was halted. This must be stored: a market order left
+	// open has no price and would break later matches.
+	if isMarketType(order.Type) && order.RemainingQuantity > 0 {
+		before := stateOf(order)
+		order.RemainingQuantity = 0
+		if err := updateOrderQuantity(ctx, tx, order, false); err != nil {
+			return nil, fmt.Errorf("failed to update order quantity: %w", err)
+		}
+		if err := recordOrderEvent(tx, order, EventExpired, ReasonMarketRemainder, &before, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return trades, nil
+}
+
+func findMatchingSellOrders(ctx context.Context, tx *sql.Tx, buyOrder *models.Order) (orders []*models.Order, err error) {
+	_, span := dbSpan(ctx, "findMatchingSellOrders", buyOrder)
+	defer func() {
+		span.SetAttributes(attribute.Int("oms.matching_orders", len(orders)))
+		endSpan(span, err)
+	}()
+
+	var query string
+	var args []interface{}
+
+	if isMarketType(buyOrder.Type) {
+		// Market buy order matches any sell order (lowest price first)
+		query = `SELECT ` + orderColumns + `
+				 FROM orders
+				 WHERE symbol = $1 AND side = 'sell' AND status IN ('open', 'partially_filled')
+				 ORDER BY price ASC, hidden ASC, queued_at ASC`
+		args = []interface{}{buyOrder.Symbol}
+	} else {
+		// Limit buy order matches sell orders at or below the buy price
+		query = `SELECT ` + orderColumns + `
+				 FROM orders
+				 WHERE symbol = $1 AND side = 'sell' AND status IN ('open', 'partially_filled') AND price <= $2
+				 ORDER BY price ASC, hidden ASC, queued_at ASC`
+		args = []interface{}{buyOrder.Symbol, *buyOrder.Price}
+	}
+
+	return queryOrders(tx, query, args...)
+}
+
+func findMatchingBuyOrders(ctx context.Context, tx *sql.Tx, sellOrder *models.Order) (orders []*models.Order, err error) {
+	_, span := dbSpan(ctx, "findMatchingBuyOrders", sellOrder)
+	defer func() {
+		span.SetAttributes(attribute.Int("oms.matching_orders", len(orders)))
+		endSpan(span, err)
+	}()
+
+	var query string
+	var args []interface{}
+
+	if isMarketType(sellOrder.Type) {
+		// Market sell order matches any buy order (highest price first)
+		query = `SELECT ` + orderColumns + `
+				 FROM orders
+				 WHERE symbol = $1 AND side = 'buy' AND status IN ('open', 'partially_filled')
+				 ORDER BY price DESC, hidden ASC, queued_at ASC`
+		args = []interface{}{sellOrder.Symbol}
+	} else {
+		// Limit sell order matches buy orders at or above the sell price
+		query = `SELECT ` + orderColumns + `
+				 FROM orders
+				 WHERE symbol = $1 AND side = 'buy' AND status IN ('open', 'partially_filled') AND price >= $2
+				 ORDER BY price DESC, hidden ASC, queued_at ASC`
+		args = []interface{}{sellOrder.Symbol, *sellOrder.Price}
+	}
+
+	return queryOrders(tx, query, args...)
+}
+
+// bestOppositePrice returns the best price on the other side of the book,
+// hidden orders included, or nil if that side is empty.
+func bestOppositePrice(tx *sql.Tx, order *models.Order) (*float64, error) {
+	query := `SELECT MIN(price) FROM orders WHERE symbol = $1 AND side = 'sell' AND status IN ('open', 'partially_filled')`
+	if order.Side == "sell" {
+		query = `SELECT MAX(price) FROM orders WHERE symbol = $1 AND side = 'buy' AND status IN ('open', 'partially_filled')`
+	}
+
+	var best sql.NullFloat64
+	if err := tx.QueryRow(query, order.Symbol).Scan(&best); err != nil {
+		return nil, err
+	}
+	if !best.Valid {
+		return nil, nil
+	}
+	return &best.Float64, nil
+}
+
+// crosses reports whether a limit order would trade against the given
+// opposite price.
+func crosses(order *models.Order, opposite float64) bool {
+	if order.Side == "buy" {
+		return *order.Price >= opposite
+	}
+	return *order.Price <= opposite
+}
+
+// repricePostOnly moves a post-only order that would cross the book to one
+// tick behind the best opposite price, so that it rests instead.
+func repricePostOnly(tx *sql.Tx, order *models.Order, precision int) error {
+	best, err := bestOppositePrice(tx, order)
+	if err != nil {
+		return fmt.Errorf("failed to load best price: %w", err)
+	}
+	if best == nil || !crosses(order, *best) {
+		return nil
+	}
+
+	price := roundToTick(*best+tickSize(precision), precision)
+	if order.Side == "buy" {
+		price = roundToTick(*best-tickSize(precision), precision)
+	}
+	if price <= 0 {
+		return ErrPostOnlyWouldCross
+	}
+
+	if _, err := tx.Exec(`UPDATE orders SET price = $1 WHERE id = $2`, price, order.ID); err != nil {
+		return fmt.Errorf("failed to reprice order: %w", err)
+	}
+	before := stateOf(order)
+	order.Price = &price
+	return recordOrderEvent(tx, order, EventRepriced, ReasonPostOnly, &before, nil)
+}
+
+func queryOrders(tx *sql.Tx, query string, args ...interface{}) ([]*models.Order, error) {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []*models.Order
+	for rows.Next() {
+		order := &models.Order{}
+		if err := scanOrder(rows, order); err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+
+	return orders, nil
+}
+
+// createTrade records a trade between two orders and settles it into the
+// balances of their accounts.
+func createTrade(ctx context.Context, tx *sql.Tx, instrument *models.Instrument, order1, order2 *models.Order, price, quantity float64) (_ *models.Trade, err error) {
+	_, span := dbSpan(ctx, "createTrade", order1)
+	defer func() { endSpan(span, err) }()
+
+	buy, sell := order1, order2
+	if order1.Side != "buy" {
+		buy, sell = order2, order1
+	}
+
+	query := `INSERT INTO trades (buy_order_id, sell_order_id, symbol, price, quantity)
+			  VALUES ($1, $2, $3, $4, $5) RETURNING id, executed_at`
+
+	trade := &models.Trade{
+		BuyOrderID:  buy.ID,
+		SellOrderID: sell.ID,
+		Symbol:      order1.Symbol,
+		Price:       price,
+		Quantity:    quantity,
+	}
+
+	err = tx.QueryRow(query, trade.BuyOrderID, trade.SellOrderID, trade.Symbol, trade.Price, trade.Quantity).
+		Scan(&trade.ID, &trade.ExecutedAt)
+	if err != nil {
+		return nil, err
+	}
+	span.SetAttributes(
+		attribute.String("oms.trade_id", trade.ID.String()),
+		attribute.Float64("oms.price", price),
+		attribute.Float64("oms.quantity", quantity),
+	)
+
+	if err := settleTrade(tx, instrument, trade, buy, sell); err != nil {
+		return nil, err
+	}
+
+	return trade, nil
+}
+
+// orderStatus derives the status of an active order from its quantities.
+func orderStatus(order *models.Order) string {
+	if order.RemainingQuantity == 0 {
+		return "filled"
+	} else if order.RemainingQuantity < order.InitialQuantity {
+		return "partially_filled"
+	}
+	return "open"
+}
+
+// availableQuantity is how much of a resting order can trade before it
+// loses its place in the queue.
+func availableQuantity(order *models.Order) float64 {
+	if order.VisibleQuantity != nil {
+		return *order.VisibleQuantity
+	}
+	return order.RemainingQuantity
+}
+
+// consumeVisible takes a fill out of the displayed slice of an iceberg
+// order. When the slice is used up, the next one is shown from the hidden
+// reserve and consumeVisible reports that the order must go to the back of
+// the queue.
+func consumeVisible(order *models.Order, quantity float64) bool {
+	if order.VisibleQuantity == nil {
+		return false
+	}
+
+	visible := RoundQuantity(*order.VisibleQuantity - quantity)
+	replenished := false
+	if visible == 0 && order.RemainingQuantity > 0 {
+		visible = min(*order.DisplayQuantity, order.RemainingQuantity)
+		replenished = true
+	}
+	order.VisibleQuantity = &visible
+	return replenished
+}
+
+// clampVisible keeps the displayed slice of an iceberg order within its
+// remaining quantity, e.g. after the order traded as the aggressor.
+func clampVisible(order *models.Order) {
+	if order.VisibleQuantity != nil && *order.VisibleQuantity > order.RemainingQuantity {
+		visible := order.RemainingQuantity
+		order.VisibleQuantity = &visible
+	}
+}
+
+// updateOrderQuantity stores the quantities and status of an order after a
+// fill. requeue sends the order to the back of the queue at its price;
+// clock_timestamp() is used so that it also goes behind the orders placed
+// earlier in the same transaction.
+func updateOrderQuantity(ctx context.Context, tx *sql.Tx, order *models.Order, requeue bool) (err error) {
+	_, span := dbSpan(ctx, "updateOrderQuantity", order)
+	defer func() { endSpan(span, err) }()
+
+	status := orderStatus(order)
+
+	query := `UPDATE orders
+			  SET remaining_quantity = $1, visible_quantity = $2, status = $3, updated_at = CURRENT_TIMESTAMP,
+				  queued_at = CASE WHEN $4 THEN clock_timestamp() ELSE queued_at END
+			  WHERE id = $5`
+	_, err = tx.Exec(query, order.RemainingQuantity, order.VisibleQuantity, status, requeue, order.ID)
+	if err != nil {
+		return err
+	}
+
+	order.Status = status
+	return nil
+}
@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Outcomes of an order entry attempt.
+const (
+	OrderAccepted = "accepted"
+	OrderRejected = "rejected"
+	OrderFailed   = "error"
+)
+
+// Metrics receives what the engine measures. The service package exports
+// it to Prometheus; an engine without metrics measures nothing.
+type Metrics interface {
+	// OrderPlaced counts an order entry attempt. The fields of orders that
+	// failed validation may be anything, so they are labeled by
+	// rejectionLabels.
+	OrderPlaced(symbol, side, orderType, result string)
+
+	// PlaceOrderObserved measures the transaction that stored and matched
+	// a single order.
+	PlaceOrderObserved(symbol string, duration time.Duration)
+
+	// TradeExecuted counts a committed trade.
+	TradeExecuted(symbol string, quantity, notional float64)
+}
+
+type noMetrics struct{}
+
+func (noMetrics) OrderPlaced(symbol, side, orderType, result string)       {}
+func (noMetrics) PlaceOrderObserved(symbol string, duration time.Duration) {}
+func (noMetrics) TradeExecuted(symbol string, quantity, notional float64)  {}
+
+// SetMetrics sets where the engine reports its measurements. It must be
+// called before the engine is used.
+func (e *Engine) SetMetrics(metrics Metrics) {
+	e.metrics = metrics
+}
+
+// orderResult classifies the outcome of an order entry attempt: rejected
+// orders are the client's fault, failed ones the server's.
+func orderResult(err error) string {
+	var validationErr *ValidationError
+	switch {
+	case err == nil:
+		return OrderAccepted
+	case errors.As(err, &validationErr):
+		return OrderRejected
+	}
+	return OrderFailed
+}
+
+// otherLabel stands for the sides, types and symbols of rejected orders
+// that the engine does not know, so that clients cannot create label
+// values at will.
+const otherLabel = "other"
+
+var orderTypes = []string{"limit", "market", "stop", "stop_limit", "peg", "market_on_open", "market_on_close"}
+
+// rejectionLabels returns the symbol, side and type an order that failed
+// validation is counted under. Symbols count when a command already ran on
+// them.
+func (e *Engine) rejectionLabels(req *OrderRequest) (symbol, side, orderType string) {
+	symbol, side, orderType = otherLabel, otherLabel, otherLabel
+	if req.Side == "buy" || req.Side == "sell" {
+		side = req.Side
+	}
+	if slices.Contains(orderTypes, req.Type) {
+		orderType = req.Type
+	}
+
+	var known bool
+	err := e.db.Get(&known, `SELECT EXISTS (SELECT 1 FROM symbol_sequences WHERE symbol = $1)`, strings.ToUpper(req.Symbol))
+	if err != nil {
+		slog.Error("failed to look up the symbol of a rejected order", "error", err)
+	} else if known {
+		symbol = strings.ToUpper(req.Symbol)
+	}
+	return symbol, side, orderType
+}
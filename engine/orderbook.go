@@ -0,0 +1,78 @@
+package engine
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/bartick/golang-order-matching-system/models"
+)
+
+// orderBookDepth is the number of price levels returned per side.
+const orderBookDepth = 10
+
+// GetOrderBook aggregates the resting orders of a symbol into price levels.
+// Only the displayed slice of iceberg orders is counted, and hidden orders
+// are left out.
+func (e *Engine) GetOrderBook(symbol string) (*models.OrderBook, error) {
+	orderBook := &models.OrderBook{
+		Symbol: strings.ToUpper(symbol),
+		Bids:   []models.OrderBookLevel{},
+		Asks:   []models.OrderBookLevel{},
+	}
+
+	// Get bids (buy orders) - highest price first
+	bidsQuery := `
+	SELECT price, SUM(COALESCE(visible_quantity, remaining_quantity)) as total_quantity, COUNT(*) as order_count
+	FROM orders
+	WHERE symbol = $1 AND side = 'buy' AND status IN ('open', 'partially_filled') AND NOT hidden
+	GROUP BY price
+	ORDER BY price DESC
+	LIMIT $2`
+
+	bids, err := e.queryLevels(bidsQuery, orderBook.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	orderBook.Bids = append(orderBook.Bids, bids...)
+
+	// Get asks (sell orders) - lowest price first
+	asksQuery := `
+	SELECT price, SUM(COALESCE(visible_quantity, remaining_quantity)) as total_quantity, COUNT(*) as order_count
+	FROM orders
+	WHERE symbol = $1 AND side = 'sell' AND status IN ('open', 'partially_filled') AND NOT hidden
+	GROUP BY price
+	ORDER BY price ASC
+	LIMIT $2`
+
+	asks, err := e.queryLevels(asksQuery, orderBook.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	orderBook.Asks = append(orderBook.Asks, asks...)
+
+	return orderBook, nil
+}
+
+func (e *Engine) queryLevels(query, symbol string) ([]models.OrderBookLevel, error) {
+	rows, err := e.db.Query(query, symbol, orderBookDepth)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var levels []models.OrderBookLevel
+	for rows.Next() {
+		var level models.OrderBookLevel
+		var price sql.NullFloat64
+		err := rows.Scan(&price, &level.TotalQuantity, &level.OrderCount)
+		if err != nil {
+			continue
+		}
+		if price.Valid {
+			level.Price = price.Float64
+			levels = append(levels, level)
+		}
+	}
+
+	return levels, rows.Err()
+}
@@ -0,0 +1,760 @@
+package engine
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bartick/golang-order-matching-system/models"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// orderColumns is the column list every order query selects, in the order
+// expected by scanOrder.
+const orderColumns = `id, client_order_id, source, account_id, symbol, side, type, price,
+	initial_quantity, remaining_quantity, display_quantity, visible_quantity,
+	post_only, hidden, stop_price, trail_amount, trail_percent,
+	peg_reference, peg_offset, peg_limit_price, liquidation, status, created_at, updated_at`
+
+type OrderRequest struct {
+	ClientOrderID string   `json:"client_order_id"`
+	Symbol        string   `json:"symbol" binding:"required"`
+	Side          string   `json:"side" binding:"required"`
+	Type          string   `json:"type" binding:"required"`
+	Price         *float64 `json:"price"`
+	Quantity      float64  `json:"quantity" binding:"required,gt=0"`
+
+	// DisplayQuantity turns a limit order into an iceberg order: only this
+	// much of it is shown in the book at a time.
+	DisplayQuantity *float64 `json:"display_quantity"`
+
+	// PostOnly orders only ever add liquidity. One that would cross the
+	// book is rejected, or with PostOnlyReprice moved one tick behind the
+	// best opposite price.
+	PostOnly        bool `json:"post_only"`
+	PostOnlyReprice bool `json:"post_only_reprice"`
+
+	// Hidden orders rest without appearing in the order book and trade
+	// after the displayed orders at the same price.
+	Hidden bool `json:"hidden"`
+
+	// StopPrice is the trigger price of stop and stop_limit orders. A
+	// trailing stop sets TrailAmount or TrailPercent instead (or as well,
+	// to start from a given stop price) and follows the last trade price.
+	StopPrice    *float64 `json:"stop_price"`
+	TrailAmount  *float64 `json:"trail_amount"`
+	TrailPercent *float64 `json:"trail_percent"`
+
+	// PegReference ("bid", "ask" or "mid") is required by peg orders, whose
+	// price follows the reference plus PegOffset, capped by PegLimitPrice.
+	PegReference  string   `json:"peg_reference"`
+	PegOffset     *float64 `json:"peg_offset"`
+	PegLimitPrice *float64 `json:"peg_limit_price"`
+
+	// Source identifies the entry point the order came through. It is set
+	// by the server, never by the client.
+	Source string `json:"-"`
+
+	// AccountID is the authenticated account placing the order, if any.
+	AccountID *uuid.UUID `json:"-"`
+
+	// Actor is recorded in the order events as who placed the order, when
+	// it is not the account, such as the FIX session of the account. It is
+	// set by the server, never by the client.
+	Actor string `json:"-"`
+
+	// Liquidation marks the orders the margin monitor places to close the
+	// positions of an account below its maintenance margin. They skip the
+	// initial margin check, the order size limits and the order-to-trade
+	// ratio. It is set by the engine, never by the client.
+	Liquidation bool `json:"-"`
+
+	// RequestID correlates the log lines of the order with the request
+	// that placed it. It is set by the server, never by the client.
+	RequestID string `json:"-"`
+}
+
+// AmendRequest changes the price and/or the total quantity of a resting
+// limit order. Nil fields are left untouched.
+type AmendRequest struct {
+	Price    *float64 `json:"price"`
+	Quantity *float64 `json:"quantity"`
+
+	// ClientOrderID replaces the client order ID of the order when set.
+	ClientOrderID string `json:"client_order_id"`
+
+	// Actor is recorded in the order events as who amended the order, and
+	// a non-nil AccountID only finds the orders of that account, like
+	// CancelOrder. Both are set by the server, never by the client.
+	Actor     string     `json:"-"`
+	AccountID *uuid.UUID `json:"-"`
+}
+
+// OrderFilter narrows down ListOrders. Empty fields match everything, and
+// a nil AccountID the orders of every account.
+type OrderFilter struct {
+	Symbol    string
+	Side      string
+	Status    string
+	Limit     int
+	AccountID *uuid.UUID
+}
+
+const (
+	defaultListLimit = 100
+	maxListLimit     = 1000
+)
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// queryer is satisfied by both *sqlx.DB and *sql.Tx.
+type queryer interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+func scanOrder(row rowScanner, order *models.Order) error {
+	return row.Scan(
+		&order.ID, &order.ClientOrderID, &order.Source, &order.AccountID, &order.Symbol, &order.Side, &order.Type, &order.Price,
+		&order.InitialQuantity, &order.RemainingQuantity, &order.DisplayQuantity, &order.VisibleQuantity,
+		&order.PostOnly, &order.Hidden, &order.StopPrice, &order.TrailAmount, &order.TrailPercent,
+		&order.PegReference, &order.PegOffset, &order.PegLimitPrice, &order.Liquidation, &order.Status,
+		&order.CreatedAt, &order.UpdatedAt,
+	)
+}
+
+// PlaceOrder validates, stores and matches a new order in a single
+// transaction and returns the order together with the trades it produced.
+func (e *Engine) PlaceOrder(ctx context.Context, req OrderRequest) (order *models.Order, trades []models.Trade, err error) {
+	ctx, span := startSpan(ctx, "PlaceOrder",
+		attribute.String("oms.symbol", req.Symbol),
+		attribute.String("oms.side", req.Side),
+		attribute.String("oms.order_type", req.Type),
+	)
+	defer func() {
+		if order != nil {
+			span.SetAttributes(attribute.String("oms.order_id", order.ID.String()), attribute.Int("oms.trades", len(trades)))
+		}
+		endSpan(span, err)
+	}()
+	defer func() {
+		if err != nil {
+			e.recordRejection(nil, req.Symbol, EventRejected, orderActor(&req), err, req)
+		}
+	}()
+	logger := orderLogger(ctx, &req)
+
+	// Validate input
+	_, validation := startSpan(ctx, "validateOrderRequest")
+	err = validateOrderRequest(&req)
+	// Liquidations must go through however large they are and however
+	// little the account has traded
+	if err == nil && !req.Liquidation {
+		err = e.checkOrderLimits(req.Symbol, req.Quantity, req.Price)
+	}
+	if err == nil && !req.Liquidation {
+		err = e.checkOrderToTradeRatio(req.AccountID)
+	}
+	endSpan(validation, err)
+	if err != nil {
+		symbol, side, orderType := e.rejectionLabels(&req)
+		e.metrics.OrderPlaced(symbol, side, orderType, OrderRejected)
+		logOrder(logger, &req, nil, nil, err)
+		return nil, nil, err
+	}
+
+	defer func() {
+		e.metrics.OrderPlaced(req.Symbol, req.Side, req.Type, orderResult(err))
+		logOrder(logger, &req, order, trades, err)
+	}()
+
+	// Begin transaction
+	start := time.Now()
+	tx, err := e.db.Begin()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := sequenceCommand(tx, req.Symbol); err != nil {
+		return nil, nil, err
+	}
+	if err := setActor(tx, orderActor(&req)); err != nil {
+		return nil, nil, err
+	}
+
+	order, trades, err = placeOrder(ctx, tx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	settled, err := settleBook(ctx, tx, order.Symbol, trades)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Commit transaction
+	if err := commit(ctx, tx); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	e.metrics.PlaceOrderObserved(order.Symbol, time.Since(start))
+
+	// Stops and pegs set off by the order traded after it.
+	e.publishTrades(order.Symbol, order.ID, trades)
+	e.publishTrades(order.Symbol, uuid.Nil, settled)
+	e.publishOrderBook(order.Symbol)
+	return order, trades, nil
+}
+
+// GetOrder returns an order of accountID, or of any account when it is nil.
+// The orders of other accounts are not found.
+func (e *Engine) GetOrder(orderID uuid.UUID, accountID *uuid.UUID) (*models.Order, error) {
+	order, err := getOrder(e.db, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if accountID != nil && (order.AccountID == nil || *order.AccountID != *accountID) {
+		return nil, ErrOrderNotFound
+	}
+	return order, nil
+}
+
+// FindOrderByClientOrderID returns the most recent order with the given
+// client order ID among the orders of one source.
+func (e *Engine) FindOrderByClientOrderID(source, clientOrderID string) (*models.Order, error) {
+	var order models.Order
+	query := `SELECT ` + orderColumns + ` FROM orders
+			  WHERE source = $1 AND client_order_id = $2
+			  ORDER BY created_at DESC LIMIT 1`
+	err := scanOrder(e.db.QueryRow(query, source, clientOrderID), &order)
+	if err == sql.ErrNoRows {
+		return nil, ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// GetOrderTrades returns the trades an order took part in, oldest first.
+func (e *Engine) GetOrderTrades(orderID uuid.UUID) ([]models.Trade, error) {
+	query := `SELECT id, buy_order_id, sell_order_id, symbol, price, quantity, executed_at
+			  FROM trades WHERE buy_order_id = $1 OR sell_order_id = $1 ORDER BY executed_at, seq`
+
+	rows, err := e.db.Query(query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var trades []models.Trade
+	for rows.Next() {
+		var trade models.Trade
+		err := rows.Scan(&trade.ID, &trade.BuyOrderID, &trade.SellOrderID,
+			&trade.Symbol, &trade.Price, &trade.Quantity, &trade.ExecutedAt)
+		if err != nil {
+			return nil, err
+		}
+		trades = append(trades, trade)
+	}
+
+	return trades, rows.Err()
+}
+
+func (e *Engine) ListOrders(filter OrderFilter) ([]models.Order, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	if limit > maxListLimit {
+		limit = maxListLimit
+	}
+
+	query := `SELECT ` + orderColumns + ` FROM orders
+			  WHERE ($1 = '' OR symbol = $1) AND ($2 = '' OR side = $2) AND ($3 = '' OR status = $3)
+			    AND ($5::uuid IS NULL OR account_id = $5)
+			  ORDER BY created_at DESC
+			  LIMIT $4`
+
+	rows, err := e.db.Query(query, strings.ToUpper(filter.Symbol), filter.Side, filter.Status, limit, filter.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := []models.Order{}
+	for rows.Next() {
+		var order models.Order
+		if err := scanOrder(rows, &order); err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+
+	return orders, rows.Err()
+}
+
+// CancelOrder cancels an open order on behalf of actor, which is recorded
+// in the order events. A non-nil accountID only cancels the orders of that
+// account: those of others are not found. Only gateways that find the order
+// among their own first, such as a FIX session, pass nil.
+func (e *Engine) CancelOrder(orderID uuid.UUID, accountID *uuid.UUID, actor string) (*models.Order, error) {
+	tx, err := e.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	symbol, err := orderSymbol(tx, orderID, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if err := sequenceCommand(tx, symbol); err != nil {
+		return nil, err
+	}
+	if err := setActor(tx, actor); err != nil {
+		return nil, err
+	}
+
+	order, err := cancelOrder(tx, orderID, accountID, ReasonUserRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	settled, err := settleBook(context.Background(), tx, order.Symbol, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	e.publish(order.Symbol, uuid.Nil, settled)
+	return order, nil
+}
+
+// AmendOrder changes a resting limit order in place. Reducing the quantity
+// keeps the order's queue position; changing the price or increasing the
+// quantity sends it to the back of the queue, and a new price may cross the
+// book, in which case the order is matched straight away.
+func (e *Engine) AmendOrder(ctx context.Context, orderID uuid.UUID, req AmendRequest) (_ *models.Order, _ []models.Trade, err error) {
+	var symbol string
+	defer func() {
+		if err == nil || errors.Is(err, ErrOrderNotFound) {
+			return
+		}
+		if symbol == "" {
+			var lookupErr error
+			if symbol, lookupErr = orderSymbol(e.db, orderID, req.AccountID); lookupErr != nil {
+				return
+			}
+		}
+		e.recordRejection(&orderID, symbol, EventAmendRejected, req.Actor, err, req)
+	}()
+
+	if req.Price == nil && req.Quantity == nil {
+		return nil, nil, newValidationError("price or quantity must be provided")
+	}
+	if req.Price != nil && *req.Price <= 0 {
+		return nil, nil, newValidationError("price must be positive")
+	}
+
+	tx, err := e.db.Begin()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	symbol, err = orderSymbol(tx, orderID, req.AccountID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := sequenceCommand(tx, symbol); err != nil {
+		return nil, nil, err
+	}
+	if err := setActor(tx, req.Actor); err != nil {
+		return nil, nil, err
+	}
+	instrument, err := checkState(tx, symbol, actionAmend)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkPricePrecision("price", req.Price, instrument); err != nil {
+		return nil, nil, err
+	}
+	if err := checkQuantityPrecision("quantity", req.Quantity, instrument); err != nil {
+		return nil, nil, err
+	}
+
+	var order models.Order
+	query := `SELECT ` + orderColumns + ` FROM orders WHERE id = $1 FOR UPDATE`
+	err = scanOrder(tx.QueryRow(query, orderID), &order)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if order.Type != "limit" || order.Status == "filled" || order.Status == "canceled" {
+		return nil, nil, ErrOrderNotAmendable
+	}
+	before := stateOf(&order)
+
+	losesPriority := false
+	if req.Price != nil && *req.Price != *order.Price {
+		order.Price = req.Price
+		losesPriority = true
+	}
+	if req.Quantity != nil {
+		filled := RoundQuantity(order.InitialQuantity - order.RemainingQuantity)
+		if *req.Quantity <= filled {
+			return nil, nil, newValidationError(fmt.Sprintf("quantity must be greater than the filled quantity (%s)", formatQuantity(filled)))
+		}
+		if *req.Quantity > order.InitialQuantity {
+			losesPriority = true
+		}
+		order.InitialQuantity = *req.Quantity
+		order.RemainingQuantity = RoundQuantity(*req.Quantity - filled)
+		clampVisible(&order)
+	}
+	if err := e.checkOrderLimits(order.Symbol, order.InitialQuantity, order.Price); err != nil {
+		return nil, nil, err
+	}
+	if req.ClientOrderID != "" {
+		order.ClientOrderID = &req.ClientOrderID
+	}
+
+	updateQuery := `UPDATE orders
+					SET price = $1, initial_quantity = $2, remaining_quantity = $3, visible_quantity = $4, client_order_id = $5,
+						queued_at = CASE WHEN $6 THEN CURRENT_TIMESTAMP ELSE queued_at END
+					WHERE id = $7 RETURNING updated_at`
+	err = tx.QueryRow(updateQuery, order.Price, order.InitialQuantity, order.RemainingQuantity, order.VisibleQuantity,
+		order.ClientOrderID, losesPriority, order.ID).
+		Scan(&order.UpdatedAt)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to amend order: %w", err)
+	}
+	err = recordOrderEvent(tx, &order, EventAmended, "", &before, map[string]bool{"loses_priority": losesPriority})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// A new price is only matched while the symbol trades continuously
+	var trades []models.Trade
+	if req.Price != nil && allows(instrument.State, actionMatch) {
+		trades, err = matchOrder(ctx, tx, &order)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to match order: %w", err)
+		}
+	}
+
+	settled, err := settleBook(ctx, tx, order.Symbol, trades)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	// Stops and pegs set off by the order traded after it.
+	e.publishTrades(order.Symbol, order.ID, trades)
+	e.publishTrades(order.Symbol, uuid.Nil, settled)
+	e.publishOrderBook(order.Symbol)
+	return &order, trades, nil
+}
+
+// placeOrder inserts and matches an already validated order. The caller
+// must have sequenced the command against the order's symbol.
+func placeOrder(ctx context.Context, tx *sql.Tx, req OrderRequest) (_ *models.Order, _ []models.Trade, err error) {
+	ctx, span := startSpan(ctx, "placeOrder", attribute.String("oms.symbol", req.Symbol))
+	defer func() { endSpan(span, err) }()
+
+	instrument, err := checkState(tx, req.Symbol, actionPlace)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := validatePrecision(&req, instrument); err != nil {
+		return nil, nil, err
+	}
+	if err := checkInitialMargin(tx, instrument, &req); err != nil {
+		return nil, nil, err
+	}
+
+	// A trailing stop without a stop price starts from the last trade
+	if isStopType(req.Type) && req.StopPrice == nil {
+		lastPrice, err := lastTradePrice(tx, req.Symbol)
+		if err != nil {
+			return nil, nil, err
+		}
+		if lastPrice == nil {
+			return nil, nil, newValidationError("stop_price is required until the symbol has traded")
+		}
+		stopPrice := trailingStopPrice(req.Side, *lastPrice, req.TrailAmount, req.TrailPercent, instrument.PricePrecision)
+		req.StopPrice = &stopPrice
+	}
+
+	// A peg order starts at its current pegged price, if there is one
+	if req.Type == "peg" {
+		bid, ask, err := referencePrices(tx, req.Symbol)
+		if err != nil {
+			return nil, nil, err
+		}
+		req.Price = pegPrice(req.Side, req.PegReference, req.PegOffset, req.PegLimitPrice, bid, ask, instrument.PricePrecision)
+	}
+
+	// Insert order. Before the open and during an auction orders are only
+	// collected.
+	order, err := insertOrder(tx, req, instrument.State)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := recordOrderEvent(tx, order, EventAccepted, "", nil, nil); err != nil {
+		return nil, nil, err
+	}
+	if order.Status == "pending" || !allows(instrument.State, actionMatch) {
+		return order, nil, nil
+	}
+
+	if req.PostOnlyReprice {
+		if err := repricePostOnly(tx, order, instrument.PricePrecision); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Match the order
+	trades, err := matchOrder(ctx, tx, order)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to match order: %w", err)
+	}
+
+	return order, trades, nil
+}
+
+// cancelOrder cancels an open order, of the account when accountID is not
+// nil, and records why. The caller must have sequenced the command against
+// the order's symbol.
+func cancelOrder(tx *sql.Tx, orderID uuid.UUID, accountID *uuid.UUID, reason string) (*models.Order, error) {
+	// Check if order exists and can be canceled
+	var order models.Order
+	query := `SELECT ` + orderColumns + ` FROM orders WHERE id = $1 AND ($2::uuid IS NULL OR account_id = $2) FOR UPDATE`
+	err := scanOrder(tx.QueryRow(query, orderID, accountID), &order)
+	if err == sql.ErrNoRows {
+		return nil, ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if order can be canceled
+	if order.Status == "filled" || order.Status == "canceled" {
+		return nil, ErrOrderNotCancelable
+	}
+	if _, err := checkState(tx, order.Symbol, actionCancel); err != nil {
+		return nil, err
+	}
+
+	// Cancel the order
+	before := stateOf(&order)
+	updateQuery := `UPDATE orders SET status = 'canceled', updated_at = CURRENT_TIMESTAMP WHERE id = $1 RETURNING updated_at`
+	if err := tx.QueryRow(updateQuery, orderID).Scan(&order.UpdatedAt); err != nil {
+		return nil, fmt.Errorf("failed to cancel order: %w", err)
+	}
+	order.Status = "canceled"
+
+	if err := recordOrderEvent(tx, &order, cancelEvent(reason), reason, &before, nil); err != nil {
+		return nil, err
+	}
+
+	return &order, nil
+}
+
+// cancelSelected locks and cancels the orders selected by query and records
+// why. The caller must have sequenced the command against their symbols.
+func cancelSelected(tx *sql.Tx, reason, query string, args ...interface{}) ([]models.Order, error) {
+	selected, err := queryOrders(tx, query+` FOR UPDATE`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find orders to cancel: %w", err)
+	}
+
+	orders := make([]models.Order, 0, len(selected))
+	for _, order := range selected {
+		before := stateOf(order)
+		updateQuery := `UPDATE orders SET status = 'canceled', updated_at = CURRENT_TIMESTAMP WHERE id = $1 RETURNING updated_at`
+		if err := tx.QueryRow(updateQuery, order.ID).Scan(&order.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to cancel order: %w", err)
+		}
+		order.Status = "canceled"
+
+		if err := recordOrderEvent(tx, order, cancelEvent(reason), reason, &before, nil); err != nil {
+			return nil, err
+		}
+		orders = append(orders, *order)
+	}
+	return orders, nil
+}
+
+// orderSymbol looks up the symbol of an order so that a command on it can
+// be sequenced before the order itself is locked. A non-nil accountID only finds
+// the orders of that account.
+func orderSymbol(q queryer, orderID uuid.UUID, accountID *uuid.UUID) (string, error) {
+	var symbol string
+	err := q.QueryRow(`SELECT symbol FROM orders WHERE id = $1 AND ($2::uuid IS NULL OR account_id = $2)`, orderID, accountID).Scan(&symbol)
+	if err == sql.ErrNoRows {
+		return "", ErrOrderNotFound
+	}
+	return symbol, err
+}
+
+func getOrder(q queryer, orderID uuid.UUID) (*models.Order, error) {
+	var order models.Order
+	query := `SELECT ` + orderColumns + ` FROM orders WHERE id = $1`
+	err := scanOrder(q.QueryRow(query, orderID), &order)
+	if err == sql.ErrNoRows {
+		return nil, ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func validateOrderRequest(req *OrderRequest) error {
+	// Validate side
+	if req.Side != "buy" && req.Side != "sell" {
+		return newValidationError("side must be 'buy' or 'sell'")
+	}
+
+	// Validate type
+	if req.Type != "limit" && req.Type != "market" && !isStopType(req.Type) && req.Type != "peg" && !isAuctionOrderType(req.Type) {
+		return newValidationError("type must be 'limit', 'market', 'stop', 'stop_limit', 'peg', 'market_on_open' or 'market_on_close'")
+	}
+	if isAuctionOrderType(req.Type) && req.Price != nil {
+		return newValidationError(req.Type + " orders cannot have a price")
+	}
+
+	// Validate price for limit orders
+	if req.Type == "limit" || req.Type == "stop_limit" {
+		if req.Price == nil || *req.Price <= 0 {
+			return newValidationError("limit orders must have a positive price")
+		}
+	}
+
+	// Validate quantity
+	if req.Quantity <= 0 {
+		return newValidationError("quantity must be positive")
+	}
+
+	// Validate display quantity
+	if req.DisplayQuantity != nil {
+		if req.Type != "limit" {
+			return newValidationError("display_quantity is only allowed on limit orders")
+		}
+		if *req.DisplayQuantity <= 0 || *req.DisplayQuantity > req.Quantity {
+			return newValidationError("display_quantity must be positive and at most quantity")
+		}
+	}
+
+	// Validate stop price and trailing offset
+	if err := validateStop(req); err != nil {
+		return err
+	}
+
+	// Validate peg reference, offset and limit price
+	if err := validatePeg(req); err != nil {
+		return err
+	}
+
+	// Validate post-only and hidden flags
+	if (req.PostOnly || req.PostOnlyReprice) && req.Type != "limit" {
+		return newValidationError("post_only is only allowed on limit orders")
+	}
+	if req.Hidden && req.Type != "limit" && req.Type != "peg" {
+		return newValidationError("hidden is only allowed on limit and peg orders")
+	}
+	if req.PostOnlyReprice && !req.PostOnly {
+		return newValidationError("post_only_reprice requires post_only")
+	}
+	if req.Hidden && req.DisplayQuantity != nil {
+		return newValidationError("hidden orders cannot have a display_quantity")
+	}
+
+	// Validate client order ID
+	if len(req.ClientOrderID) > 64 {
+		return newValidationError("client_order_id must be at most 64 characters")
+	}
+
+	// Validate symbol (basic format check): a ticker such as AAPL, or a
+	// pair such as BTC-USD
+	if len(req.Symbol) < 1 || len(req.Symbol) > 20 {
+		return newValidationError("symbol must be between 1 and 20 characters")
+	}
+	req.Symbol = strings.ToUpper(req.Symbol)
+
+	return nil
+}
+
+func insertOrder(tx *sql.Tx, req OrderRequest, state string) (*models.Order, error) {
+	query := `INSERT INTO orders (client_order_id, source, account_id, symbol, side, type, price, initial_quantity, remaining_quantity,
+								  display_quantity, visible_quantity, post_only, hidden, stop_price, trail_amount, trail_percent,
+								  peg_reference, peg_offset, peg_limit_price, liquidation, status)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21)
+			  RETURNING id, created_at, updated_at`
+
+	order := &models.Order{
+		Source:            req.Source,
+		AccountID:         req.AccountID,
+		Symbol:            strings.ToUpper(req.Symbol),
+		Side:              req.Side,
+		Type:              req.Type,
+		Price:             req.Price,
+		InitialQuantity:   req.Quantity,
+		RemainingQuantity: req.Quantity,
+		DisplayQuantity:   req.DisplayQuantity,
+		PostOnly:          req.PostOnly,
+		Hidden:            req.Hidden,
+		StopPrice:         req.StopPrice,
+		TrailAmount:       req.TrailAmount,
+		TrailPercent:      req.TrailPercent,
+		PegOffset:         req.PegOffset,
+		PegLimitPrice:     req.PegLimitPrice,
+		Liquidation:       req.Liquidation,
+		Status:            "open",
+	}
+
+	if req.PegReference != "" {
+		order.PegReference = &req.PegReference
+	}
+
+	// Stop orders wait for their trigger, and peg orders for a reference
+	// price, before they can match. Auction orders, and market orders placed
+	// while orders are collected, wait for the auction.
+	if isStopType(req.Type) || req.Type == "peg" && req.Price == nil || isAuctionOrderType(req.Type) ||
+		req.Type == "market" && collectsOrders(state) {
+		order.Status = "pending"
+	}
+
+	if req.DisplayQuantity != nil {
+		visible := *req.DisplayQuantity
+		order.VisibleQuantity = &visible
+	}
+
+	if req.ClientOrderID != "" {
+		order.ClientOrderID = &req.ClientOrderID
+	}
+
+	err := tx.QueryRow(query, order.ClientOrderID, order.Source, order.AccountID, order.Symbol, order.Side, order.Type, order.Price,
+		order.InitialQuantity, order.RemainingQuantity, order.DisplayQuantity, order.VisibleQuantity,
+		order.PostOnly, order.Hidden, order.StopPrice, order.TrailAmount, order.TrailPercent,
+		order.PegReference, order.PegOffset, order.PegLimitPrice, order.Liquidation, order.Status).
+		Scan(&order.ID, &order.CreatedAt, &order.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert order: %w", err)
+	}
+
+	return order, nil
+}
@@ -0,0 +1,152 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/bartick/golang-order-matching-system/models"
+)
+
+// DefaultQuoteAsset prices the symbols that are not written as a pair,
+// such as equity symbols like AAPL.
+const DefaultQuoteAsset = "USD"
+
+// Precisions of the symbols without their own. Equity symbols trade whole
+// shares in cents; pairs such as BTC-USD trade fractions of the base asset.
+const (
+	defaultPricePrecision        = 2
+	defaultQuantityPrecision     = 0
+	defaultPairQuantityPrecision = 8
+)
+
+// maxPrecision is the number of decimals prices, quantities and balances
+// are stored with.
+const maxPrecision = 8
+
+var assetPattern = regexp.MustCompile(`^[A-Z0-9]{1,10}$`)
+
+// splitPair returns the base and quote asset a symbol is written as, e.g.
+// BTC and USD for BTC-USD. Other symbols are quoted in DefaultQuoteAsset.
+func splitPair(symbol string) (base, quote string, ok bool) {
+	base, quote, ok = strings.Cut(symbol, "-")
+	if !ok {
+		return symbol, DefaultQuoteAsset, false
+	}
+	return base, quote, true
+}
+
+// defaultPair fills in the assets and precisions of an instrument that
+// were not set by an administrator.
+func defaultPair(instrument *models.Instrument) {
+	base, quote, isPair := splitPair(instrument.Symbol)
+	if instrument.BaseAsset == "" {
+		instrument.BaseAsset = base
+	}
+	if instrument.QuoteAsset == "" {
+		instrument.QuoteAsset = quote
+	}
+	if instrument.PricePrecision < 0 {
+		instrument.PricePrecision = defaultPricePrecision
+	}
+	if instrument.QuantityPrecision < 0 {
+		instrument.QuantityPrecision = defaultQuantityPrecision
+		if isPair {
+			instrument.QuantityPrecision = defaultPairQuantityPrecision
+		}
+	}
+}
+
+// roundToTick rounds a price to the given number of decimals.
+func roundToTick(price float64, precision int) float64 {
+	scale := math.Pow10(precision)
+	return math.Round(price*scale) / scale
+}
+
+// tickSize is the smallest price increment with the given number of
+// decimals.
+func tickSize(precision int) float64 {
+	return math.Pow10(-precision)
+}
+
+// RoundQuantity removes the floating point error of quantity arithmetic.
+// Quantities are stored with maxPrecision decimals, so every quantity
+// computed from others is rounded to them before it is compared or stored.
+func RoundQuantity(quantity float64) float64 {
+	return roundToTick(quantity, maxPrecision)
+}
+
+// floorToLot rounds a quantity down to the given number of decimals.
+func floorToLot(quantity float64, precision int) float64 {
+	scale := math.Pow10(precision)
+	return RoundQuantity(math.Floor(RoundQuantity(quantity*scale)) / scale)
+}
+
+// hasPrecision reports whether value has at most precision decimals.
+func hasPrecision(value float64, precision int) bool {
+	return roundToTick(value, precision) == RoundQuantity(value)
+}
+
+// validatePrecision rejects prices and quantities with more decimals than
+// the instrument allows.
+func validatePrecision(req *OrderRequest, instrument *models.Instrument) error {
+	for _, price := range []struct {
+		name  string
+		value *float64
+	}{
+		{"price", req.Price}, {"stop_price", req.StopPrice}, {"trail_amount", req.TrailAmount},
+		{"peg_offset", req.PegOffset}, {"peg_limit_price", req.PegLimitPrice},
+	} {
+		if err := checkPricePrecision(price.name, price.value, instrument); err != nil {
+			return err
+		}
+	}
+	if err := checkQuantityPrecision("quantity", &req.Quantity, instrument); err != nil {
+		return err
+	}
+	return checkQuantityPrecision("display_quantity", req.DisplayQuantity, instrument)
+}
+
+func checkPricePrecision(name string, price *float64, instrument *models.Instrument) error {
+	if price != nil && !hasPrecision(*price, instrument.PricePrecision) {
+		return newValidationError(fmt.Sprintf("%s of %s must have at most %d decimals",
+			name, instrument.Symbol, instrument.PricePrecision))
+	}
+	return nil
+}
+
+func checkQuantityPrecision(name string, quantity *float64, instrument *models.Instrument) error {
+	if quantity != nil && !hasPrecision(*quantity, instrument.QuantityPrecision) {
+		return newValidationError(fmt.Sprintf("%s of %s must have at most %d decimals",
+			name, instrument.Symbol, instrument.QuantityPrecision))
+	}
+	return nil
+}
+
+// SetPair defines the assets a symbol trades and is priced in, and the
+// number of decimals of its prices and quantities. Resting orders are left
+// as they are.
+func (e *Engine) SetPair(symbol, baseAsset, quoteAsset string, pricePrecision, quantityPrecision int) (*models.Instrument, error) {
+	baseAsset, quoteAsset = strings.ToUpper(baseAsset), strings.ToUpper(quoteAsset)
+	if !assetPattern.MatchString(baseAsset) || !assetPattern.MatchString(quoteAsset) {
+		return nil, newValidationError("assets must be 1 to 10 letters or digits")
+	}
+	if baseAsset == quoteAsset {
+		return nil, newValidationError("base and quote asset must differ")
+	}
+	if pricePrecision < 0 || pricePrecision > maxPrecision || quantityPrecision < 0 || quantityPrecision > maxPrecision {
+		return nil, newValidationError(fmt.Sprintf("precisions must be between 0 and %d", maxPrecision))
+	}
+
+	symbol = strings.ToUpper(symbol)
+	query := `INSERT INTO instruments (symbol, base_asset, quote_asset, price_precision, quantity_precision)
+			  VALUES ($1, $2, $3, $4, $5)
+			  ON CONFLICT (symbol) DO UPDATE
+			  SET base_asset = EXCLUDED.base_asset, quote_asset = EXCLUDED.quote_asset,
+				  price_precision = EXCLUDED.price_precision, quantity_precision = EXCLUDED.quantity_precision`
+	if _, err := e.db.Exec(query, symbol, baseAsset, quoteAsset, pricePrecision, quantityPrecision); err != nil {
+		return nil, fmt.Errorf("failed to set pair: %w", err)
+	}
+	return e.GetInstrument(symbol)
+}
@@ -0,0 +1,205 @@
+package engine
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/bartick/golang-order-matching-system/models"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func validatePeg(req *OrderRequest) error {
+	if req.Type != "peg" {
+		if req.PegReference != "" || req.PegOffset != nil || req.PegLimitPrice != nil {
+			return newValidationError("peg_reference, peg_offset and peg_limit_price are only allowed on peg orders")
+		}
+		return nil
+	}
+
+	if req.PegReference != "bid" && req.PegReference != "ask" && req.PegReference != "mid" {
+		return newValidationError("peg_reference must be 'bid', 'ask' or 'mid'")
+	}
+	if req.Price != nil {
+		return newValidationError("peg orders are priced by their peg; use peg_limit_price to cap the price")
+	}
+	if req.PegLimitPrice != nil && *req.PegLimitPrice <= 0 {
+		return newValidationError("peg_limit_price must be positive")
+	}
+
+	// Midpoint liquidity is never displayed.
+	if req.PegReference == "mid" {
+		req.Hidden = true
+	}
+	return nil
+}
+
+func isMidPeg(order *models.Order) bool {
+	return order.PegReference != nil && *order.PegReference == "mid"
+}
+
+// referencePrices returns the best displayed bid and ask of a symbol,
+// either of which is nil when that side is empty. Peg orders are left out
+// so that they never follow each other.
+func referencePrices(q queryer, symbol string) (bid, ask *float64, err error) {
+	query := `SELECT
+				MAX(price) FILTER (WHERE side = 'buy'),
+				MIN(price) FILTER (WHERE side = 'sell')
+			  FROM orders
+			  WHERE symbol = $1 AND status IN ('open', 'partially_filled') AND NOT hidden AND type <> 'peg'`
+
+	var bestBid, bestAsk sql.NullFloat64
+	if err := q.QueryRow(query, symbol).Scan(&bestBid, &bestAsk); err != nil {
+		return nil, nil, fmt.Errorf("failed to load reference prices: %w", err)
+	}
+	if bestBid.Valid {
+		bid = &bestBid.Float64
+	}
+	if bestAsk.Valid {
+		ask = &bestAsk.Float64
+	}
+	return bid, ask, nil
+}
+
+// pegPrice computes the price of a peg order from the reference prices,
+// rounded to the given number of decimals, or nil when its reference does
+// not exist. The limit price caps how far the peg may go: buys never pay
+// more, sells never sell for less.
+func pegPrice(side, reference string, offset, limitPrice, bid, ask *float64, precision int) *float64 {
+	var price float64
+	switch reference {
+	case "bid":
+		if bid == nil {
+			return nil
+		}
+		price = *bid
+	case "ask":
+		if ask == nil {
+			return nil
+		}
+		price = *ask
+	case "mid":
+		if bid == nil || ask == nil {
+			return nil
+		}
+		price = (*bid + *ask) / 2
+	}
+	if offset != nil {
+		price += *offset
+	}
+
+	if limitPrice != nil {
+		if side == "buy" {
+			price = min(price, *limitPrice)
+		} else {
+			price = max(price, *limitPrice)
+		}
+	}
+
+	price = roundToTick(price, precision)
+	if price <= 0 {
+		return nil
+	}
+	return &price
+}
+
+// repegOrders moves the peg orders of a symbol to their current pegged
+// price. A repriced order goes to the back of the queue at its new price
+// and may cross the book, in which case it is matched straight away; the
+// resulting trades are returned.
+func repegOrders(ctx context.Context, tx *sql.Tx, symbol string) ([]models.Trade, error) {
+	bid, ask, err := referencePrices(tx, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT ` + orderColumns + ` FROM orders
+			  WHERE symbol = $1 AND type = 'peg' AND status IN ('pending', 'open', 'partially_filled')
+			  ORDER BY queued_at ASC`
+	pegs, err := queryOrders(tx, query, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find peg orders: %w", err)
+	}
+	if len(pegs) == 0 {
+		return nil, nil
+	}
+	instrument, err := getInstrument(tx, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	var trades []models.Trade
+	for _, peg := range pegs {
+		price := pegPrice(peg.Side, *peg.PegReference, peg.PegOffset, peg.PegLimitPrice, bid, ask, instrument.PricePrecision)
+		if price == nil && peg.Price == nil || price != nil && peg.Price != nil && *price == *peg.Price {
+			continue
+		}
+
+		// Without a reference price the order is suspended until one
+		// comes back.
+		before := stateOf(peg)
+		peg.Price = price
+		peg.Status = "pending"
+		if price != nil {
+			peg.Status = orderStatus(peg)
+		}
+
+		query := `UPDATE orders SET price = $1, status = $2, queued_at = clock_timestamp(), updated_at = CURRENT_TIMESTAMP
+				  WHERE id = $3`
+		if _, err := tx.Exec(query, peg.Price, peg.Status, peg.ID); err != nil {
+			return nil, fmt.Errorf("failed to reprice peg order: %w", err)
+		}
+		if err := recordOrderEvent(tx, peg, EventRepriced, ReasonPeg, &before, nil); err != nil {
+			return nil, err
+		}
+
+		if price != nil {
+			pegTrades, err := matchOrder(ctx, tx, peg)
+			if err != nil {
+				return nil, fmt.Errorf("failed to match peg order: %w", err)
+			}
+			trades = append(trades, pegTrades...)
+		}
+	}
+
+	return trades, nil
+}
+
+// settleBook brings a book back to a consistent state after a command: it
+// triggers the stops reached by the command's trades and reprices the peg
+// orders, over and over while either produces new trades. The trades
+// produced along the way are returned. Nothing is done unless the symbol
+// trades continuously, and settling stops if it is halted on the way.
+func settleBook(ctx context.Context, tx *sql.Tx, symbol string, trades []models.Trade) (_ []models.Trade, err error) {
+	ctx, span := startSpan(ctx, "settleBook", attribute.String("oms.symbol", symbol))
+	defer func() { endSpan(span, err) }()
+
+	// Whatever settling does is done by the engine, not by the issuer of
+	// the command.
+	if err := setActor(tx, ActorEngine); err != nil {
+		return nil, err
+	}
+
+	var settled []models.Trade
+	for {
+		if ok, err := canMatch(tx, symbol); err != nil || !ok {
+			return settled, err
+		}
+
+		triggered, err := triggerStops(ctx, tx, symbol, trades)
+		if err != nil {
+			return nil, err
+		}
+		settled = append(settled, triggered...)
+
+		pegTrades, err := repegOrders(ctx, tx, symbol)
+		if err != nil {
+			return nil, err
+		}
+		if len(pegTrades) == 0 {
+			return settled, nil
+		}
+		settled = append(settled, pegTrades...)
+		trades = pegTrades
+	}
+}
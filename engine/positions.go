@@ -0,0 +1,366 @@
+package engine
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/bartick/golang-order-matching-system/models"
+	"github.com/google/uuid"
+)
+
+// settlePositions adds the quantity of a trade to the position of the
+// buyer and takes it from the position of the seller.
+func settlePositions(tx *sql.Tx, instrument *models.Instrument, trade *models.Trade, buy, sell *models.Order) error {
+	for _, movement := range []struct {
+		accountID *uuid.UUID
+		quantity  float64
+	}{
+		{buy.AccountID, trade.Quantity},
+		{sell.AccountID, -trade.Quantity},
+	} {
+		if movement.accountID == nil {
+			continue
+		}
+		if err := adjustPosition(tx, *movement.accountID, instrument, movement.quantity, trade.Price); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func adjustPosition(tx *sql.Tx, accountID uuid.UUID, instrument *models.Instrument, quantity, price float64) error {
+	position := models.Position{AccountID: accountID, Symbol: instrument.Symbol}
+	query := `SELECT quantity, average_entry_price, realized_pnl FROM positions
+			  WHERE account_id = $1 AND symbol = $2 FOR UPDATE`
+	err := tx.QueryRow(query, accountID, instrument.Symbol).
+		Scan(&position.Quantity, &position.AverageEntryPrice, &position.RealizedPnL)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to load %s position: %w", instrument.Symbol, err)
+	}
+
+	applyFill(&position, quantity, price, contractMultiplier(instrument))
+	if err := storePosition(tx, &position); err != nil {
+		return fmt.Errorf("failed to update %s position: %w", instrument.Symbol, err)
+	}
+	return nil
+}
+
+func storePosition(tx *sql.Tx, position *models.Position) error {
+	query := `INSERT INTO positions (account_id, symbol, quantity, average_entry_price, realized_pnl)
+			  VALUES ($1, $2, $3, $4, $5)
+			  ON CONFLICT (account_id, symbol) DO UPDATE
+			  SET quantity = EXCLUDED.quantity, average_entry_price = EXCLUDED.average_entry_price,
+				  realized_pnl = EXCLUDED.realized_pnl`
+	_, err := tx.Exec(query, position.AccountID, position.Symbol, position.Quantity,
+		position.AverageEntryPrice, position.RealizedPnL)
+	return err
+}
+
+// applyFill adds a fill of quantity, negative for a sale, at price to a
+// position. A fill in the direction of the position moves its average
+// entry price; a fill against it realizes the price difference on the
+// quantity it closes, and whatever goes beyond opens the other way at
+// price.
+func applyFill(position *models.Position, quantity, price, multiplier float64) {
+	held := position.Quantity
+	if held == 0 || (held > 0) == (quantity > 0) {
+		cost := position.AverageEntryPrice*math.Abs(held) + price*math.Abs(quantity)
+		position.AverageEntryPrice = RoundQuantity(cost / math.Abs(held+quantity))
+	} else {
+		closed := math.Min(math.Abs(quantity), math.Abs(held))
+		pnl := closed * (price - position.AverageEntryPrice) * multiplier
+		if held < 0 {
+			pnl = -pnl
+		}
+		position.RealizedPnL = RoundQuantity(position.RealizedPnL + pnl)
+		if math.Abs(quantity) > math.Abs(held) {
+			position.AverageEntryPrice = price
+		}
+	}
+
+	position.Quantity = RoundQuantity(held + quantity)
+	if position.Quantity == 0 {
+		position.AverageEntryPrice = 0
+	}
+}
+
+// contractMultiplier is how much of the quote asset a price move of one
+// is worth per unit of quantity.
+func contractMultiplier(instrument *models.Instrument) float64 {
+	if instrument.ContractMultiplier != nil {
+		return *instrument.ContractMultiplier
+	}
+	return 1
+}
+
+// GetPositions returns the positions of an account, by symbol, marked to
+// the current prices.
+func (e *Engine) GetPositions(accountID uuid.UUID) ([]models.Position, error) {
+	query := `SELECT account_id, symbol, quantity, average_entry_price, realized_pnl, updated_at
+			  FROM positions WHERE account_id = $1 ORDER BY symbol`
+	rows, err := e.db.Query(query, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load positions: %w", err)
+	}
+	defer rows.Close()
+
+	positions := []models.Position{}
+	for rows.Next() {
+		var position models.Position
+		err := rows.Scan(&position.AccountID, &position.Symbol, &position.Quantity,
+			&position.AverageEntryPrice, &position.RealizedPnL, &position.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		positions = append(positions, position)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for i := range positions {
+		if err := e.markPosition(&positions[i]); err != nil {
+			return nil, err
+		}
+	}
+	return positions, nil
+}
+
+// GetPosition returns the position of an account in a symbol, marked to
+// the current price.
+func (e *Engine) GetPosition(accountID uuid.UUID, symbol string) (*models.Position, error) {
+	position := &models.Position{}
+	query := `SELECT account_id, symbol, quantity, average_entry_price, realized_pnl, updated_at
+			  FROM positions WHERE account_id = $1 AND symbol = $2`
+	err := e.db.QueryRow(query, accountID, strings.ToUpper(symbol)).Scan(&position.AccountID, &position.Symbol,
+		&position.Quantity, &position.AverageEntryPrice, &position.RealizedPnL, &position.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrPositionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load position: %w", err)
+	}
+
+	if err := e.markPosition(position); err != nil {
+		return nil, err
+	}
+	return position, nil
+}
+
+// markPosition sets the mark price and unrealized PnL of a position.
+func (e *Engine) markPosition(position *models.Position) error {
+	instrument, err := getInstrument(e.db, position.Symbol)
+	if err != nil {
+		return err
+	}
+	mark, err := markPrice(e.db, position.Symbol)
+	if err != nil || mark == nil {
+		return err
+	}
+
+	unrealized := RoundQuantity(position.Quantity * (*mark - position.AverageEntryPrice) * contractMultiplier(instrument))
+	position.MarkPrice = mark
+	position.UnrealizedPnL = &unrealized
+	return nil
+}
+
+// markPrice is the mid price of a symbol's displayed book, or its last
+// trade price when either side is empty. It is nil when there is neither.
+func markPrice(q queryer, symbol string) (*float64, error) {
+	bid, ask, err := referencePrices(q, symbol)
+	if err != nil {
+		return nil, err
+	}
+	if bid != nil && ask != nil {
+		mid := RoundQuantity((*bid + *ask) / 2)
+		return &mid, nil
+	}
+	return lastTradePrice(q, symbol)
+}
+
+// PositionMismatch is a position whose stored state differs from the one
+// rebuilt from the trades. Stored is nil when the position is missing, and
+// Rebuilt when no trade accounts for it.
+type PositionMismatch struct {
+	Stored  *models.Position `json:"stored"`
+	Rebuilt *models.Position `json:"rebuilt"`
+}
+
+// RebuildPositions recomputes every position from the full history of
+// trades and returns those that differ from the positions maintained trade
+// by trade. fix replaces the stored positions that differ with the rebuilt
+// ones.
+func (e *Engine) RebuildPositions(fix bool) ([]PositionMismatch, error) {
+	tx, err := e.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Trades settle into positions in the transaction that creates them, so
+	// once no transaction can write positions every committed trade is
+	// accounted for and later ones wait for the rebuild.
+	if _, err := tx.Exec(`LOCK TABLE positions IN EXCLUSIVE MODE`); err != nil {
+		return nil, fmt.Errorf("failed to lock positions: %w", err)
+	}
+
+	rebuilt, err := replayTrades(tx)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := storedPositions(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	var mismatches []PositionMismatch
+	for key, position := range rebuilt {
+		if current, ok := stored[key]; !ok || !samePosition(current, position) {
+			mismatches = append(mismatches, PositionMismatch{Stored: current, Rebuilt: position})
+		}
+	}
+	for key, position := range stored {
+		if _, ok := rebuilt[key]; !ok {
+			mismatches = append(mismatches, PositionMismatch{Stored: position})
+		}
+	}
+	sort.Slice(mismatches, func(i, j int) bool {
+		a, b := mismatchPosition(mismatches[i]), mismatchPosition(mismatches[j])
+		if a.Symbol != b.Symbol {
+			return a.Symbol < b.Symbol
+		}
+		return a.AccountID.String() < b.AccountID.String()
+	})
+
+	if !fix || len(mismatches) == 0 {
+		return mismatches, nil
+	}
+	for _, mismatch := range mismatches {
+		if mismatch.Rebuilt == nil {
+			_, err = tx.Exec(`DELETE FROM positions WHERE account_id = $1 AND symbol = $2`,
+				mismatch.Stored.AccountID, mismatch.Stored.Symbol)
+		} else {
+			err = storePosition(tx, mismatch.Rebuilt)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to fix position: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return mismatches, nil
+}
+
+type positionKey struct {
+	accountID uuid.UUID
+	symbol    string
+}
+
+// replayTrades applies every trade between orders with an account, in the
+// order they were executed, to a fresh set of positions.
+func replayTrades(tx *sql.Tx) (map[positionKey]*models.Position, error) {
+	query := `SELECT t.symbol, t.price, t.quantity, bo.account_id, so.account_id
+			  FROM trades t
+			  JOIN orders bo ON bo.id = t.buy_order_id
+			  JOIN orders so ON so.id = t.sell_order_id
+			  WHERE bo.account_id IS NOT NULL OR so.account_id IS NOT NULL
+			  ORDER BY t.executed_at, t.seq`
+	rows, err := tx.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load trades: %w", err)
+	}
+	defer rows.Close()
+
+	type fill struct {
+		symbol                  string
+		price, quantity         float64
+		buyAccount, sellAccount *uuid.UUID
+	}
+	var fills []fill
+	for rows.Next() {
+		var f fill
+		if err := rows.Scan(&f.symbol, &f.price, &f.quantity, &f.buyAccount, &f.sellAccount); err != nil {
+			return nil, err
+		}
+		fills = append(fills, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	multipliers := make(map[string]float64)
+	positions := make(map[positionKey]*models.Position)
+	for _, f := range fills {
+		multiplier, ok := multipliers[f.symbol]
+		if !ok {
+			instrument, err := getInstrument(tx, f.symbol)
+			if err != nil {
+				return nil, err
+			}
+			multiplier = contractMultiplier(instrument)
+			multipliers[f.symbol] = multiplier
+		}
+
+		for _, movement := range []struct {
+			accountID *uuid.UUID
+			quantity  float64
+		}{
+			{f.buyAccount, f.quantity},
+			{f.sellAccount, -f.quantity},
+		} {
+			if movement.accountID == nil {
+				continue
+			}
+			key := positionKey{*movement.accountID, f.symbol}
+			position, ok := positions[key]
+			if !ok {
+				position = &models.Position{AccountID: key.accountID, Symbol: key.symbol}
+				positions[key] = position
+			}
+			applyFill(position, movement.quantity, f.price, multiplier)
+		}
+	}
+	return positions, nil
+}
+
+func storedPositions(tx *sql.Tx) (map[positionKey]*models.Position, error) {
+	query := `SELECT account_id, symbol, quantity, average_entry_price, realized_pnl, updated_at FROM positions`
+	rows, err := tx.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load positions: %w", err)
+	}
+	defer rows.Close()
+
+	positions := make(map[positionKey]*models.Position)
+	for rows.Next() {
+		position := &models.Position{}
+		err := rows.Scan(&position.AccountID, &position.Symbol, &position.Quantity,
+			&position.AverageEntryPrice, &position.RealizedPnL, &position.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		positions[positionKey{position.AccountID, position.Symbol}] = position
+	}
+	return positions, rows.Err()
+}
+
+// samePosition compares positions to the precision they are stored with.
+func samePosition(a, b *models.Position) bool {
+	const tolerance = 0.5e-8
+	return math.Abs(a.Quantity-b.Quantity) < tolerance &&
+		math.Abs(a.AverageEntryPrice-b.AverageEntryPrice) < tolerance &&
+		math.Abs(a.RealizedPnL-b.RealizedPnL) < tolerance
+}
+
+func mismatchPosition(mismatch PositionMismatch) *models.Position {
+	if mismatch.Rebuilt != nil {
+		return mismatch.Rebuilt
+	}
+	return mismatch.Stored
+}
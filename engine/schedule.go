@@ -0,0 +1,239 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bartick/golang-order-matching-system/models"
+
+	// The schedule's timezones must load without a system zoneinfo.
+	_ "time/tzdata"
+)
+
+// scheduleInterval is how often the trading schedule is checked.
+const scheduleInterval = time.Second
+
+// Schedule drives the state of instruments through the trading day. Symbols
+// that are not part of any session are left alone.
+type Schedule struct {
+	Sessions []*TradingSession `json:"sessions"`
+
+	sessions map[string]*TradingSession
+	stop     chan struct{}
+}
+
+// TradingSession is the trading day of a group of symbols. Times are
+// "HH:MM" in Timezone. The symbols are closed until PreOpen, collect orders
+// from PreOpen, call the opening auction from OpeningAuction, trade
+// continuously from Continuous, call the closing auction from
+// ClosingAuction and close at Close. OpeningAuction and ClosingAuction are
+// optional. Outside Weekdays (Monday to Friday by default) and on Holidays
+// ("YYYY-MM-DD") the symbols stay closed.
+type TradingSession struct {
+	Symbols        []string `json:"symbols"`
+	Timezone       string   `json:"timezone"`
+	PreOpen        string   `json:"pre_open"`
+	OpeningAuction string   `json:"opening_auction"`
+	Continuous     string   `json:"continuous"`
+	ClosingAuction string   `json:"closing_auction"`
+	Close          string   `json:"close"`
+	Weekdays       []string `json:"weekdays"`
+	Holidays       []string `json:"holidays"`
+
+	location *time.Location
+	phases   []schedulePhase
+	weekdays map[time.Weekday]bool
+	holidays map[string]bool
+}
+
+// schedulePhase is a state that starts at a time of day, in minutes after
+// midnight.
+type schedulePhase struct {
+	start   int
+	state   string
+	auction string
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday,
+	"thursday": time.Thursday, "friday": time.Friday, "saturday": time.Saturday,
+}
+
+// LoadSchedule reads a trading schedule from a JSON file.
+func LoadSchedule(path string) (*Schedule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trading schedule: %w", err)
+	}
+
+	var schedule Schedule
+	if err := json.Unmarshal(data, &schedule); err != nil {
+		return nil, fmt.Errorf("failed to parse trading schedule: %w", err)
+	}
+
+	schedule.sessions = make(map[string]*TradingSession)
+	for i, session := range schedule.Sessions {
+		if err := session.parse(); err != nil {
+			return nil, fmt.Errorf("trading session %d: %w", i, err)
+		}
+		for _, symbol := range session.Symbols {
+			symbol = strings.ToUpper(symbol)
+			if _, ok := schedule.sessions[symbol]; ok {
+				return nil, fmt.Errorf("trading session %d: %s is already scheduled", i, symbol)
+			}
+			schedule.sessions[symbol] = session
+		}
+	}
+	return &schedule, nil
+}
+
+func (s *TradingSession) parse() error {
+	if len(s.Symbols) == 0 {
+		return fmt.Errorf("no symbols")
+	}
+
+	var err error
+	if s.location, err = time.LoadLocation(s.Timezone); err != nil {
+		return fmt.Errorf("invalid timezone %q: %w", s.Timezone, err)
+	}
+
+	s.phases = nil
+	for _, phase := range []struct {
+		name, value, state, auction string
+		required                    bool
+	}{
+		{"pre_open", s.PreOpen, StatePreOpen, "", true},
+		{"opening_auction", s.OpeningAuction, StateAuction, AuctionOpening, false},
+		{"continuous", s.Continuous, StateContinuous, "", true},
+		{"closing_auction", s.ClosingAuction, StateAuction, AuctionClosing, false},
+		{"close", s.Close, StateClosed, "", true},
+	} {
+		if phase.value == "" {
+			if phase.required {
+				return fmt.Errorf("%s is required", phase.name)
+			}
+			continue
+		}
+		clock, err := time.Parse("15:04", phase.value)
+		if err != nil {
+			return fmt.Errorf("%s must be HH:MM", phase.name)
+		}
+		start := clock.Hour()*60 + clock.Minute()
+		if len(s.phases) > 0 && start <= s.phases[len(s.phases)-1].start {
+			return fmt.Errorf("%s must be after the previous phase", phase.name)
+		}
+		s.phases = append(s.phases, schedulePhase{start: start, state: phase.state, auction: phase.auction})
+	}
+
+	weekdays := s.Weekdays
+	if len(weekdays) == 0 {
+		weekdays = []string{"monday", "tuesday", "wednesday", "thursday", "friday"}
+	}
+	s.weekdays = make(map[time.Weekday]bool)
+	for _, name := range weekdays {
+		weekday, ok := weekdayNames[strings.ToLower(name)]
+		if !ok {
+			return fmt.Errorf("invalid weekday %q", name)
+		}
+		s.weekdays[weekday] = true
+	}
+
+	s.holidays = make(map[string]bool)
+	for _, holiday := range s.Holidays {
+		if _, err := time.Parse(time.DateOnly, holiday); err != nil {
+			return fmt.Errorf("holiday %q must be YYYY-MM-DD", holiday)
+		}
+		s.holidays[holiday] = true
+	}
+	return nil
+}
+
+// stateAt returns the scheduled state, and auction, of the session's
+// symbols at t.
+func (s *TradingSession) stateAt(t time.Time) (string, string) {
+	local := t.In(s.location)
+	if !s.weekdays[local.Weekday()] || s.holidays[local.Format(time.DateOnly)] {
+		return StateClosed, ""
+	}
+
+	minute := local.Hour()*60 + local.Minute()
+	state, auction := StateClosed, ""
+	for _, phase := range s.phases {
+		if minute < phase.start {
+			break
+		}
+		state, auction = phase.state, phase.auction
+	}
+	return state, auction
+}
+
+// apply moves a scheduled symbol to its current scheduled state. It does
+// nothing for symbols without a schedule or overridden by an administrator.
+func (s *Schedule) apply(e *Engine, symbol string) error {
+	if s == nil {
+		return nil
+	}
+	session, ok := s.sessions[symbol]
+	if !ok {
+		return nil
+	}
+
+	state, auction := session.stateAt(time.Now())
+	change, err := e.transition(symbol, false, func(*models.Instrument) (string, string, error) {
+		return state, auction, nil
+	})
+	if err != nil {
+		return err
+	}
+	if change != nil {
+		slog.Info("schedule moved instrument", "symbol", symbol, "state", state)
+	}
+	return nil
+}
+
+// StartSchedule applies the trading schedule until StopSchedule is called.
+func (e *Engine) StartSchedule(schedule *Schedule) {
+	e.schedule = schedule
+	schedule.stop = make(chan struct{})
+	e.heartbeats.start(MonitorSchedule, scheduleInterval)
+
+	go func() {
+		ticker := time.NewTicker(scheduleInterval)
+		defer ticker.Stop()
+
+		// The scheduled state of every symbol is applied once at startup
+		// and then every time it changes.
+		applied := make(map[string]string)
+		for {
+			for symbol, session := range schedule.sessions {
+				state, auction := session.stateAt(time.Now())
+				if applied[symbol] == state+auction {
+					continue
+				}
+				if err := schedule.apply(e, symbol); err != nil {
+					slog.Error("schedule failed to move instrument", "symbol", symbol, "state", state, "error", err)
+					continue
+				}
+				applied[symbol] = state + auction
+			}
+			e.heartbeats.beat(MonitorSchedule)
+
+			select {
+			case <-schedule.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (e *Engine) StopSchedule() {
+	if e.schedule != nil {
+		close(e.schedule.stop)
+		e.heartbeats.stop(MonitorSchedule)
+	}
+}
@@ -0,0 +1,49 @@
+package engine
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+)
+
+// sequenceCommand assigns the next sequence number of every given symbol to
+// the command running in tx. The row locks taken on symbol_sequences are
+// held until tx ends, so commands touching the same book are applied one
+// after the other, in sequence order, and never interleave. Symbols are
+// locked in a fixed order so that multi-symbol commands cannot deadlock.
+func sequenceCommand(tx *sql.Tx, symbols ...string) error {
+	sorted := uniqueSymbols(symbols)
+	sort.Strings(sorted)
+
+	query := `INSERT INTO symbol_sequences (symbol, last_sequence) VALUES ($1, 1)
+			  ON CONFLICT (symbol) DO UPDATE SET last_sequence = symbol_sequences.last_sequence + 1`
+	for _, symbol := range sorted {
+		if _, err := tx.Exec(query, symbol); err != nil {
+			return fmt.Errorf("failed to sequence command for %s: %w", symbol, err)
+		}
+	}
+
+	return nil
+}
+
+// SymbolSequences returns the sequence number of the last command committed
+// on every symbol. Since commands on a symbol commit in sequence order,
+// every order event up to that number is already visible.
+func (e *Engine) SymbolSequences() (map[string]int64, error) {
+	rows, err := e.db.Query(`SELECT symbol, last_sequence FROM symbol_sequences`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sequences := make(map[string]int64)
+	for rows.Next() {
+		var symbol string
+		var sequence int64
+		if err := rows.Scan(&symbol, &sequence); err != nil {
+			return nil, err
+		}
+		sequences[symbol] = sequence
+	}
+	return sequences, rows.Err()
+}
@@ -0,0 +1,168 @@
+package engine
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/bartick/golang-order-matching-system/models"
+)
+
+func isStopType(orderType string) bool {
+	return orderType == "stop" || orderType == "stop_limit"
+}
+
+// isMarketType reports whether an order trades at any price: a market
+// order, or a stop order once triggered.
+func isMarketType(orderType string) bool {
+	return orderType == "market" || orderType == "stop"
+}
+
+func validateStop(req *OrderRequest) error {
+	trailing := req.TrailAmount != nil || req.TrailPercent != nil
+	if !isStopType(req.Type) {
+		if req.StopPrice != nil || trailing {
+			return newValidationError("stop_price and trailing offsets are only allowed on stop orders")
+		}
+		return nil
+	}
+
+	if req.StopPrice == nil && !trailing {
+		return newValidationError("stop orders must have a stop_price or a trailing offset")
+	}
+	if req.StopPrice != nil && *req.StopPrice <= 0 {
+		return newValidationError("stop_price must be positive")
+	}
+	if req.TrailAmount != nil && req.TrailPercent != nil {
+		return newValidationError("trail_amount and trail_percent are mutually exclusive")
+	}
+	if req.TrailAmount != nil && *req.TrailAmount <= 0 {
+		return newValidationError("trail_amount must be positive")
+	}
+	if req.TrailPercent != nil && (*req.TrailPercent <= 0 || *req.TrailPercent >= 100) {
+		return newValidationError("trail_percent must be between 0 and 100")
+	}
+	return nil
+}
+
+// trailingStopPrice is where a trailing stop sits for a given last trade
+// price: below it for sell stops, above it for buy stops. It is rounded to
+// the given number of decimals.
+func trailingStopPrice(side string, lastPrice float64, trailAmount, trailPercent *float64, precision int) float64 {
+	offset := 0.0
+	if trailAmount != nil {
+		offset = *trailAmount
+	} else if trailPercent != nil {
+		offset = lastPrice * *trailPercent / 100
+	}
+
+	if side == "sell" {
+		return roundToTick(lastPrice-offset, precision)
+	}
+	return roundToTick(lastPrice+offset, precision)
+}
+
+func lastTradePrice(q queryer, symbol string) (*float64, error) {
+	var price float64
+	err := q.QueryRow(`SELECT price FROM trades WHERE symbol = $1 ORDER BY executed_at DESC, seq DESC LIMIT 1`, symbol).Scan(&price)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load last trade price: %w", err)
+	}
+	return &price, nil
+}
+
+// triggerStops runs the pending stop orders of a symbol against the trades
+// just produced by a command. Trailing stops first follow the last trade
+// price, then every stop whose price was reached is activated and matched.
+// Their trades can trigger further stops, so this repeats until no stop is
+// left to trigger. The trades of the triggered stops are returned.
+func triggerStops(ctx context.Context, tx *sql.Tx, symbol string, trades []models.Trade) ([]models.Trade, error) {
+	var triggered []models.Trade
+
+	for len(trades) > 0 {
+		lastPrice := trades[len(trades)-1].Price
+		if err := trailStops(tx, symbol, lastPrice); err != nil {
+			return nil, err
+		}
+
+		query := `SELECT ` + orderColumns + ` FROM orders
+				  WHERE symbol = $1 AND status = 'pending'
+				    AND ((side = 'buy' AND stop_price <= $2) OR (side = 'sell' AND stop_price >= $2))
+				  ORDER BY queued_at ASC`
+		stops, err := queryOrders(tx, query, symbol, lastPrice)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find triggered stops: %w", err)
+		}
+
+		trades = nil
+		for _, stop := range stops {
+			// Stops wait for trading to resume after a volatility halt
+			if ok, err := canMatch(tx, symbol); err != nil || !ok {
+				return append(triggered, trades...), err
+			}
+
+			_, err := tx.Exec(`UPDATE orders SET status = 'open', queued_at = clock_timestamp(), updated_at = CURRENT_TIMESTAMP
+							   WHERE id = $1`, stop.ID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to trigger stop order: %w", err)
+			}
+			before := stateOf(stop)
+			stop.Status = "open"
+			err = recordOrderEvent(tx, stop, EventTriggered, "", &before, map[string]float64{"last_price": lastPrice})
+			if err != nil {
+				return nil, err
+			}
+
+			stopTrades, err := matchOrder(ctx, tx, stop)
+			if err != nil {
+				return nil, fmt.Errorf("failed to match triggered stop order: %w", err)
+			}
+			trades = append(trades, stopTrades...)
+		}
+		triggered = append(triggered, trades...)
+	}
+
+	return triggered, nil
+}
+
+// trailStops moves the stop price of trailing stops that the last trade
+// price has moved away from. Stop prices only ever move in the favourable
+// direction: up for sell stops, down for buy stops.
+func trailStops(tx *sql.Tx, symbol string, lastPrice float64) error {
+	query := `SELECT ` + orderColumns + ` FROM orders
+			  WHERE symbol = $1 AND status = 'pending' AND (trail_amount IS NOT NULL OR trail_percent IS NOT NULL)`
+	stops, err := queryOrders(tx, query, symbol)
+	if err != nil {
+		return fmt.Errorf("failed to find trailing stops: %w", err)
+	}
+	if len(stops) == 0 {
+		return nil
+	}
+	instrument, err := getInstrument(tx, symbol)
+	if err != nil {
+		return err
+	}
+
+	for _, stop := range stops {
+		stopPrice := trailingStopPrice(stop.Side, lastPrice, stop.TrailAmount, stop.TrailPercent, instrument.PricePrecision)
+		if stop.Side == "sell" && stopPrice <= *stop.StopPrice || stop.Side == "buy" && stopPrice >= *stop.StopPrice {
+			continue
+		}
+
+		_, err := tx.Exec(`UPDATE orders SET stop_price = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`, stopPrice, stop.ID)
+		if err != nil {
+			return fmt.Errorf("failed to adjust trailing stop: %w", err)
+		}
+		details := map[string]float64{"stop_price": stopPrice, "previous_stop_price": *stop.StopPrice, "last_price": lastPrice}
+		before := stateOf(stop)
+		stop.StopPrice = &stopPrice
+		if err := recordOrderEvent(tx, stop, EventTrailAdjusted, "", &before, details); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package engine
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/bartick/golang-order-matching-system/models"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer records the spans of the matching path. It uses the global tracer
+// provider, so no spans are recorded unless the application sets one up.
+var tracer = otel.Tracer("github.com/bartick/golang-order-matching-system/engine")
+
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endSpan marks the span as failed when err is set and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func orderAttributes(order *models.Order) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("oms.order_id", order.ID.String()),
+		attribute.String("oms.symbol", order.Symbol),
+		attribute.String("oms.side", order.Side),
+		attribute.String("oms.order_type", order.Type),
+	}
+}
+
+// dbSpan starts the span of a query run on behalf of an order.
+func dbSpan(ctx context.Context, name string, order *models.Order) (context.Context, trace.Span) {
+	return startSpan(ctx, name, append(orderAttributes(order), attribute.String("db.system", "postgresql"))...)
+}
+
+// commit commits the transaction of a command within its own span.
+func commit(ctx context.Context, tx *sql.Tx) (err error) {
+	_, span := startSpan(ctx, "commit", attribute.String("db.system", "postgresql"))
+	defer func() { endSpan(span, err) }()
+	return tx.Commit()
+}
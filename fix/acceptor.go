@@ -0,0 +1,235 @@
+package fix
+
+import (
+	"bufio"
+	"errors"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/bartick/golang-order-matching-system/engine"
+	"github.com/bartick/golang-order-matching-system/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// sourcePrefix marks the orders placed through the gateway. It is followed
+// by the counterparty's CompID.
+const sourcePrefix = "fix:"
+
+// Acceptor is a FIX 4.4 acceptor that maps order entry messages onto the
+// matching engine. Only the configured counterparties may log on, each with
+// the credentials of the account its SenderCompID maps to, and their orders
+// belong to that account.
+type Acceptor struct {
+	SenderCompID string
+
+	engine         *engine.Engine
+	store          *Store
+	counterparties map[string]uuid.UUID
+
+	mu       sync.Mutex
+	sessions map[SessionID]*Session
+	closed   bool
+	conns    map[net.Conn]struct{}
+	done     chan struct{}
+}
+
+// NewAcceptor accepts the counterparties given by their SenderCompID, each
+// trading for the account it maps to.
+func NewAcceptor(senderCompID string, counterparties map[string]uuid.UUID, eng *engine.Engine, db *sqlx.DB) *Acceptor {
+	return &Acceptor{
+		SenderCompID:   senderCompID,
+		engine:         eng,
+		store:          NewStore(db),
+		counterparties: counterparties,
+		sessions:       make(map[SessionID]*Session),
+		conns:          make(map[net.Conn]struct{}),
+		done:           make(chan struct{}),
+	}
+}
+
+// Serve accepts connections on lis until it is closed. The fills and
+// cancels the sessions did not ask for are reported to them while Serve
+// runs.
+func (a *Acceptor) Serve(lis net.Listener) error {
+	go a.watchEvents()
+
+	for {
+		conn, err := lis.Accept()
+		if err != nil {
+			if a.isClosed() {
+				return nil
+			}
+			return err
+		}
+		go a.handleConn(conn)
+	}
+}
+
+// Close disconnects every session. The listener passed to Serve must be
+// closed by the caller.
+func (a *Acceptor) Close() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.closed {
+		return
+	}
+	a.closed = true
+	close(a.done)
+	for conn := range a.conns {
+		conn.Close()
+	}
+}
+
+func (a *Acceptor) isClosed() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.closed
+}
+
+func (a *Acceptor) track(conn net.Conn) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.closed {
+		return false
+	}
+	a.conns[conn] = struct{}{}
+	return true
+}
+
+func (a *Acceptor) untrack(conn net.Conn) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.conns, conn)
+}
+
+func (a *Acceptor) session(id SessionID, accountID uuid.UUID) *Session {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	session, ok := a.sessions[id]
+	if !ok {
+		session = &Session{ID: id, AccountID: accountID, acceptor: a}
+		a.sessions[id] = session
+	}
+	return session
+}
+
+func (a *Acceptor) handleConn(conn net.Conn) {
+	if !a.track(conn) {
+		conn.Close()
+		return
+	}
+	defer a.untrack(conn)
+
+	reader := bufio.NewReader(conn)
+	conn.SetReadDeadline(time.Now().Add(logonTimeout))
+	raw, err := readMessage(reader)
+	if err != nil {
+		slog.Warn("fix: failed to read logon", "remote_addr", conn.RemoteAddr().String(), "error", err)
+		conn.Close()
+		return
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	msg, err := ParseMessage(raw)
+	if err != nil || msg.MsgType() != msgTypeLogon {
+		slog.Warn("fix: first message is not a valid logon", "remote_addr", conn.RemoteAddr().String())
+		conn.Close()
+		return
+	}
+
+	sender, _ := msg.Get(tagSenderCompID)
+	target, _ := msg.Get(tagTargetCompID)
+	if sender == "" || target != a.SenderCompID {
+		slog.Warn("fix: logon has unknown CompIDs", "remote_addr", conn.RemoteAddr().String(), "sender_comp_id", sender, "target_comp_id", target)
+		conn.Close()
+		return
+	}
+
+	accountID, err := a.authenticate(msg)
+	if err != nil {
+		slog.Warn("fix: logon refused", "remote_addr", conn.RemoteAddr().String(), "sender_comp_id", sender, "error", err)
+		a.refuseLogon(conn, sender, err)
+		return
+	}
+
+	a.session(SessionID{SenderCompID: a.SenderCompID, TargetCompID: sender}, accountID).run(conn, reader, msg)
+}
+
+// logonError is why a Logon is refused, as told to the counterparty.
+type logonError string
+
+func (e logonError) Error() string { return string(e) }
+
+// authenticate checks the credentials of a Logon: the SenderCompID must be
+// a configured counterparty, Username (553) the ID of its account and
+// Password (554) the API key of that account. It returns the account.
+func (a *Acceptor) authenticate(logon *Message) (uuid.UUID, error) {
+	sender, _ := logon.Get(tagSenderCompID)
+	accountID, ok := a.counterparties[sender]
+	if !ok {
+		return uuid.Nil, logonError("Unknown SenderCompID")
+	}
+
+	username, _ := logon.Get(tagUsername)
+	password, _ := logon.Get(tagPassword)
+	if username != accountID.String() || password == "" {
+		return uuid.Nil, logonError("Invalid Username or Password")
+	}
+	account, err := a.engine.GetAccountByAPIKey(password)
+	if errors.Is(err, engine.ErrAccountNotFound) || (err == nil && account.ID != accountID) {
+		return uuid.Nil, logonError("Invalid Username or Password")
+	}
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if !account.Enabled {
+		return uuid.Nil, logonError("Account is disabled")
+	}
+	return accountID, nil
+}
+
+// refuseLogon answers a Logon that failed authentication with a Logout and
+// closes the connection. The Logout is neither numbered by nor stored in
+// the session, whose sequence numbers must not be moved by a counterparty
+// that did not log on.
+func (a *Acceptor) refuseLogon(conn net.Conn, target string, err error) {
+	defer conn.Close()
+
+	text := "Internal error"
+	var refused logonError
+	if errors.As(err, &refused) {
+		text = refused.Error()
+	}
+	logout := NewMessage(msgTypeLogout)
+	logout.Set(tagSenderCompID, a.SenderCompID)
+	logout.Set(tagTargetCompID, target)
+	logout.SetInt(tagMsgSeqNum, 1)
+	logout.SetTime(tagSendingTime, time.Now())
+	logout.Set(tagText, text)
+	conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	conn.Write(logout.Bytes())
+}
+
+// reportSecurityStatus tells every connected session about a state
+// transition of an instrument.
+func (a *Acceptor) reportSecurityStatus(instrument *models.Instrument) {
+	for _, session := range a.allSessions() {
+		session.reportSecurityStatus(instrument)
+	}
+}
+
+func (a *Acceptor) allSessions() []*Session {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	sessions := make([]*Session, 0, len(a.sessions))
+	for _, session := range a.sessions {
+		sessions = append(sessions, session)
+	}
+	return sessions
+}
@@ -0,0 +1,498 @@
+package fix
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/bartick/golang-order-matching-system/db/dbtest"
+	"github.com/bartick/golang-order-matching-system/engine"
+	"github.com/bartick/golang-order-matching-system/models"
+	"github.com/google/uuid"
+)
+
+const (
+	acceptorCompID  = "OMS"
+	initiatorCompID = "CLIENT"
+	clientAPIKey    = "client-api-key"
+
+	// testSymbol is not among the symbols the migrations seed orders for
+	testSymbol = "FIXT"
+)
+
+type fixTest struct {
+	eng       *engine.Engine
+	addr      string
+	accountID uuid.UUID
+}
+
+// newFIXTest runs an acceptor for a fresh engine on a loopback port, with
+// initiatorCompID trading for the account of clientAPIKey.
+func newFIXTest(t *testing.T) *fixTest {
+	db := dbtest.Open(t)
+	accountID := dbtest.CreateAccount(t, db, "client", clientAPIKey)
+	eng := engine.NewEngine(db)
+	if err := eng.LoadOrderLimits(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := eng.LoadBooks(); err != nil {
+		t.Fatal(err)
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	acceptor := NewAcceptor(acceptorCompID, map[string]uuid.UUID{initiatorCompID: accountID}, eng, db)
+	go acceptor.Serve(lis)
+	t.Cleanup(func() {
+		acceptor.Close()
+		lis.Close()
+	})
+
+	return &fixTest{eng: eng, addr: lis.Addr().String(), accountID: accountID}
+}
+
+// initiator is the counterparty side of a session, driven by hand.
+type initiator struct {
+	t        *testing.T
+	conn     net.Conn
+	reader   *bufio.Reader
+	nextSeq  int
+	username string
+	password string
+}
+
+func (f *fixTest) connect(t *testing.T, nextSeq int) *initiator {
+	t.Helper()
+	conn, err := net.Dial("tcp", f.addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &initiator{
+		t: t, conn: conn, reader: bufio.NewReader(conn), nextSeq: nextSeq,
+		username: f.accountID.String(), password: clientAPIKey,
+	}
+}
+
+// send stamps msg with the next sequence number and writes it.
+func (i *initiator) send(msg *Message) {
+	i.t.Helper()
+	i.sendSeq(msg, i.nextSeq)
+	i.nextSeq++
+}
+
+func (i *initiator) sendSeq(msg *Message, seq int) {
+	i.t.Helper()
+	msg.Set(tagSenderCompID, initiatorCompID)
+	msg.Set(tagTargetCompID, acceptorCompID)
+	msg.SetInt(tagMsgSeqNum, seq)
+	msg.SetTime(tagSendingTime, time.Now())
+	if _, err := i.conn.Write(msg.Bytes()); err != nil {
+		i.t.Fatalf("send %s: %v", msg.MsgType(), err)
+	}
+}
+
+func (i *initiator) receive() *Message {
+	i.t.Helper()
+	i.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	raw, err := readMessage(i.reader)
+	if err != nil {
+		i.t.Fatalf("receive: %v", err)
+	}
+	msg, err := ParseMessage(raw)
+	if err != nil {
+		i.t.Fatalf("parse %q: %v", raw, err)
+	}
+	return msg
+}
+
+// expect receives the next message and checks its type and fields.
+func (i *initiator) expect(msgType string, fields map[int]string) *Message {
+	i.t.Helper()
+	msg := i.receive()
+	if msg.MsgType() != msgType {
+		i.t.Fatalf("got %s, want MsgType %s", msg, msgType)
+	}
+	for tag, want := range fields {
+		if got, _ := msg.Get(tag); got != want {
+			i.t.Fatalf("tag %d is %q, want %q in %s", tag, got, want, msg)
+		}
+	}
+	return msg
+}
+
+// logonMessage is a Logon with the credentials of the initiator.
+func (i *initiator) logonMessage() *Message {
+	logon := NewMessage(msgTypeLogon)
+	logon.SetInt(tagEncryptMethod, 0)
+	logon.SetInt(tagHeartBtInt, 30)
+	logon.Set(tagUsername, i.username)
+	logon.Set(tagPassword, i.password)
+	return logon
+}
+
+func (i *initiator) logon(reset bool) *Message {
+	i.t.Helper()
+	logon := i.logonMessage()
+	if reset {
+		logon.Set(tagResetSeqNumFlag, "Y")
+	}
+	i.send(logon)
+	return i.expect(msgTypeLogon, map[int]string{tagHeartBtInt: "30"})
+}
+
+// logout ends the session and waits for the acceptor to close the
+// connection, after which the session can log on again.
+func (i *initiator) logout() {
+	i.t.Helper()
+	i.send(NewMessage(msgTypeLogout))
+	i.expect(msgTypeLogout, nil)
+	i.expectClosed()
+}
+
+func (i *initiator) expectClosed() {
+	i.t.Helper()
+	i.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := readMessage(i.reader); !errors.Is(err, io.EOF) {
+		i.t.Fatalf("got %v, want the connection closed", err)
+	}
+}
+
+func newOrderSingle(clOrdID, side, price, quantity string) *Message {
+	msg := NewMessage(msgTypeNewOrderSingle)
+	msg.Set(tagClOrdID, clOrdID)
+	msg.Set(tagSymbol, testSymbol)
+	msg.Set(tagSide, side)
+	msg.Set(tagOrdType, "2")
+	msg.Set(tagPrice, price)
+	msg.Set(tagOrderQty, quantity)
+	msg.SetTime(tagTransactTime, time.Now())
+	return msg
+}
+
+// placeElsewhere places an order through another protocol.
+func (f *fixTest) placeElsewhere(t *testing.T, side string, price, quantity float64) *models.Order {
+	t.Helper()
+	order, _, err := f.eng.PlaceOrder(context.Background(), engine.OrderRequest{
+		Symbol: testSymbol, Side: side, Type: "limit", Price: &price, Quantity: quantity, Source: "http",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return order
+}
+
+func TestFIXLogon(t *testing.T) {
+	f := newFIXTest(t)
+	client := f.connect(t, 1)
+
+	client.logon(false)
+
+	ping := NewMessage(msgTypeTestRequest)
+	ping.Set(tagTestReqID, "ping")
+	client.send(ping)
+	client.expect(msgTypeHeartbeat, map[int]string{tagTestReqID: "ping", tagMsgSeqNum: "2"})
+	client.logout()
+}
+
+func TestFIXLogonRejectsWrongCompID(t *testing.T) {
+	f := newFIXTest(t)
+	client := f.connect(t, 1)
+
+	logon := client.logonMessage()
+	logon.Set(tagSenderCompID, initiatorCompID)
+	logon.Set(tagTargetCompID, "SOMEONE-ELSE")
+	logon.SetInt(tagMsgSeqNum, 1)
+	logon.SetTime(tagSendingTime, time.Now())
+	if _, err := client.conn.Write(logon.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	client.expectClosed()
+}
+
+func TestFIXLogonRejectsUnknownCounterparty(t *testing.T) {
+	f := newFIXTest(t)
+	client := f.connect(t, 1)
+
+	logon := client.logonMessage()
+	logon.Set(tagSenderCompID, "INTRUDER")
+	logon.Set(tagTargetCompID, acceptorCompID)
+	logon.SetInt(tagMsgSeqNum, 1)
+	logon.SetTime(tagSendingTime, time.Now())
+	if _, err := client.conn.Write(logon.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	client.expect(msgTypeLogout, map[int]string{tagTargetCompID: "INTRUDER", tagText: "Unknown SenderCompID"})
+	client.expectClosed()
+}
+
+func TestFIXLogonRejectsWrongCredentials(t *testing.T) {
+	f := newFIXTest(t)
+	other := dbtest.CreateAccount(t, f.eng.DB(), "other", "other-api-key")
+
+	for name, credentials := range map[string][2]string{
+		"wrong password":         {f.accountID.String(), "guess"},
+		"missing password":       {f.accountID.String(), ""},
+		"key of another account": {f.accountID.String(), "other-api-key"},
+		"another account":        {other.String(), "other-api-key"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			client := f.connect(t, 1)
+			client.username, client.password = credentials[0], credentials[1]
+			client.send(client.logonMessage())
+			client.expect(msgTypeLogout, map[int]string{tagText: "Invalid Username or Password"})
+			client.expectClosed()
+		})
+	}
+
+	// Refused logons leave the session alone
+	client := f.connect(t, 1)
+	client.logon(false)
+}
+
+func TestFIXLogonRejectsDisabledAccount(t *testing.T) {
+	f := newFIXTest(t)
+	if _, err := f.eng.SetAccountEnabled(f.accountID, false); err != nil {
+		t.Fatal(err)
+	}
+
+	client := f.connect(t, 1)
+	client.send(client.logonMessage())
+	client.expect(msgTypeLogout, map[int]string{tagText: "Account is disabled"})
+	client.expectClosed()
+}
+
+func TestFIXSequenceNumbersSurviveReconnect(t *testing.T) {
+	f := newFIXTest(t)
+	first := f.connect(t, 1)
+	first.logon(false)
+	first.logout()
+
+	// The acceptor expects 3 after Logon and Logout; starting over is
+	// refused unless asked for
+	tooLow := f.connect(t, 1)
+	tooLow.send(tooLow.logonMessage())
+	tooLow.expect(msgTypeLogout, map[int]string{tagText: "MsgSeqNum too low, expecting 3 but received 1"})
+	tooLow.expectClosed()
+
+	resumed := f.connect(t, 3)
+	resumed.logon(false)
+	resumed.logout()
+
+	reset := f.connect(t, 1)
+	response := reset.logon(true)
+	if flag, _ := response.Get(tagResetSeqNumFlag); flag != "Y" {
+		t.Fatalf("got %s, want ResetSeqNumFlag=Y", response)
+	}
+	if seq, _ := response.Get(tagMsgSeqNum); seq != "1" {
+		t.Fatalf("got MsgSeqNum %s, want 1", seq)
+	}
+}
+
+func TestFIXSequenceReset(t *testing.T) {
+	f := newFIXTest(t)
+	client := f.connect(t, 1)
+	client.logon(false)
+
+	// A gap makes the acceptor ask for the missing messages
+	client.sendSeq(NewMessage(msgTypeHeartbeat), 5)
+	client.expect(msgTypeResendRequest, map[int]string{tagBeginSeqNo: "2", tagEndSeqNo: "0"})
+
+	gapFill := NewMessage(msgTypeSequenceReset)
+	gapFill.Set(tagGapFillFlag, "Y")
+	gapFill.SetInt(tagNewSeqNo, 6)
+	client.sendSeq(gapFill, 2)
+
+	ping := NewMessage(msgTypeTestRequest)
+	ping.Set(tagTestReqID, "after-gap-fill")
+	client.sendSeq(ping, 6)
+	client.expect(msgTypeHeartbeat, map[int]string{tagTestReqID: "after-gap-fill"})
+
+	// A reset ignores the sequence number of the message carrying it
+	reset := NewMessage(msgTypeSequenceReset)
+	reset.SetInt(tagNewSeqNo, 20)
+	client.sendSeq(reset, 7)
+	ping.Set(tagTestReqID, "after-reset")
+	client.sendSeq(ping, 20)
+	client.expect(msgTypeHeartbeat, map[int]string{tagTestReqID: "after-reset"})
+}
+
+func TestFIXResendRequest(t *testing.T) {
+	f := newFIXTest(t)
+	client := f.connect(t, 1)
+	client.logon(false)
+
+	client.send(newOrderSingle("order-1", "1", "150", "10"))
+	ack := client.expect(msgTypeExecutionReport, map[int]string{tagMsgSeqNum: "2", tagExecType: execTypeNew})
+
+	resend := NewMessage(msgTypeResendRequest)
+	resend.SetInt(tagBeginSeqNo, 1)
+	resend.SetInt(tagEndSeqNo, 0)
+	client.send(resend)
+
+	// The Logon is replaced by a gap fill; the report is sent again
+	client.expect(msgTypeSequenceReset, map[int]string{tagMsgSeqNum: "1", tagGapFillFlag: "Y", tagNewSeqNo: "2"})
+	orderID, _ := ack.Get(tagOrderID)
+	client.expect(msgTypeExecutionReport, map[int]string{
+		tagMsgSeqNum: "2", tagPossDupFlag: "Y", tagOrderID: orderID, tagClOrdID: "order-1",
+	})
+}
+
+func TestFIXNewOrderSingle(t *testing.T) {
+	f := newFIXTest(t)
+	client := f.connect(t, 1)
+	client.logon(false)
+
+	client.send(newOrderSingle("buy-1", "1", "150", "10"))
+	ack := client.expect(msgTypeExecutionReport, map[int]string{
+		tagClOrdID: "buy-1", tagExecType: execTypeNew, tagOrdStatus: ordStatusNew,
+		tagSide: "1", tagPrice: "150", tagOrderQty: "10", tagLeavesQty: "10", tagCumQty: "0",
+	})
+
+	// The order belongs to the account of the session
+	orderID, _ := ack.Get(tagOrderID)
+	if _, err := f.eng.GetOrder(uuid.MustParse(orderID), &f.accountID); err != nil {
+		t.Fatal(err)
+	}
+
+	// An order crossing one of the session's own is acknowledged, then
+	// filled; the resting order's fill follows from the order events
+	client.send(newOrderSingle("sell-1", "2", "149", "4"))
+	client.expect(msgTypeExecutionReport, map[int]string{tagClOrdID: "sell-1", tagExecType: execTypeNew})
+	client.expect(msgTypeExecutionReport, map[int]string{
+		tagClOrdID: "sell-1", tagExecType: execTypeTrade, tagOrdStatus: ordStatusFilled,
+		tagLastPx: "150", tagLastQty: "4", tagCumQty: "4", tagLeavesQty: "0", tagAvgPx: "150",
+	})
+	client.expect(msgTypeExecutionReport, map[int]string{
+		tagClOrdID: "buy-1", tagExecType: execTypeTrade, tagOrdStatus: ordStatusPartiallyFilled,
+		tagLastPx: "150", tagLastQty: "4", tagCumQty: "4", tagLeavesQty: "6", tagAvgPx: "150",
+	})
+
+	client.send(newOrderSingle("bad-side", "9", "150", "10"))
+	client.expect(msgTypeExecutionReport, map[int]string{
+		tagClOrdID: "bad-side", tagExecType: execTypeRejected, tagOrdStatus: ordStatusRejected, tagText: "Unsupported Side",
+	})
+}
+
+func TestFIXOrderCancel(t *testing.T) {
+	f := newFIXTest(t)
+	client := f.connect(t, 1)
+	client.logon(false)
+
+	client.send(newOrderSingle("buy-1", "1", "150", "10"))
+	client.expect(msgTypeExecutionReport, map[int]string{tagExecType: execTypeNew})
+
+	cancel := NewMessage(msgTypeOrderCancelRequest)
+	cancel.Set(tagClOrdID, "cancel-1")
+	cancel.Set(tagOrigClOrdID, "buy-1")
+	cancel.Set(tagSymbol, testSymbol)
+	cancel.Set(tagSide, "1")
+	client.send(cancel)
+	client.expect(msgTypeExecutionReport, map[int]string{
+		tagClOrdID: "cancel-1", tagOrigClOrdID: "buy-1", tagExecType: execTypeCanceled,
+		tagOrdStatus: ordStatusCanceled, tagLeavesQty: "0",
+	})
+
+	cancel.Set(tagClOrdID, "cancel-2")
+	client.send(cancel)
+	client.expect(msgTypeOrderCancelReject, map[int]string{
+		tagClOrdID: "cancel-2", tagCxlRejResponseTo: cxlRejResponseToCancel, tagCxlRejReason: "0",
+	})
+
+	cancel.Set(tagClOrdID, "cancel-3")
+	cancel.Set(tagOrigClOrdID, "unknown")
+	client.send(cancel)
+	client.expect(msgTypeOrderCancelReject, map[int]string{
+		tagClOrdID: "cancel-3", tagOrderID: "NONE", tagCxlRejReason: "1",
+	})
+}
+
+func TestFIXOrderCancelReplace(t *testing.T) {
+	f := newFIXTest(t)
+	client := f.connect(t, 1)
+	client.logon(false)
+
+	client.send(newOrderSingle("buy-1", "1", "150", "10"))
+	client.expect(msgTypeExecutionReport, map[int]string{tagExecType: execTypeNew})
+
+	replace := NewMessage(msgTypeOrderCancelReplaceRequest)
+	replace.Set(tagClOrdID, "buy-2")
+	replace.Set(tagOrigClOrdID, "buy-1")
+	replace.Set(tagSymbol, testSymbol)
+	replace.Set(tagSide, "1")
+	replace.Set(tagOrdType, "2")
+	replace.Set(tagPrice, "151")
+	replace.Set(tagOrderQty, "8")
+	client.send(replace)
+	client.expect(msgTypeExecutionReport, map[int]string{
+		tagClOrdID: "buy-2", tagOrigClOrdID: "buy-1", tagExecType: execTypeReplaced,
+		tagPrice: "151", tagOrderQty: "8", tagLeavesQty: "8",
+	})
+}
+
+func TestFIXReportsFillsOfRestingOrders(t *testing.T) {
+	f := newFIXTest(t)
+	client := f.connect(t, 1)
+	client.logon(false)
+
+	client.send(newOrderSingle("buy-1", "1", "150", "10"))
+	client.expect(msgTypeExecutionReport, map[int]string{tagExecType: execTypeNew})
+
+	f.placeElsewhere(t, "sell", 150, 4)
+	client.expect(msgTypeExecutionReport, map[int]string{
+		tagClOrdID: "buy-1", tagExecType: execTypeTrade, tagOrdStatus: ordStatusPartiallyFilled,
+		tagLastQty: "4", tagCumQty: "4", tagLeavesQty: "6",
+	})
+}
+
+func TestFIXReportsMissedEventsAfterLogon(t *testing.T) {
+	f := newFIXTest(t)
+	client := f.connect(t, 1)
+	client.logon(false)
+	client.send(newOrderSingle("buy-1", "1", "150", "10"))
+	client.expect(msgTypeExecutionReport, map[int]string{tagExecType: execTypeNew})
+	client.logout()
+
+	// Each report carries the state the order was left in by its fill,
+	// not the state at the time of the report
+	f.placeElsewhere(t, "sell", 149, 4)
+	f.placeElsewhere(t, "sell", 150, 6)
+
+	back := f.connect(t, client.nextSeq)
+	back.logon(false)
+	back.expect(msgTypeExecutionReport, map[int]string{
+		tagClOrdID: "buy-1", tagExecType: execTypeTrade, tagOrdStatus: ordStatusPartiallyFilled,
+		tagLastQty: "4", tagCumQty: "4", tagLeavesQty: "6",
+	})
+	back.expect(msgTypeExecutionReport, map[int]string{
+		tagClOrdID: "buy-1", tagExecType: execTypeTrade, tagOrdStatus: ordStatusFilled,
+		tagLastQty: "6", tagCumQty: "10", tagLeavesQty: "0",
+	})
+
+	// Nothing is reported twice
+	ping := NewMessage(msgTypeTestRequest)
+	ping.Set(tagTestReqID, "done")
+	back.send(ping)
+	back.expect(msgTypeHeartbeat, map[int]string{tagTestReqID: "done"})
+}
+
+func TestFIXReportsCancelsByOthers(t *testing.T) {
+	f := newFIXTest(t)
+	client := f.connect(t, 1)
+	client.logon(false)
+	client.send(newOrderSingle("buy-1", "1", "150", "10"))
+	client.expect(msgTypeExecutionReport, map[int]string{tagExecType: execTypeNew})
+
+	if _, err := f.eng.AdminCancelOrders(engine.CancelFilter{Symbol: testSymbol, Actor: engine.AdminActor("ops")}); err != nil {
+		t.Fatal(err)
+	}
+	client.expect(msgTypeExecutionReport, map[int]string{
+		tagClOrdID: "buy-1", tagExecType: execTypeCanceled, tagOrdStatus: ordStatusCanceled, tagLeavesQty: "0",
+	})
+}
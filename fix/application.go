@@ -0,0 +1,608 @@
+package fix
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bartick/golang-order-matching-system/engine"
+	"github.com/bartick/golang-order-matching-system/models"
+	"github.com/google/uuid"
+)
+
+// ExecType, OrdStatus and related enumerations.
+const (
+	execTypeNew      = "0"
+	execTypeCanceled = "4"
+	execTypeReplaced = "5"
+	execTypeRejected = "8"
+	execTypeTrade    = "F"
+
+	ordStatusNew             = "0"
+	ordStatusPartiallyFilled = "1"
+	ordStatusFilled          = "2"
+	ordStatusCanceled        = "4"
+	ordStatusRejected        = "8"
+
+	cxlRejResponseToCancel  = "1"
+	cxlRejResponseToReplace = "2"
+
+	cxlRejReasonTooLate      = 0
+	cxlRejReasonUnknownOrder = 1
+	cxlRejReasonOther        = 99
+
+	ordRejReasonOther = 99
+
+	execInstParticipateDontInitiate = "6"
+	execInstMarketPeg               = "P"
+	execInstPrimaryPeg              = "R"
+	execInstMidPricePeg             = "M"
+
+	timeInForceAtTheOpening = "2"
+	timeInForceAtTheClose   = "7"
+)
+
+func sideFromFIX(value string) (string, bool) {
+	switch value {
+	case "1":
+		return "buy", true
+	case "2":
+		return "sell", true
+	}
+	return "", false
+}
+
+func sideToFIX(side string) string {
+	if side == "buy" {
+		return "1"
+	}
+	return "2"
+}
+
+func ordTypeFromFIX(value string) (string, bool) {
+	switch value {
+	case "1":
+		return "market", true
+	case "2":
+		return "limit", true
+	case "3":
+		return "stop", true
+	case "4":
+		return "stop_limit", true
+	case "5":
+		return "market_on_close", true
+	case "P":
+		return "peg", true
+	}
+	return "", false
+}
+
+// auctionOrdTypeFromFIX turns a market order for the opening or the closing
+// auction, given by its TimeInForce, into the matching auction order type.
+func auctionOrdTypeFromFIX(orderType, timeInForce string) string {
+	if orderType != "market" {
+		return orderType
+	}
+	switch timeInForce {
+	case timeInForceAtTheOpening:
+		return "market_on_open"
+	case timeInForceAtTheClose:
+		return "market_on_close"
+	}
+	return orderType
+}
+
+func ordTypeToFIX(orderType string) string {
+	switch orderType {
+	case "market", "market_on_open":
+		return "1"
+	case "market_on_close":
+		return "5"
+	case "stop":
+		return "3"
+	case "stop_limit":
+		return "4"
+	case "peg":
+		return "P"
+	}
+	return "2"
+}
+
+// securityTradingStatusToFIX maps an instrument state onto
+// SecurityTradingStatus: pre-open, opening rotation for an auction, ready to
+// trade, trading halt and not available for trading.
+func securityTradingStatusToFIX(state string) string {
+	switch state {
+	case engine.StatePreOpen:
+		return "21"
+	case engine.StateAuction:
+		return "22"
+	case engine.StateContinuous:
+		return "17"
+	case engine.StateHalted:
+		return "2"
+	}
+	return "18"
+}
+
+// pegReferenceFromFIX maps the peg instruction of ExecInst onto a peg
+// reference: a primary peg follows the order's own side of the book and a
+// market peg the opposite side.
+func pegReferenceFromFIX(execInst []string, side string) (string, bool) {
+	for _, inst := range execInst {
+		switch {
+		case inst == execInstMidPricePeg:
+			return "mid", true
+		case inst == execInstPrimaryPeg && side == "buy", inst == execInstMarketPeg && side == "sell":
+			return "bid", true
+		case inst == execInstPrimaryPeg && side == "sell", inst == execInstMarketPeg && side == "buy":
+			return "ask", true
+		}
+	}
+	return "", false
+}
+
+func pegReferenceToFIX(reference, side string) string {
+	switch {
+	case reference == "mid":
+		return execInstMidPricePeg
+	case reference == "bid" && side == "buy", reference == "ask" && side == "sell":
+		return execInstPrimaryPeg
+	}
+	return execInstMarketPeg
+}
+
+func ordStatusToFIX(status string) string {
+	switch status {
+	case "partially_filled":
+		return ordStatusPartiallyFilled
+	case "filled":
+		return ordStatusFilled
+	case "canceled":
+		return ordStatusCanceled
+	}
+	return ordStatusNew
+}
+
+// parseQuantity reads OrderQty, which FIX defines as a float. Whether it
+// may have decimals depends on the symbol and is checked by the engine.
+func parseQuantity(msg *Message) (float64, error) {
+	quantity, err := msg.GetFloat(tagOrderQty)
+	if err != nil {
+		return 0, err
+	}
+	if quantity <= 0 {
+		return 0, fmt.Errorf("OrderQty must be positive")
+	}
+	return quantity, nil
+}
+
+func (s *Session) onNewOrderSingle(msg *Message) {
+	clOrdID, _ := msg.Get(tagClOrdID)
+	if clOrdID == "" {
+		s.rejectOrder(msg, "ClOrdID is required")
+		return
+	}
+
+	// A possible duplicate of an order we already accepted is ignored; the
+	// counterparty already has (or will get through resend) its reports.
+	if msg.GetBool(tagPossDupFlag) {
+		if _, err := s.acceptor.engine.FindOrderByClientOrderID(s.source(), clOrdID); err == nil {
+			return
+		}
+	}
+
+	rawSide, _ := msg.Get(tagSide)
+	side, ok := sideFromFIX(rawSide)
+	if !ok {
+		s.rejectOrder(msg, "Unsupported Side")
+		return
+	}
+	rawOrdType, _ := msg.Get(tagOrdType)
+	orderType, ok := ordTypeFromFIX(rawOrdType)
+	if !ok {
+		s.rejectOrder(msg, "Unsupported OrdType")
+		return
+	}
+	timeInForce, _ := msg.Get(tagTimeInForce)
+	orderType = auctionOrdTypeFromFIX(orderType, timeInForce)
+	quantity, err := parseQuantity(msg)
+	if err != nil {
+		s.rejectOrder(msg, err.Error())
+		return
+	}
+	symbol, _ := msg.Get(tagSymbol)
+
+	req := engine.OrderRequest{
+		ClientOrderID: clOrdID,
+		Symbol:        symbol,
+		Side:          side,
+		Type:          orderType,
+		Quantity:      quantity,
+		Source:        s.source(),
+		AccountID:     &s.AccountID,
+		// The session rather than the account, so that its reports can
+		// tell its own commands apart
+		Actor: s.source(),
+	}
+	if orderType == "limit" || orderType == "stop_limit" {
+		price, err := msg.GetFloat(tagPrice)
+		if err != nil {
+			s.rejectOrder(msg, "Price is required for limit orders")
+			return
+		}
+		req.Price = &price
+	}
+	if _, ok := msg.Get(tagPrice); ok && orderType == "peg" {
+		// Price caps a pegged order
+		price, err := msg.GetFloat(tagPrice)
+		if err != nil {
+			s.rejectOrder(msg, "Price must be a number")
+			return
+		}
+		req.PegLimitPrice = &price
+	}
+	if orderType == "stop" || orderType == "stop_limit" {
+		stopPx, err := msg.GetFloat(tagStopPx)
+		if err != nil {
+			s.rejectOrder(msg, "StopPx is required for stop orders")
+			return
+		}
+		req.StopPrice = &stopPx
+	}
+	if _, ok := msg.Get(tagDisplayQty); ok {
+		displayQty, err := msg.GetFloat(tagDisplayQty)
+		if err != nil {
+			s.rejectOrder(msg, "DisplayQty must be a number")
+			return
+		}
+		// DisplayQty=0 is the FIX way of asking for a hidden order.
+		if displayQty == 0 {
+			req.Hidden = true
+		} else {
+			req.DisplayQuantity = &displayQty
+		}
+	}
+	rawExecInst, _ := msg.Get(tagExecInst)
+	execInst := strings.Fields(rawExecInst)
+	if slices.Contains(execInst, execInstParticipateDontInitiate) {
+		req.PostOnly = true
+	}
+	if orderType == "peg" {
+		if req.PegReference, ok = pegReferenceFromFIX(execInst, side); !ok {
+			s.rejectOrder(msg, "ExecInst must carry a peg instruction for pegged orders")
+			return
+		}
+		if _, ok := msg.Get(tagPegOffsetValue); ok {
+			offset, err := msg.GetFloat(tagPegOffsetValue)
+			if err != nil {
+				s.rejectOrder(msg, "PegOffsetValue must be a number")
+				return
+			}
+			req.PegOffset = &offset
+		}
+	}
+
+	order, trades, err := s.acceptor.engine.PlaceOrder(context.Background(), req)
+	var validationErr *engine.ValidationError
+	if errors.As(err, &validationErr) {
+		s.rejectOrder(msg, validationErr.Error())
+		return
+	}
+	if err != nil {
+		slog.Error("fix: failed to place order", "session", s.ID, "cl_ord_id", clOrdID, "error", err)
+		s.rejectOrder(msg, "Internal error")
+		return
+	}
+
+	s.reportPlacement(order, trades, 0, execTypeNew, "")
+}
+
+func (s *Session) onOrderCancelRequest(msg *Message) {
+	clOrdID, _ := msg.Get(tagClOrdID)
+	origClOrdID, _ := msg.Get(tagOrigClOrdID)
+
+	order, err := s.findOrder(msg)
+	if err != nil {
+		s.rejectCancel(msg, nil, cxlRejResponseToCancel, cxlRejReasonUnknownOrder, "Unknown order")
+		return
+	}
+
+	canceled, err := s.acceptor.engine.CancelOrder(order.ID, &s.AccountID, s.source())
+	if errors.Is(err, engine.ErrOrderNotCancelable) {
+		s.rejectCancel(msg, order, cxlRejResponseToCancel, cxlRejReasonTooLate, "Too late to cancel")
+		return
+	}
+	if err != nil {
+		slog.Error("fix: failed to cancel order", "session", s.ID, "order_id", order.ID, "error", err)
+		s.rejectCancel(msg, order, cxlRejResponseToCancel, cxlRejReasonOther, "Internal error")
+		return
+	}
+
+	report := s.executionReport(canceled, execTypeCanceled, clOrdID)
+	report.Set(tagOrigClOrdID, origClOrdID)
+	report.SetFloat(tagLeavesQty, 0)
+	report.SetFloat(tagAvgPx, s.averagePrice(canceled, 0))
+	s.sendReport(report)
+}
+
+func (s *Session) onOrderCancelReplaceRequest(msg *Message) {
+	clOrdID, _ := msg.Get(tagClOrdID)
+
+	order, err := s.findOrder(msg)
+	if err != nil {
+		s.rejectCancel(msg, nil, cxlRejResponseToReplace, cxlRejReasonUnknownOrder, "Unknown order")
+		return
+	}
+
+	quantity, err := parseQuantity(msg)
+	if err != nil {
+		s.rejectCancel(msg, order, cxlRejResponseToReplace, cxlRejReasonOther, err.Error())
+		return
+	}
+	amend := engine.AmendRequest{Quantity: &quantity, ClientOrderID: clOrdID, Actor: s.source(), AccountID: &s.AccountID}
+	if _, ok := msg.Get(tagPrice); ok {
+		price, err := msg.GetFloat(tagPrice)
+		if err != nil {
+			s.rejectCancel(msg, order, cxlRejResponseToReplace, cxlRejReasonOther, err.Error())
+			return
+		}
+		amend.Price = &price
+	}
+
+	amended, trades, err := s.acceptor.engine.AmendOrder(context.Background(), order.ID, amend)
+	var validationErr *engine.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		s.rejectCancel(msg, order, cxlRejResponseToReplace, cxlRejReasonOther, validationErr.Error())
+		return
+	case errors.Is(err, engine.ErrOrderNotAmendable):
+		s.rejectCancel(msg, order, cxlRejResponseToReplace, cxlRejReasonTooLate, "Too late to replace")
+		return
+	case err != nil:
+		slog.Error("fix: failed to replace order", "session", s.ID, "order_id", order.ID, "error", err)
+		s.rejectCancel(msg, order, cxlRejResponseToReplace, cxlRejReasonOther, "Internal error")
+		return
+	}
+
+	// The quantity filled before the replace, excluding the trades the
+	// replaced order produced straight away.
+	cumQty := amended.InitialQuantity - amended.RemainingQuantity
+	for _, trade := range trades {
+		cumQty -= trade.Quantity
+	}
+	cumQty = engine.RoundQuantity(cumQty)
+
+	origClOrdID, _ := msg.Get(tagOrigClOrdID)
+	s.reportPlacement(amended, trades, cumQty, execTypeReplaced, origClOrdID)
+}
+
+// findOrder resolves the order a cancel or replace request refers to,
+// preferring OrderID over OrigClOrdID. Orders of other sessions are never
+// returned.
+func (s *Session) findOrder(msg *Message) (*models.Order, error) {
+	if rawID, ok := msg.Get(tagOrderID); ok {
+		if orderID, err := uuid.Parse(rawID); err == nil {
+			order, err := s.acceptor.engine.GetOrder(orderID, &s.AccountID)
+			if err != nil {
+				return nil, err
+			}
+			if order.Source != s.source() {
+				return nil, engine.ErrOrderNotFound
+			}
+			return order, nil
+		}
+	}
+
+	origClOrdID, _ := msg.Get(tagOrigClOrdID)
+	if origClOrdID == "" {
+		return nil, engine.ErrOrderNotFound
+	}
+	return s.acceptor.engine.FindOrderByClientOrderID(s.source(), origClOrdID)
+}
+
+// reportPlacement acknowledges a new or replaced order and reports the
+// trades it produced while being matched. cumQty is the quantity the order
+// had already filled before this call. A market order that could not be
+// filled completely has its remainder reported as canceled.
+func (s *Session) reportPlacement(order *models.Order, trades []models.Trade, cumQty float64, execType, origClOrdID string) {
+	clOrdID := clientOrderID(order)
+
+	priorAvgPx := 0.0
+	if cumQty > 0 {
+		priorAvgPx = s.averagePrice(order, len(trades))
+	}
+	notional := priorAvgPx * cumQty
+
+	ack := s.executionReport(order, execType, clOrdID)
+	if origClOrdID != "" {
+		ack.Set(tagOrigClOrdID, origClOrdID)
+	}
+	ack.Set(tagOrdStatus, ordStatusNew)
+	if cumQty > 0 {
+		ack.Set(tagOrdStatus, ordStatusPartiallyFilled)
+	}
+	ack.SetFloat(tagCumQty, cumQty)
+	ack.SetFloat(tagLeavesQty, engine.RoundQuantity(order.InitialQuantity-cumQty))
+	ack.SetFloat(tagAvgPx, priorAvgPx)
+	s.sendReport(ack)
+
+	for _, trade := range trades {
+		cumQty = engine.RoundQuantity(cumQty + trade.Quantity)
+		notional += trade.Price * trade.Quantity
+
+		report := s.executionReport(order, execTypeTrade, clOrdID)
+		report.Set(tagExecID, tradeExecID(trade.ID, order.Side))
+		report.SetFloat(tagLastPx, trade.Price)
+		report.SetFloat(tagLastQty, trade.Quantity)
+		report.SetFloat(tagCumQty, cumQty)
+		report.SetFloat(tagLeavesQty, engine.RoundQuantity(order.InitialQuantity-cumQty))
+		report.SetFloat(tagAvgPx, notional/cumQty)
+		report.Set(tagOrdStatus, ordStatusPartiallyFilled)
+		if cumQty == order.InitialQuantity {
+			report.Set(tagOrdStatus, ordStatusFilled)
+		}
+		report.SetTime(tagTransactTime, trade.ExecutedAt)
+		s.sendReport(report)
+	}
+
+	if order.Type == "market" && cumQty < order.InitialQuantity {
+		report := s.executionReport(order, execTypeCanceled, clOrdID)
+		report.Set(tagOrdStatus, ordStatusCanceled)
+		report.SetFloat(tagCumQty, cumQty)
+		report.SetFloat(tagLeavesQty, 0)
+		report.SetFloat(tagAvgPx, 0)
+		if cumQty > 0 {
+			report.SetFloat(tagAvgPx, notional/cumQty)
+		}
+		report.Set(tagText, "No more liquidity for market order")
+		s.sendReport(report)
+	}
+}
+
+// reportSecurityStatus sends a SecurityStatus for an instrument state
+// transition. It is only sent to a connected counterparty: a stale status is
+// of no use after a reconnect.
+func (s *Session) reportSecurityStatus(instrument *models.Instrument) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return
+	}
+
+	status := NewMessage(msgTypeSecurityStatus)
+	status.Set(tagSymbol, instrument.Symbol)
+	status.Set(tagSecurityTradingStatus, securityTradingStatusToFIX(instrument.State))
+	status.Set(tagText, instrument.State)
+	status.SetTime(tagTransactTime, time.Now())
+	s.sendReport(status)
+}
+
+// executionReport builds an ExecutionReport describing the current state of
+// an order. AvgPx is left at zero for the caller to fill in.
+func (s *Session) executionReport(order *models.Order, execType, clOrdID string) *Message {
+	report := NewMessage(msgTypeExecutionReport)
+	report.Set(tagOrderID, order.ID.String())
+	report.Set(tagClOrdID, clOrdID)
+	report.Set(tagExecID, uuid.NewString())
+	report.Set(tagExecType, execType)
+	report.Set(tagOrdStatus, ordStatusToFIX(order.Status))
+	report.Set(tagSymbol, order.Symbol)
+	report.Set(tagSide, sideToFIX(order.Side))
+	report.Set(tagOrdType, ordTypeToFIX(order.Type))
+	if order.Type == "market_on_open" {
+		report.Set(tagTimeInForce, timeInForceAtTheOpening)
+	}
+	if order.Price != nil {
+		report.SetFloat(tagPrice, *order.Price)
+	}
+	if order.StopPrice != nil {
+		report.SetFloat(tagStopPx, *order.StopPrice)
+	}
+	report.SetFloat(tagOrderQty, order.InitialQuantity)
+	report.SetFloat(tagLeavesQty, order.RemainingQuantity)
+	report.SetFloat(tagCumQty, engine.RoundQuantity(order.InitialQuantity-order.RemainingQuantity))
+	if order.DisplayQuantity != nil {
+		report.SetFloat(tagDisplayQty, *order.DisplayQuantity)
+	}
+	if order.Hidden {
+		report.SetFloat(tagDisplayQty, 0)
+	}
+	if order.PostOnly {
+		report.Set(tagExecInst, execInstParticipateDontInitiate)
+	}
+	if order.PegReference != nil {
+		report.Set(tagExecInst, pegReferenceToFIX(*order.PegReference, order.Side))
+		if order.PegOffset != nil {
+			report.SetFloat(tagPegOffsetValue, *order.PegOffset)
+		}
+	}
+	report.SetFloat(tagAvgPx, 0)
+	report.SetTime(tagTransactTime, time.Now())
+	return report
+}
+
+// averagePrice returns the average fill price of an order, leaving out its
+// skipLast most recent trades.
+func (s *Session) averagePrice(order *models.Order, skipLast int) float64 {
+	if order.Type == "limit" && order.RemainingQuantity == order.InitialQuantity {
+		return 0
+	}
+	trades, err := s.acceptor.engine.GetOrderTrades(order.ID)
+	if err != nil {
+		slog.Error("fix: failed to load trades of order", "session", s.ID, "order_id", order.ID, "error", err)
+		return 0
+	}
+	if skipLast >= len(trades) {
+		return 0
+	}
+	trades = trades[:len(trades)-skipLast]
+
+	quantity := 0.0
+	notional := 0.0
+	for _, trade := range trades {
+		quantity = engine.RoundQuantity(quantity + trade.Quantity)
+		notional += trade.Price * trade.Quantity
+	}
+	if quantity == 0 {
+		return 0
+	}
+	return notional / quantity
+}
+
+// tradeExecID derives a stable ExecID from the trade, so that both sides of
+// a trade between two orders of the same session get distinct IDs.
+func tradeExecID(tradeID uuid.UUID, side string) string {
+	return tradeID.String() + "-" + sideToFIX(side)
+}
+
+func (s *Session) rejectOrder(msg *Message, text string) {
+	report := NewMessage(msgTypeExecutionReport)
+	report.Set(tagOrderID, "NONE")
+	clOrdID, _ := msg.Get(tagClOrdID)
+	report.Set(tagClOrdID, clOrdID)
+	report.Set(tagExecID, uuid.NewString())
+	report.Set(tagExecType, execTypeRejected)
+	report.Set(tagOrdStatus, ordStatusRejected)
+	report.SetInt(tagOrdRejReason, ordRejReasonOther)
+	for _, tag := range []int{tagSymbol, tagSide, tagOrdType, tagPrice, tagOrderQty} {
+		if value, ok := msg.Get(tag); ok {
+			report.Set(tag, value)
+		}
+	}
+	report.SetFloat(tagLeavesQty, 0)
+	report.SetFloat(tagCumQty, 0)
+	report.SetFloat(tagAvgPx, 0)
+	report.Set(tagText, text)
+	report.SetTime(tagTransactTime, time.Now())
+	s.sendReport(report)
+}
+
+func (s *Session) rejectCancel(msg *Message, order *models.Order, responseTo string, reason int, text string) {
+	reject := NewMessage(msgTypeOrderCancelReject)
+	reject.Set(tagOrderID, "NONE")
+	reject.Set(tagOrdStatus, ordStatusRejected)
+	if order != nil {
+		reject.Set(tagOrderID, order.ID.String())
+		reject.Set(tagOrdStatus, ordStatusToFIX(order.Status))
+	}
+	clOrdID, _ := msg.Get(tagClOrdID)
+	origClOrdID, _ := msg.Get(tagOrigClOrdID)
+	reject.Set(tagClOrdID, clOrdID)
+	reject.Set(tagOrigClOrdID, origClOrdID)
+	reject.Set(tagCxlRejResponseTo, responseTo)
+	reject.SetInt(tagCxlRejReason, reason)
+	reject.Set(tagText, text)
+	s.sendReport(reject)
+}
+
+// sendReport must be called with s.mu held.
+func (s *Session) sendReport(report *Message) {
+	if err := s.send(report); err != nil {
+		slog.Error("fix: failed to send message", "session", s.ID, "msg_type", report.MsgType(), "error", err)
+	}
+}
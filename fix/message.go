@@ -0,0 +1,346 @@
+package fix
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	beginString = "FIX.4.4"
+	soh         = '\x01'
+
+	// sendingTimeFormat is the UTCTimestamp format with milliseconds.
+	sendingTimeFormat = "20060102-15:04:05.000"
+)
+
+// Tags used by the gateway.
+const (
+	tagAvgPx                 = 6
+	tagBeginSeqNo            = 7
+	tagBeginString           = 8
+	tagBodyLength            = 9
+	tagCheckSum              = 10
+	tagClOrdID               = 11
+	tagCumQty                = 14
+	tagEndSeqNo              = 16
+	tagExecID                = 17
+	tagExecInst              = 18
+	tagLastPx                = 31
+	tagLastQty               = 32
+	tagMsgSeqNum             = 34
+	tagMsgType               = 35
+	tagNewSeqNo              = 36
+	tagOrderID               = 37
+	tagOrderQty              = 38
+	tagOrdStatus             = 39
+	tagOrdType               = 40
+	tagOrigClOrdID           = 41
+	tagPossDupFlag           = 43
+	tagPrice                 = 44
+	tagRefSeqNum             = 45
+	tagSenderCompID          = 49
+	tagSendingTime           = 52
+	tagSide                  = 54
+	tagSymbol                = 55
+	tagTargetCompID          = 56
+	tagText                  = 58
+	tagTimeInForce           = 59
+	tagTransactTime          = 60
+	tagEncryptMethod         = 98
+	tagStopPx                = 99
+	tagCxlRejReason          = 102
+	tagOrdRejReason          = 103
+	tagHeartBtInt            = 108
+	tagPegOffsetValue        = 211
+	tagTestReqID             = 112
+	tagOrigSendingTime       = 122
+	tagGapFillFlag           = 123
+	tagResetSeqNumFlag       = 141
+	tagExecType              = 150
+	tagLeavesQty             = 151
+	tagSecurityTradingStatus = 326
+	tagRefMsgType            = 372
+	tagSessionRejectReason   = 373
+	tagCxlRejResponseTo      = 434
+	tagUsername              = 553
+	tagPassword              = 554
+	tagDisplayQty            = 1138
+
+	// tagCancelOnDisconnect is a user-defined Logon tag. When set to Y, the
+	// orders of the session are canceled if it stays disconnected for
+	// longer than its heartbeat interval.
+	tagCancelOnDisconnect = 8013
+)
+
+// Message types used by the gateway.
+const (
+	msgTypeHeartbeat                 = "0"
+	msgTypeTestRequest               = "1"
+	msgTypeResendRequest             = "2"
+	msgTypeReject                    = "3"
+	msgTypeSequenceReset             = "4"
+	msgTypeLogout                    = "5"
+	msgTypeExecutionReport           = "8"
+	msgTypeOrderCancelReject         = "9"
+	msgTypeLogon                     = "A"
+	msgTypeNewOrderSingle            = "D"
+	msgTypeOrderCancelRequest        = "F"
+	msgTypeOrderCancelReplaceRequest = "G"
+	msgTypeSecurityStatus            = "f"
+)
+
+// isAdminMsgType reports whether a message type belongs to the session
+// layer. Admin messages are never resent; a gap fill is sent instead.
+func isAdminMsgType(msgType string) bool {
+	switch msgType {
+	case msgTypeHeartbeat, msgTypeTestRequest, msgTypeResendRequest, msgTypeReject,
+		msgTypeSequenceReset, msgTypeLogout, msgTypeLogon:
+		return true
+	}
+	return false
+}
+
+type Field struct {
+	Tag   int
+	Value string
+}
+
+// Message is a FIX message without its BeginString, BodyLength and
+// CheckSum fields, which are computed when the message is encoded. Fields
+// are kept in the order they were added.
+type Message struct {
+	Fields []Field
+}
+
+func NewMessage(msgType string) *Message {
+	m := &Message{}
+	m.Set(tagMsgType, msgType)
+	return m
+}
+
+func (m *Message) MsgType() string {
+	value, _ := m.Get(tagMsgType)
+	return value
+}
+
+func (m *Message) Get(tag int) (string, bool) {
+	for _, field := range m.Fields {
+		if field.Tag == tag {
+			return field.Value, true
+		}
+	}
+	return "", false
+}
+
+func (m *Message) GetInt(tag int) (int, error) {
+	value, ok := m.Get(tag)
+	if !ok {
+		return 0, fmt.Errorf("missing tag %d", tag)
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("tag %d is not an integer: %q", tag, value)
+	}
+	return n, nil
+}
+
+func (m *Message) GetFloat(tag int) (float64, error) {
+	value, ok := m.Get(tag)
+	if !ok {
+		return 0, fmt.Errorf("missing tag %d", tag)
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("tag %d is not a number: %q", tag, value)
+	}
+	return f, nil
+}
+
+func (m *Message) GetBool(tag int) bool {
+	value, _ := m.Get(tag)
+	return value == "Y"
+}
+
+// Set replaces the value of tag, or appends the field if it is not present.
+func (m *Message) Set(tag int, value string) {
+	for i, field := range m.Fields {
+		if field.Tag == tag {
+			m.Fields[i].Value = value
+			return
+		}
+	}
+	m.Fields = append(m.Fields, Field{Tag: tag, Value: value})
+}
+
+func (m *Message) SetInt(tag int, value int) {
+	m.Set(tag, strconv.Itoa(value))
+}
+
+func (m *Message) SetFloat(tag int, value float64) {
+	m.Set(tag, strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+func (m *Message) SetTime(tag int, value time.Time) {
+	m.Set(tag, value.UTC().Format(sendingTimeFormat))
+}
+
+// withHeader returns a copy of m whose standard header fields come first,
+// in the order required by the specification.
+func (m *Message) withHeader() *Message {
+	headerTags := []int{tagMsgType, tagSenderCompID, tagTargetCompID, tagMsgSeqNum,
+		tagPossDupFlag, tagSendingTime, tagOrigSendingTime}
+
+	out := &Message{Fields: make([]Field, 0, len(m.Fields))}
+	for _, tag := range headerTags {
+		if value, ok := m.Get(tag); ok {
+			out.Fields = append(out.Fields, Field{Tag: tag, Value: value})
+		}
+	}
+	for _, field := range m.Fields {
+		if !isHeaderTag(field.Tag, headerTags) {
+			out.Fields = append(out.Fields, field)
+		}
+	}
+	return out
+}
+
+func isHeaderTag(tag int, headerTags []int) bool {
+	for _, headerTag := range headerTags {
+		if tag == headerTag {
+			return true
+		}
+	}
+	return false
+}
+
+// Bytes encodes the message, adding BeginString, BodyLength and CheckSum.
+func (m *Message) Bytes() []byte {
+	var body bytes.Buffer
+	for _, field := range m.withHeader().Fields {
+		body.WriteString(strconv.Itoa(field.Tag))
+		body.WriteByte('=')
+		body.WriteString(field.Value)
+		body.WriteByte(soh)
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "8=%s%c9=%d%c", beginString, soh, body.Len(), soh)
+	out.Write(body.Bytes())
+	fmt.Fprintf(&out, "10=%03d%c", checksum(out.Bytes()), soh)
+	return out.Bytes()
+}
+
+func (m *Message) String() string {
+	return strings.ReplaceAll(string(m.Bytes()), string(soh), "|")
+}
+
+func checksum(data []byte) int {
+	sum := 0
+	for _, b := range data {
+		sum += int(b)
+	}
+	return sum % 256
+}
+
+// ParseMessage decodes a single raw message, verifying its BeginString,
+// BodyLength and CheckSum.
+func ParseMessage(raw []byte) (*Message, error) {
+	if len(raw) == 0 || raw[len(raw)-1] != soh {
+		return nil, fmt.Errorf("message is not terminated by SOH")
+	}
+
+	parts := strings.Split(string(raw[:len(raw)-1]), string(soh))
+	if len(parts) < 4 {
+		return nil, fmt.Errorf("message is too short")
+	}
+
+	fields := make([]Field, 0, len(parts))
+	for _, part := range parts {
+		tagStr, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("malformed field %q", part)
+		}
+		tag, err := strconv.Atoi(tagStr)
+		if err != nil {
+			return nil, fmt.Errorf("malformed tag %q", tagStr)
+		}
+		fields = append(fields, Field{Tag: tag, Value: value})
+	}
+
+	if fields[0].Tag != tagBeginString || fields[0].Value != beginString {
+		return nil, fmt.Errorf("unsupported BeginString %q", fields[0].Value)
+	}
+	if fields[1].Tag != tagBodyLength {
+		return nil, fmt.Errorf("BodyLength must be the second field")
+	}
+	last := fields[len(fields)-1]
+	if last.Tag != tagCheckSum {
+		return nil, fmt.Errorf("CheckSum must be the last field")
+	}
+
+	bodyLength, err := strconv.Atoi(fields[1].Value)
+	if err != nil {
+		return nil, fmt.Errorf("malformed BodyLength %q", fields[1].Value)
+	}
+	headerLength := len("8=") + len(fields[0].Value) + 1 + len("9=") + len(fields[1].Value) + 1
+	trailerLength := len("10=") + len(last.Value) + 1
+	if headerLength+bodyLength+trailerLength != len(raw) {
+		return nil, fmt.Errorf("BodyLength %d does not match the message", bodyLength)
+	}
+
+	expected, err := strconv.Atoi(last.Value)
+	if err != nil || expected != checksum(raw[:len(raw)-trailerLength]) {
+		return nil, fmt.Errorf("invalid CheckSum %q", last.Value)
+	}
+
+	return &Message{Fields: fields[2 : len(fields)-1]}, nil
+}
+
+// readMessage reads the next raw message from r. It relies on BodyLength to
+// find the end of the message rather than scanning for the CheckSum field.
+func readMessage(r *bufio.Reader) ([]byte, error) {
+	begin, err := r.ReadBytes(soh)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(begin, []byte("8=")) {
+		return nil, fmt.Errorf("expected BeginString, got %q", begin)
+	}
+
+	length, err := r.ReadBytes(soh)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(length, []byte("9=")) {
+		return nil, fmt.Errorf("expected BodyLength, got %q", length)
+	}
+	bodyLength, err := strconv.Atoi(string(length[2 : len(length)-1]))
+	if err != nil || bodyLength <= 0 || bodyLength > maxBodyLength {
+		return nil, fmt.Errorf("invalid BodyLength %q", length)
+	}
+
+	body := make([]byte, bodyLength)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+
+	trailer, err := r.ReadBytes(soh)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := make([]byte, 0, len(begin)+len(length)+len(body)+len(trailer))
+	raw = append(raw, begin...)
+	raw = append(raw, length...)
+	raw = append(raw, body...)
+	raw = append(raw, trailer...)
+	return raw, nil
+}
+
+// maxBodyLength protects the acceptor from clients announcing huge bodies.
+const maxBodyLength = 64 * 1024
@@ -0,0 +1,276 @@
+package fix
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/bartick/golang-order-matching-system/engine"
+	"github.com/bartick/golang-order-matching-system/models"
+	"github.com/google/uuid"
+)
+
+// reportInterval is how often the sessions are caught up with the order
+// events when market data shows no activity, which also covers the events
+// whose notification was dropped.
+const reportInterval = time.Second
+
+// watchEvents reports the fills and cancels of FIX orders that their
+// sessions did not ask for, such as fills of resting orders caused by other
+// clients and cancels issued by the engine or an administrator. They are
+// read from the order events, so none are lost: market data only tells when
+// to look. Instrument state transitions are forwarded as they come.
+func (a *Acceptor) watchEvents() {
+	wake := make(chan struct{}, 1)
+	go a.reportEvents(wake)
+
+	for {
+		sub := a.engine.MarketData().SubscribeAll()
+		a.consumeEvents(sub, wake)
+		a.engine.MarketData().Unsubscribe(sub)
+
+		select {
+		case <-a.done:
+			return
+		default:
+			slog.Warn("fix: market data subscription dropped, resubscribing")
+		}
+	}
+}
+
+func (a *Acceptor) consumeEvents(sub *engine.Subscription, wake chan<- struct{}) {
+	for {
+		select {
+		case <-a.done:
+			return
+		case event, ok := <-sub.Events:
+			if !ok {
+				return
+			}
+			if event.Instrument != nil {
+				a.reportSecurityStatus(event.Instrument)
+				continue
+			}
+			if event.Trade != nil || event.CanceledOrder != nil {
+				select {
+				case wake <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}
+}
+
+// reportEvents catches the connected sessions up whenever it is woken, and
+// every reportInterval.
+func (a *Acceptor) reportEvents(wake <-chan struct{}) {
+	ticker := time.NewTicker(reportInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.done:
+			return
+		case <-wake:
+		case <-ticker.C:
+		}
+		for _, session := range a.allSessions() {
+			session.catchUp()
+		}
+	}
+}
+
+// catchUp reports the order events recorded since the last report, if the
+// counterparty is connected. Those recorded while it is not are reported
+// after its next Logon.
+func (s *Session) catchUp() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return
+	}
+	if err := s.reportEvents(); err != nil {
+		slog.Error("fix: failed to report order events", "session", s.ID, "error", err)
+	}
+}
+
+// reportEvents sends the execution reports of the order events of the
+// session recorded since the last report, symbol by symbol, and moves the
+// report cursor of each symbol past them. A report may be sent again if the
+// cursor cannot be stored; fills keep their ExecID. It must be called with
+// s.mu held.
+func (s *Session) reportEvents() error {
+	eng, store := s.acceptor.engine, s.acceptor.store
+	sequences, err := eng.SymbolSequences()
+	if err != nil {
+		return err
+	}
+	cursors, err := store.ReportCursors(s.ID)
+	if err != nil {
+		return err
+	}
+
+	symbols := make([]string, 0, len(sequences))
+	for symbol := range sequences {
+		symbols = append(symbols, symbol)
+	}
+	slices.Sort(symbols)
+
+	for _, symbol := range symbols {
+		last, reported := sequences[symbol], cursors[symbol]
+		if last <= reported {
+			continue
+		}
+		events, err := eng.SourceOrderEvents(s.source(), symbol, reported, last)
+		if err != nil {
+			return err
+		}
+		s.reportOrderEvents(events)
+		if err := store.SetReportCursor(s.ID, symbol, last); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// orderCommand identifies a command on an order by the sequence number it
+// was given.
+type orderCommand struct {
+	orderID  uuid.UUID
+	sequence int64
+}
+
+// reportOrderEvents reports the fills, cancels and expiries among events
+// that the session was not already told about in reply to its own requests:
+// the fills of the orders it placed or replaced in the same command, and the
+// cancels and expiries it caused.
+func (s *Session) reportOrderEvents(events []models.OrderEvent) {
+	answered := make(map[orderCommand]bool)
+	for _, event := range events {
+		if (event.EventType == engine.EventAccepted || event.EventType == engine.EventAmended) && event.Actor == s.source() {
+			answered[orderCommand{*event.OrderID, *event.Sequence}] = true
+		}
+	}
+
+	for i := range events {
+		event := &events[i]
+		ownCommand := event.Actor == s.source()
+		switch event.EventType {
+		case engine.EventFill:
+			if ownCommand && answered[orderCommand{*event.OrderID, *event.Sequence}] {
+				continue
+			}
+			s.reportFill(event)
+		case engine.EventCanceled, engine.EventExpired:
+			if ownCommand {
+				continue
+			}
+			s.reportCancel(event)
+		}
+	}
+}
+
+// eventState is what an order event records of its order after it.
+type eventState struct {
+	Status            string   `json:"status"`
+	Price             *float64 `json:"price"`
+	InitialQuantity   float64  `json:"initial_quantity"`
+	RemainingQuantity float64  `json:"remaining_quantity"`
+	StopPrice         *float64 `json:"stop_price"`
+	ClientOrderID     *string  `json:"client_order_id"`
+}
+
+// orderAfter returns the order of an event as the event left it.
+func (s *Session) orderAfter(event *models.OrderEvent) (*models.Order, error) {
+	order, err := s.acceptor.engine.GetOrder(*event.OrderID, nil)
+	if err != nil {
+		return nil, err
+	}
+	var state eventState
+	if err := json.Unmarshal(event.After, &state); err != nil {
+		return nil, fmt.Errorf("event %d has invalid state: %w", event.ID, err)
+	}
+	order.Status = state.Status
+	order.Price = state.Price
+	order.InitialQuantity = state.InitialQuantity
+	order.RemainingQuantity = state.RemainingQuantity
+	order.StopPrice = state.StopPrice
+	order.ClientOrderID = state.ClientOrderID
+	return order, nil
+}
+
+// reportFill reports a fill with the state the order was left in by it.
+func (s *Session) reportFill(event *models.OrderEvent) {
+	order, err := s.orderAfter(event)
+	if err != nil {
+		slog.Error("fix: failed to load filled order", "session", s.ID, "event_id", event.ID, "error", err)
+		return
+	}
+	var fill struct {
+		TradeID  uuid.UUID `json:"trade_id"`
+		Price    float64   `json:"price"`
+		Quantity float64   `json:"quantity"`
+	}
+	if err := json.Unmarshal(event.Details, &fill); err != nil {
+		slog.Error("fix: fill has invalid details", "session", s.ID, "event_id", event.ID, "error", err)
+		return
+	}
+
+	report := s.executionReport(order, execTypeTrade, clientOrderID(order))
+	report.Set(tagExecID, tradeExecID(fill.TradeID, order.Side))
+	report.SetFloat(tagLastPx, fill.Price)
+	report.SetFloat(tagLastQty, fill.Quantity)
+	report.SetFloat(tagAvgPx, s.averagePriceThrough(order, fill.TradeID))
+	report.SetTime(tagTransactTime, event.CreatedAt)
+	s.sendReport(report)
+}
+
+// reportCancel sends an unsolicited cancel for an order the session did not
+// ask to cancel, e.g. by its cancel-on-disconnect switch.
+func (s *Session) reportCancel(event *models.OrderEvent) {
+	order, err := s.orderAfter(event)
+	if err != nil {
+		slog.Error("fix: failed to load canceled order", "session", s.ID, "event_id", event.ID, "error", err)
+		return
+	}
+
+	report := s.executionReport(order, execTypeCanceled, clientOrderID(order))
+	report.Set(tagOrdStatus, ordStatusCanceled)
+	report.SetFloat(tagLeavesQty, 0)
+	report.SetFloat(tagAvgPx, s.averagePrice(order, 0))
+	report.SetTime(tagTransactTime, event.CreatedAt)
+	s.sendReport(report)
+}
+
+// averagePriceThrough returns the average fill price of an order over its
+// trades up to and including the given one.
+func (s *Session) averagePriceThrough(order *models.Order, tradeID uuid.UUID) float64 {
+	trades, err := s.acceptor.engine.GetOrderTrades(order.ID)
+	if err != nil {
+		slog.Error("fix: failed to load trades of order", "session", s.ID, "order_id", order.ID, "error", err)
+		return 0
+	}
+
+	quantity := 0.0
+	notional := 0.0
+	for _, trade := range trades {
+		quantity = engine.RoundQuantity(quantity + trade.Quantity)
+		notional += trade.Price * trade.Quantity
+		if trade.ID == tradeID {
+			break
+		}
+	}
+	if quantity == 0 {
+		return 0
+	}
+	return notional / quantity
+}
+
+func clientOrderID(order *models.Order) string {
+	if order.ClientOrderID == nil {
+		return ""
+	}
+	return *order.ClientOrderID
+}
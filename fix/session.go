@@ -0,0 +1,467 @@
+package fix
+
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bartick/golang-order-matching-system/engine"
+	"github.com/google/uuid"
+)
+
+const (
+	// logonTimeout is how long a new connection has to send its Logon.
+	logonTimeout = 10 * time.Second
+	writeTimeout = 5 * time.Second
+)
+
+// Session holds the state of one counterparty. It outlives its TCP
+// connections: messages sent while the counterparty is disconnected are
+// stored and delivered through the resend mechanism after the next Logon.
+type Session struct {
+	ID SessionID
+
+	// AccountID is the account the counterparty trades for.
+	AccountID uuid.UUID
+
+	acceptor *Acceptor
+
+	// mu serialises everything that touches the session: incoming message
+	// processing, outgoing messages and the heartbeat timer.
+	mu              sync.Mutex
+	conn            net.Conn
+	heartBtInt      time.Duration
+	lastSent        time.Time
+	lastReceived    time.Time
+	testRequestSent bool
+	nextTargetSeq   int
+	resendRequested bool
+
+	cancelOnDisconnect bool
+}
+
+func (s *Session) source() string {
+	return sourcePrefix + s.ID.TargetCompID
+}
+
+// run drives a connection after its Logon message has been read.
+func (s *Session) run(conn net.Conn, reader *bufio.Reader, logon *Message) {
+	defer conn.Close()
+
+	if err := s.logon(conn, logon); err != nil {
+		slog.Warn("fix: logon rejected", "session", s.ID, "error", err)
+		return
+	}
+	defer s.detach(conn)
+	slog.Info("fix: logged on", "session", s.ID)
+
+	done := make(chan struct{})
+	defer close(done)
+	go s.heartbeat(conn, done)
+
+	for {
+		raw, err := readMessage(reader)
+		if err != nil {
+			slog.Info("fix: connection closed", "session", s.ID, "error", err)
+			return
+		}
+		msg, err := ParseMessage(raw)
+		if err != nil {
+			// Garbled messages are ignored; the sequence gap they leave
+			// is recovered through a resend request.
+			slog.Warn("fix: ignoring garbled message", "session", s.ID, "error", err)
+			continue
+		}
+		if !s.handle(conn, msg) {
+			return
+		}
+	}
+}
+
+func (s *Session) logon(conn net.Conn, msg *Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn != nil {
+		return fmt.Errorf("session is already logged on")
+	}
+
+	heartBtInt, err := msg.GetInt(tagHeartBtInt)
+	if err != nil || heartBtInt <= 0 {
+		return fmt.Errorf("invalid HeartBtInt")
+	}
+	seq, err := msg.GetInt(tagMsgSeqNum)
+	if err != nil {
+		return err
+	}
+
+	store := s.acceptor.store
+	reset := msg.GetBool(tagResetSeqNumFlag)
+	if reset {
+		if err := store.Reset(s.ID); err != nil {
+			return err
+		}
+	}
+	_, nextTargetSeq, err := store.Load(s.ID)
+	if err != nil {
+		return err
+	}
+
+	s.conn = conn
+	s.heartBtInt = time.Duration(heartBtInt) * time.Second
+	s.lastReceived = time.Now()
+	s.testRequestSent = false
+	s.nextTargetSeq = nextTargetSeq
+	s.resendRequested = false
+	s.cancelOnDisconnect = msg.GetBool(tagCancelOnDisconnect)
+
+	if seq < s.nextTargetSeq {
+		s.logout(fmt.Sprintf("MsgSeqNum too low, expecting %d but received %d", s.nextTargetSeq, seq))
+		s.conn = nil
+		return fmt.Errorf("MsgSeqNum %d lower than expected %d", seq, s.nextTargetSeq)
+	}
+
+	response := NewMessage(msgTypeLogon)
+	response.SetInt(tagEncryptMethod, 0)
+	response.SetInt(tagHeartBtInt, heartBtInt)
+	if reset {
+		response.Set(tagResetSeqNumFlag, "Y")
+	}
+	if s.cancelOnDisconnect {
+		response.Set(tagCancelOnDisconnect, "Y")
+	}
+	if err := s.send(response); err != nil {
+		s.conn = nil
+		return err
+	}
+
+	// Reconnecting in time keeps the orders of the previous connection.
+	if _, err := s.acceptor.engine.CancelAllAfter(engine.CancelFilter{Source: s.source()}, 0); err != nil {
+		slog.Error("fix: failed to disarm cancel-on-disconnect", "session", s.ID, "error", err)
+	}
+
+	if seq > s.nextTargetSeq {
+		err = s.requestResend()
+	} else {
+		err = s.advanceTargetSeq()
+	}
+	if err != nil {
+		return err
+	}
+
+	// Report what happened to the orders of the session while it was away
+	if err := s.reportEvents(); err != nil {
+		slog.Error("fix: failed to report order events", "session", s.ID, "error", err)
+	}
+	return nil
+}
+
+func (s *Session) detach(conn net.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn != conn {
+		return
+	}
+	s.conn = nil
+	slog.Info("fix: logged out", "session", s.ID)
+
+	if s.cancelOnDisconnect {
+		grace := min(s.heartBtInt, engine.MaxCancelAllAfter)
+		if _, err := s.acceptor.engine.CancelAllAfter(engine.CancelFilter{Source: s.source()}, grace); err != nil {
+			slog.Error("fix: failed to arm cancel-on-disconnect", "session", s.ID, "error", err)
+		}
+	}
+}
+
+// heartbeat sends heartbeats when the session is idle, probes a silent
+// counterparty with a test request and drops the connection if it still
+// does not answer.
+func (s *Session) heartbeat(conn net.Conn, done <-chan struct{}) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-ticker.C:
+			s.mu.Lock()
+			if s.conn != conn {
+				s.mu.Unlock()
+				return
+			}
+
+			silence := now.Sub(s.lastReceived)
+			switch {
+			case silence >= 2*s.heartBtInt+s.heartBtInt/2:
+				slog.Warn("fix: no response to test request, disconnecting", "session", s.ID)
+				conn.Close()
+			case silence >= s.heartBtInt+s.heartBtInt/5 && !s.testRequestSent:
+				testRequest := NewMessage(msgTypeTestRequest)
+				testRequest.Set(tagTestReqID, strconv.FormatInt(now.Unix(), 10))
+				if err := s.send(testRequest); err != nil {
+					slog.Error("fix: failed to send test request", "session", s.ID, "error", err)
+				}
+				s.testRequestSent = true
+			case now.Sub(s.lastSent) >= s.heartBtInt:
+				if err := s.send(NewMessage(msgTypeHeartbeat)); err != nil {
+					slog.Error("fix: failed to send heartbeat", "session", s.ID, "error", err)
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// handle processes one incoming message and reports whether the connection
+// should stay open.
+func (s *Session) handle(conn net.Conn, msg *Message) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn != conn {
+		return false
+	}
+	s.lastReceived = time.Now()
+	s.testRequestSent = false
+
+	sender, _ := msg.Get(tagSenderCompID)
+	target, _ := msg.Get(tagTargetCompID)
+	if sender != s.ID.TargetCompID || target != s.ID.SenderCompID {
+		s.logout("CompID problem")
+		return false
+	}
+
+	seq, err := msg.GetInt(tagMsgSeqNum)
+	if err != nil {
+		s.logout("MsgSeqNum missing")
+		return false
+	}
+	msgType := msg.MsgType()
+
+	// A sequence reset in reset mode ignores the sequence number of the
+	// message carrying it.
+	if msgType == msgTypeSequenceReset && !msg.GetBool(tagGapFillFlag) {
+		s.sequenceReset(msg)
+		return true
+	}
+
+	switch {
+	case seq > s.nextTargetSeq:
+		// Resend requests are answered even while we wait for a gap to
+		// be filled, otherwise both sides could wait on each other.
+		if msgType == msgTypeResendRequest {
+			s.resend(msg)
+		}
+		if msgType == msgTypeLogout {
+			s.logout("")
+			return false
+		}
+		if !s.resendRequested {
+			if err := s.requestResend(); err != nil {
+				slog.Error("fix: failed to request resend", "session", s.ID, "error", err)
+				return false
+			}
+		}
+		return true
+	case seq < s.nextTargetSeq:
+		if msg.GetBool(tagPossDupFlag) {
+			return true
+		}
+		s.logout(fmt.Sprintf("MsgSeqNum too low, expecting %d but received %d", s.nextTargetSeq, seq))
+		return false
+	}
+
+	keepOpen := true
+	switch msgType {
+	case msgTypeHeartbeat, msgTypeReject, msgTypeLogon:
+	case msgTypeTestRequest:
+		heartbeat := NewMessage(msgTypeHeartbeat)
+		if testReqID, ok := msg.Get(tagTestReqID); ok {
+			heartbeat.Set(tagTestReqID, testReqID)
+		}
+		if err := s.send(heartbeat); err != nil {
+			slog.Error("fix: failed to answer test request", "session", s.ID, "error", err)
+		}
+	case msgTypeResendRequest:
+		s.resend(msg)
+	case msgTypeSequenceReset:
+		s.sequenceReset(msg)
+		return true
+	case msgTypeLogout:
+		s.logout("")
+		keepOpen = false
+	case msgTypeNewOrderSingle:
+		s.onNewOrderSingle(msg)
+	case msgTypeOrderCancelRequest:
+		s.onOrderCancelRequest(msg)
+	case msgTypeOrderCancelReplaceRequest:
+		s.onOrderCancelReplaceRequest(msg)
+	default:
+		s.reject(seq, msgType, 11, "Unsupported MsgType")
+	}
+
+	if err := s.advanceTargetSeq(); err != nil {
+		slog.Error("fix: failed to persist sequence number", "session", s.ID, "error", err)
+		return false
+	}
+	return keepOpen
+}
+
+func (s *Session) advanceTargetSeq() error {
+	s.nextTargetSeq++
+	s.resendRequested = false
+	return s.acceptor.store.SetNextTargetSeq(s.ID, s.nextTargetSeq)
+}
+
+func (s *Session) sequenceReset(msg *Message) {
+	newSeq, err := msg.GetInt(tagNewSeqNo)
+	if err != nil || newSeq < s.nextTargetSeq {
+		slog.Warn("fix: ignoring invalid sequence reset", "session", s.ID)
+		return
+	}
+	s.nextTargetSeq = newSeq
+	s.resendRequested = false
+	if err := s.acceptor.store.SetNextTargetSeq(s.ID, s.nextTargetSeq); err != nil {
+		slog.Error("fix: failed to persist sequence number", "session", s.ID, "error", err)
+	}
+}
+
+func (s *Session) requestResend() error {
+	request := NewMessage(msgTypeResendRequest)
+	request.SetInt(tagBeginSeqNo, s.nextTargetSeq)
+	request.SetInt(tagEndSeqNo, 0)
+	s.resendRequested = true
+	return s.send(request)
+}
+
+// resend answers a resend request. Application messages are sent again
+// with PossDupFlag set; runs of admin messages are replaced by gap fills.
+func (s *Session) resend(msg *Message) {
+	begin, err := msg.GetInt(tagBeginSeqNo)
+	if err != nil {
+		return
+	}
+	end, err := msg.GetInt(tagEndSeqNo)
+	if err != nil {
+		return
+	}
+
+	store := s.acceptor.store
+	nextSenderSeq, err := store.NextSenderSeq(s.ID)
+	if err != nil {
+		slog.Error("fix: failed to load sequence number", "session", s.ID, "error", err)
+		return
+	}
+	if end == 0 || end >= nextSenderSeq {
+		end = nextSenderSeq - 1
+	}
+	if begin < 1 || begin > end {
+		return
+	}
+
+	messages, err := store.Messages(s.ID, begin, end)
+	if err != nil {
+		slog.Error("fix: failed to load messages for resend", "session", s.ID, "error", err)
+		return
+	}
+
+	gapStart := 0
+	for seq := begin; seq <= end; seq++ {
+		stored, ok := messages[seq]
+		if !ok || isAdminMsgType(stored.MsgType()) {
+			if gapStart == 0 {
+				gapStart = seq
+			}
+			continue
+		}
+
+		if gapStart != 0 {
+			s.gapFill(gapStart, seq)
+			gapStart = 0
+		}
+
+		if sendingTime, ok := stored.Get(tagSendingTime); ok {
+			stored.Set(tagOrigSendingTime, sendingTime)
+		}
+		stored.Set(tagPossDupFlag, "Y")
+		stored.SetTime(tagSendingTime, time.Now())
+		if err := s.write(stored.Bytes()); err != nil {
+			slog.Error("fix: failed to resend message", "session", s.ID, "seq", seq, "error", err)
+			return
+		}
+	}
+	if gapStart != 0 {
+		s.gapFill(gapStart, end+1)
+	}
+}
+
+func (s *Session) gapFill(seq, newSeq int) {
+	gapFill := NewMessage(msgTypeSequenceReset)
+	gapFill.Set(tagSenderCompID, s.ID.SenderCompID)
+	gapFill.Set(tagTargetCompID, s.ID.TargetCompID)
+	gapFill.SetInt(tagMsgSeqNum, seq)
+	gapFill.Set(tagPossDupFlag, "Y")
+	gapFill.SetTime(tagSendingTime, time.Now())
+	gapFill.Set(tagGapFillFlag, "Y")
+	gapFill.SetInt(tagNewSeqNo, newSeq)
+	if err := s.write(gapFill.Bytes()); err != nil {
+		slog.Error("fix: failed to send gap fill", "session", s.ID, "error", err)
+	}
+}
+
+func (s *Session) reject(refSeqNum int, refMsgType string, reason int, text string) {
+	reject := NewMessage(msgTypeReject)
+	reject.SetInt(tagRefSeqNum, refSeqNum)
+	reject.Set(tagRefMsgType, refMsgType)
+	reject.SetInt(tagSessionRejectReason, reason)
+	reject.Set(tagText, text)
+	if err := s.send(reject); err != nil {
+		slog.Error("fix: failed to send reject", "session", s.ID, "error", err)
+	}
+}
+
+func (s *Session) logout(text string) {
+	logout := NewMessage(msgTypeLogout)
+	if text != "" {
+		logout.Set(tagText, text)
+	}
+	if err := s.send(logout); err != nil {
+		slog.Error("fix: failed to send logout", "session", s.ID, "error", err)
+	}
+}
+
+// send stamps, stores and, if the counterparty is connected, writes an
+// outgoing message. It must be called with s.mu held.
+func (s *Session) send(msg *Message) error {
+	msg.Set(tagSenderCompID, s.ID.SenderCompID)
+	msg.Set(tagTargetCompID, s.ID.TargetCompID)
+	msg.SetTime(tagSendingTime, time.Now())
+
+	raw, err := s.acceptor.store.SaveOutgoing(s.ID, msg)
+	if err != nil {
+		return err
+	}
+	if s.conn == nil {
+		return nil
+	}
+	return s.write(raw)
+}
+
+// write must be called with s.mu held.
+func (s *Session) write(raw []byte) error {
+	if s.conn == nil {
+		return nil
+	}
+	s.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if _, err := s.conn.Write(raw); err != nil {
+		return err
+	}
+	s.lastSent = time.Now()
+	return nil
+}
@@ -0,0 +1,190 @@
+package fix
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// SessionID identifies a FIX session from the acceptor's point of view:
+// SenderCompID is ours and TargetCompID is the counterparty's.
+type SessionID struct {
+	SenderCompID string
+	TargetCompID string
+}
+
+func (id SessionID) String() string {
+	return id.SenderCompID + "->" + id.TargetCompID
+}
+
+// Store persists sequence numbers and outgoing messages in Postgres so that
+// a session can be resumed, and resend requests answered, after a restart.
+type Store struct {
+	db *sqlx.DB
+}
+
+func NewStore(db *sqlx.DB) *Store {
+	return &Store{db: db}
+}
+
+// Load returns the next outgoing and the next expected incoming sequence
+// numbers of a session, creating the session on first use. A new session
+// starts reporting from the events recorded after its creation.
+func (s *Store) Load(id SessionID) (nextSenderSeq, nextTargetSeq int, err error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	created, err := tx.Exec(`INSERT INTO fix_sessions (sender_comp_id, target_comp_id) VALUES ($1, $2)
+							 ON CONFLICT (sender_comp_id, target_comp_id) DO NOTHING`, id.SenderCompID, id.TargetCompID)
+	if err != nil {
+		return 0, 0, err
+	}
+	if rows, _ := created.RowsAffected(); rows == 1 {
+		_, err = tx.Exec(`INSERT INTO fix_report_cursors (sender_comp_id, target_comp_id, symbol, last_sequence)
+						  SELECT $1, $2, symbol, last_sequence FROM symbol_sequences`, id.SenderCompID, id.TargetCompID)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to start report cursors: %w", err)
+		}
+	}
+
+	err = tx.QueryRow(`SELECT next_sender_seq, next_target_seq FROM fix_sessions WHERE sender_comp_id = $1 AND target_comp_id = $2`,
+		id.SenderCompID, id.TargetCompID).Scan(&nextSenderSeq, &nextTargetSeq)
+	if err != nil {
+		return 0, 0, err
+	}
+	return nextSenderSeq, nextTargetSeq, tx.Commit()
+}
+
+// ReportCursors returns, by symbol, the sequence number up to which the
+// order events of a symbol have been reported to a session.
+func (s *Store) ReportCursors(id SessionID) (map[string]int64, error) {
+	rows, err := s.db.Query(`SELECT symbol, last_sequence FROM fix_report_cursors WHERE sender_comp_id = $1 AND target_comp_id = $2`,
+		id.SenderCompID, id.TargetCompID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cursors := make(map[string]int64)
+	for rows.Next() {
+		var symbol string
+		var sequence int64
+		if err := rows.Scan(&symbol, &sequence); err != nil {
+			return nil, err
+		}
+		cursors[symbol] = sequence
+	}
+	return cursors, rows.Err()
+}
+
+func (s *Store) SetReportCursor(id SessionID, symbol string, sequence int64) error {
+	_, err := s.db.Exec(`INSERT INTO fix_report_cursors (sender_comp_id, target_comp_id, symbol, last_sequence) VALUES ($1, $2, $3, $4)
+						 ON CONFLICT (sender_comp_id, target_comp_id, symbol) DO UPDATE
+						 SET last_sequence = EXCLUDED.last_sequence, updated_at = CURRENT_TIMESTAMP`,
+		id.SenderCompID, id.TargetCompID, symbol, sequence)
+	return err
+}
+
+// Reset starts both sequences over at 1 and forgets the stored messages.
+// What has been reported is not forgotten.
+func (s *Store) Reset(id SessionID) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`DELETE FROM fix_messages WHERE sender_comp_id = $1 AND target_comp_id = $2`,
+		id.SenderCompID, id.TargetCompID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`UPDATE fix_sessions SET next_sender_seq = 1, next_target_seq = 1
+					  WHERE sender_comp_id = $1 AND target_comp_id = $2`, id.SenderCompID, id.TargetCompID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *Store) SetNextTargetSeq(id SessionID, seq int) error {
+	_, err := s.db.Exec(`UPDATE fix_sessions SET next_target_seq = $1 WHERE sender_comp_id = $2 AND target_comp_id = $3`,
+		seq, id.SenderCompID, id.TargetCompID)
+	return err
+}
+
+// SaveOutgoing assigns the next outgoing sequence number to msg, stores the
+// encoded message and returns it ready to be written to the wire.
+func (s *Store) SaveOutgoing(id SessionID, msg *Message) ([]byte, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var seq int
+	query := `UPDATE fix_sessions SET next_sender_seq = next_sender_seq + 1
+			  WHERE sender_comp_id = $1 AND target_comp_id = $2
+			  RETURNING next_sender_seq - 1`
+	if err := tx.QueryRow(query, id.SenderCompID, id.TargetCompID).Scan(&seq); err != nil {
+		return nil, fmt.Errorf("failed to allocate sequence number: %w", err)
+	}
+	msg.SetInt(tagMsgSeqNum, seq)
+
+	raw := msg.Bytes()
+	_, err = tx.Exec(`INSERT INTO fix_messages (sender_comp_id, target_comp_id, seq_num, message) VALUES ($1, $2, $3, $4)`,
+		id.SenderCompID, id.TargetCompID, seq, string(raw))
+	if err != nil {
+		return nil, fmt.Errorf("failed to store message: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// NextSenderSeq returns the sequence number the next outgoing message will
+// be given.
+func (s *Store) NextSenderSeq(id SessionID) (int, error) {
+	var seq int
+	err := s.db.QueryRow(`SELECT next_sender_seq FROM fix_sessions WHERE sender_comp_id = $1 AND target_comp_id = $2`,
+		id.SenderCompID, id.TargetCompID).Scan(&seq)
+	if err == sql.ErrNoRows {
+		return 1, nil
+	}
+	return seq, err
+}
+
+// Messages returns the stored outgoing messages with sequence numbers in
+// [begin, end], keyed by sequence number.
+func (s *Store) Messages(id SessionID, begin, end int) (map[int]*Message, error) {
+	rows, err := s.db.Query(`SELECT seq_num, message FROM fix_messages
+							 WHERE sender_comp_id = $1 AND target_comp_id = $2 AND seq_num BETWEEN $3 AND $4`,
+		id.SenderCompID, id.TargetCompID, begin, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := make(map[int]*Message)
+	for rows.Next() {
+		var seq int
+		var raw string
+		if err := rows.Scan(&seq, &raw); err != nil {
+			return nil, err
+		}
+		msg, err := ParseMessage([]byte(raw))
+		if err != nil {
+			return nil, fmt.Errorf("stored message %d is corrupt: %w", seq, err)
+		}
+		messages[seq] = msg
+	}
+
+	return messages, rows.Err()
+}
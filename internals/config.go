@@ -1,41 +1,207 @@
 package internals
 
 import (
-	"os"
+	"crypto/tls"
+	"fmt"
+	"time"
 
-	"github.com/joho/godotenv"
+	"github.com/google/uuid"
 )
 
+// Config is the configuration of the whole system. Every setting can come
+// from the YAML configuration file; those with an env tag can also be set
+// by that environment variable and those with a flag tag by that command
+// line flag, see Load. Settings tagged reload are applied again on SIGHUP,
+// the others need a restart.
 type Config struct {
-	DBHost     string
-	DBPort     string
-	DBUser     string
-	DBPassword string
-	DBName     string
-	ServerPort string
+	Database DatabaseConfig `yaml:"database"`
+	HTTP     HTTPConfig     `yaml:"http"`
+	GRPC     GRPCConfig     `yaml:"grpc"`
+	FIX      FIXConfig      `yaml:"fix"`
+
+	// TLS serves HTTP and gRPC over TLS when a certificate is given.
+	TLS TLSConfig `yaml:"tls"`
+
+	Log     LogConfig     `yaml:"log"`
+	Tracing TracingConfig `yaml:"tracing"`
+
+	RateLimits RateLimitConfig `yaml:"rate_limits" reload:"true"`
+
+	// MaxBatchSize bounds the number of orders placed or canceled by a
+	// single batch command.
+	MaxBatchSize int `yaml:"max_batch_size" env:"MAX_BATCH_SIZE" flag:"max-batch-size" reload:"true"`
+
+	// TradingScheduleFile is a JSON trading schedule; without one every
+	// symbol trades continuously unless an administrator changes its state.
+	TradingScheduleFile string `yaml:"trading_schedule_file" env:"TRADING_SCHEDULE_FILE" flag:"trading-schedule"`
+
+	// Instruments holds the settings of individual symbols.
+	Instruments map[string]InstrumentConfig `yaml:"instruments" reload:"true"`
 }
 
-func GetConfig() Config {
-	if os.Getenv("GO_ENV") != "production" {
-		_ = godotenv.Load(".env") // fallback for local dev
-	}
+type DatabaseConfig struct {
+	Host     string `yaml:"host" env:"DB_HOST" flag:"db-host"`
+	Port     int    `yaml:"port" env:"DB_PORT" flag:"db-port"`
+	User     string `yaml:"user" env:"DB_USER" flag:"db-user"`
+	Password string `yaml:"password" env:"DB_PASSWORD"`
+	Name     string `yaml:"name" env:"DB_NAME" flag:"db-name"`
+	SSLMode  string `yaml:"sslmode" env:"DB_SSLMODE"`
+
+	// Connection pool. Zero MaxOpenConns and lifetimes are unlimited.
+	MaxOpenConns    int           `yaml:"max_open_conns" env:"DB_MAX_OPEN_CONNS"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME"`
+	ConnectTimeout  time.Duration `yaml:"connect_timeout" env:"DB_CONNECT_TIMEOUT"`
+}
+
+type HTTPConfig struct {
+	Port              int           `yaml:"port" env:"SERVER_PORT" flag:"http-port"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" env:"HTTP_READ_TIMEOUT"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env:"HTTP_READ_HEADER_TIMEOUT"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" env:"HTTP_WRITE_TIMEOUT"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" env:"HTTP_IDLE_TIMEOUT"`
+
+	// ShutdownTimeout is how long a graceful shutdown waits for the
+	// requests in flight.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"HTTP_SHUTDOWN_TIMEOUT"`
+
+	// TrustedProxies are the addresses or CIDR ranges of the reverse
+	// proxies whose X-Forwarded-For and X-Real-IP headers give the client
+	// address. Without any, the client is the peer of the connection.
+	TrustedProxies []string `yaml:"trusted_proxies"`
+}
+
+type GRPCConfig struct {
+	Port int `yaml:"port" env:"GRPC_PORT" flag:"grpc-port"`
+}
+
+type FIXConfig struct {
+	Port         int    `yaml:"port" env:"FIX_PORT" flag:"fix-port"`
+	SenderCompID string `yaml:"sender_comp_id" env:"FIX_SENDER_COMP_ID"`
+
+	// Counterparties are the only ones allowed to log on, by their
+	// SenderCompID, each trading for the account it maps to.
+	Counterparties map[string]uuid.UUID `yaml:"counterparties"`
+}
 
-	config := Config{
-		DBHost:     getEnv("DB_HOST", "localhost"),
-		DBPort:     getEnv("DB_PORT", "5432"),
-		DBUser:     getEnv("DB_USER", "postgres"),
-		DBPassword: getEnv("DB_PASSWORD", "password"),
-		DBName:     getEnv("DB_NAME", "order_matching_system"),
-		ServerPort: getEnv("SERVER_PORT", "8080"),
+type TLSConfig struct {
+	CertFile string `yaml:"cert_file" env:"TLS_CERT_FILE" flag:"tls-cert"`
+	KeyFile  string `yaml:"key_file" env:"TLS_KEY_FILE" flag:"tls-key"`
+}
+
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != ""
+}
+
+// Load reads the certificate, or returns nil when TLS is not enabled.
+func (c TLSConfig) Load() (*tls.Config, error) {
+	if !c.Enabled() {
+		return nil, nil
+	}
+	certificate, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the TLS certificate: %w", err)
 	}
+	return &tls.Config{Certificates: []tls.Certificate{certificate}, MinVersion: tls.VersionTLS12}, nil
+}
+
+type LogConfig struct {
+	// Level is the minimum level logged: debug, info, warn or error.
+	Level string `yaml:"level" env:"LOG_LEVEL" flag:"log-level" reload:"true"`
+}
+
+// TracingConfig sends OpenTelemetry spans nowhere ("none"), to an OTLP
+// collector ("otlp", configured by the standard OTEL_EXPORTER_OTLP_*
+// variables), to stdout ("stdout") or to File ("file"). SampleRatio is the
+// fraction of the traces started here that are recorded; traces started by
+// a caller follow its sampling decision.
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter" env:"TRACE_EXPORTER" flag:"trace-exporter"`
+	File        string  `yaml:"file" env:"TRACE_FILE"`
+	SampleRatio float64 `yaml:"sample_ratio" env:"TRACE_SAMPLE_RATIO"`
+}
+
+// RateLimitConfig limits how fast each API key, or each IP address for
+// anonymous requests, may send requests of each kind.
+type RateLimitConfig struct {
+	Enabled    bool         `yaml:"enabled" env:"RATE_LIMITS_ENABLED"`
+	Orders     BucketConfig `yaml:"orders"`
+	Cancels    BucketConfig `yaml:"cancels"`
+	MarketData BucketConfig `yaml:"market_data"`
+
+	// Authentication limits the requests with an unknown API key per IP
+	// address. Once it is used up, requests with any API key from the
+	// address are refused before the key is looked up.
+	Authentication BucketConfig `yaml:"authentication"`
+
+	// OrderToTradeRatio rejects the orders of an account that placed more
+	// than MaxRatio orders per trade over Window, once it placed at least
+	// MinOrders. A zero MaxRatio disables it.
+	OrderToTradeRatio OrderToTradeRatioConfig `yaml:"order_to_trade_ratio"`
+}
+
+// BucketConfig is a token bucket refilled with Rate tokens per second up
+// to Burst.
+type BucketConfig struct {
+	Rate  float64 `yaml:"rate"`
+	Burst int     `yaml:"burst"`
+}
+
+type OrderToTradeRatioConfig struct {
+	MaxRatio  float64       `yaml:"max_ratio"`
+	MinOrders int           `yaml:"min_orders"`
+	Window    time.Duration `yaml:"window"`
+}
 
-	return config
+// InstrumentConfig holds the order limits of a symbol; zero is unlimited.
+// MaxOrderNotional only applies to orders with a price.
+type InstrumentConfig struct {
+	MaxOrderQuantity float64 `yaml:"max_order_quantity"`
+	MaxOrderNotional float64 `yaml:"max_order_notional"`
 }
 
-func getEnv(key, defaultValue string) string {
-	value := os.Getenv(key)
-	if value == "" {
-		return defaultValue
+// Defaults returns the configuration used for every setting that is not
+// configured.
+func Defaults() Config {
+	return Config{
+		Database: DatabaseConfig{
+			Host:           "localhost",
+			Port:           5432,
+			User:           "postgres",
+			Password:       "password",
+			Name:           "order_matching_system",
+			SSLMode:        "disable",
+			MaxOpenConns:   25,
+			MaxIdleConns:   10,
+			ConnectTimeout: 10 * time.Second,
+		},
+		HTTP: HTTPConfig{
+			Port:              8080,
+			ReadTimeout:       15 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       2 * time.Minute,
+			ShutdownTimeout:   15 * time.Second,
+		},
+		GRPC: GRPCConfig{Port: 9090},
+		FIX:  FIXConfig{Port: 9878, SenderCompID: "OMS"},
+		Log:  LogConfig{Level: "info"},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			File:        "traces.jsonl",
+			SampleRatio: 1,
+		},
+		RateLimits: RateLimitConfig{
+			Orders:         BucketConfig{Rate: 20, Burst: 40},
+			Cancels:        BucketConfig{Rate: 40, Burst: 80},
+			MarketData:     BucketConfig{Rate: 10, Burst: 20},
+			Authentication: BucketConfig{Rate: 1, Burst: 10},
+			OrderToTradeRatio: OrderToTradeRatioConfig{
+				MinOrders: 100,
+				Window:    time.Hour,
+			},
+		},
+		MaxBatchSize: 50,
 	}
-	return value
 }
@@ -0,0 +1,182 @@
+package internals
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// configFileEnv names the configuration file when the -config flag does not.
+const configFileEnv = "CONFIG_FILE"
+
+// Load builds the configuration from, in increasing order of precedence,
+// the defaults, the YAML file given by -config or CONFIG_FILE, environment
+// variables and command line flags, and validates it. It returns the
+// arguments left after the flags.
+func Load(args []string) (Config, []string, error) {
+	if os.Getenv("GO_ENV") != "production" {
+		_ = godotenv.Load(".env") // fallback for local dev
+	}
+
+	flags := flag.NewFlagSet("order-matching-system", flag.ContinueOnError)
+	configFile := flags.String("config", os.Getenv(configFileEnv), "YAML configuration file (env "+configFileEnv+")")
+	flagValues := make(map[string]string)
+	walkSettings(reflect.ValueOf(Defaults()), "", func(_ reflect.Value, field reflect.StructField, path string) {
+		name := field.Tag.Get("flag")
+		if name == "" {
+			return
+		}
+		usage := "overrides " + path
+		if env := field.Tag.Get("env"); env != "" {
+			usage += " and " + env
+		}
+		flags.Func(name, usage, func(value string) error {
+			flagValues[name] = value
+			return nil
+		})
+	})
+	if err := flags.Parse(args); err != nil {
+		return Config{}, nil, err
+	}
+
+	config := Defaults()
+	if *configFile != "" {
+		if err := loadFile(*configFile, &config); err != nil {
+			return Config{}, nil, err
+		}
+	}
+
+	// Environment variables, then flags, override the file
+	var problems []error
+	walkSettings(reflect.ValueOf(&config).Elem(), "", func(value reflect.Value, field reflect.StructField, _ string) {
+		if env := field.Tag.Get("env"); env != "" && os.Getenv(env) != "" {
+			if err := setSetting(value, os.Getenv(env)); err != nil {
+				problems = append(problems, fmt.Errorf("%s: %w", env, err))
+			}
+		}
+		if name := field.Tag.Get("flag"); name != "" {
+			if flagValue, ok := flagValues[name]; ok {
+				if err := setSetting(value, flagValue); err != nil {
+					problems = append(problems, fmt.Errorf("-%s: %w", name, err))
+				}
+			}
+		}
+	})
+	if len(problems) > 0 {
+		return Config{}, nil, errors.Join(problems...)
+	}
+
+	if err := config.Validate(); err != nil {
+		return Config{}, nil, err
+	}
+	return config, flags.Args(), nil
+}
+
+func loadFile(path string, config *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read the configuration file: %w", err)
+	}
+
+	// Unknown keys are most likely typos, which would otherwise silently
+	// leave a setting at its default.
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(config); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("invalid configuration file %s: %w", path, err)
+	}
+
+	normalized := make(map[string]InstrumentConfig, len(config.Instruments))
+	for symbol, instrument := range config.Instruments {
+		normalized[strings.ToUpper(symbol)] = instrument
+	}
+	config.Instruments = normalized
+	return nil
+}
+
+// walkSettings calls fn for every setting of the configuration, that is
+// every field that is not a nested section, with its path in the file.
+func walkSettings(v reflect.Value, prefix string, fn func(reflect.Value, reflect.StructField, string)) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		path := prefix + strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if field.Type.Kind() == reflect.Struct {
+			walkSettings(v.Field(i), path+".", fn)
+			continue
+		}
+		fn(v.Field(i), field, path)
+	}
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// setSetting parses a setting given as text by an environment variable or
+// a flag.
+func setSetting(value reflect.Value, text string) error {
+	switch {
+	case value.Type() == durationType:
+		d, err := time.ParseDuration(text)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q, expected e.g. 30s or 5m", text)
+		}
+		value.SetInt(int64(d))
+	case value.Kind() == reflect.String:
+		value.SetString(text)
+	case value.Kind() == reflect.Int:
+		n, err := strconv.Atoi(text)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", text)
+		}
+		value.SetInt(int64(n))
+	case value.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", text)
+		}
+		value.SetFloat(f)
+	case value.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q, expected true or false", text)
+		}
+		value.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported setting type %s", value.Type())
+	}
+	return nil
+}
+
+// RestartRequired returns the settings that differ between two
+// configurations and are not applied by a reload.
+func RestartRequired(current, next Config) []string {
+	var changed []string
+	restartRequired(reflect.ValueOf(current), reflect.ValueOf(next), "", &changed)
+	return changed
+}
+
+func restartRequired(current, next reflect.Value, prefix string, changed *[]string) {
+	for i := 0; i < current.NumField(); i++ {
+		field := current.Type().Field(i)
+		if field.Tag.Get("reload") != "" {
+			continue
+		}
+		path := prefix + strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if field.Type.Kind() == reflect.Struct {
+			restartRequired(current.Field(i), next.Field(i), path+".", changed)
+			continue
+		}
+		if !reflect.DeepEqual(current.Field(i).Interface(), next.Field(i).Interface()) {
+			*changed = append(*changed, path)
+		}
+	}
+}
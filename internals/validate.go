@@ -0,0 +1,173 @@
+package internals
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/netip"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	sslModes       = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+	traceExporters = []string{"none", "otlp", "stdout", "file"}
+)
+
+// Validate checks the configuration as a whole and returns every problem
+// found, each naming the setting by its path in the configuration file.
+func (c Config) Validate() error {
+	var problems []error
+	add := func(format string, args ...any) {
+		problems = append(problems, fmt.Errorf(format, args...))
+	}
+
+	ports := map[int]string{}
+	for _, port := range []struct {
+		path   string
+		number int
+	}{
+		{"http.port", c.HTTP.Port},
+		{"grpc.port", c.GRPC.Port},
+		{"fix.port", c.FIX.Port},
+	} {
+		if port.number < 1 || port.number > 65535 {
+			add("%s must be between 1 and 65535, got %d", port.path, port.number)
+			continue
+		}
+		if other, ok := ports[port.number]; ok {
+			add("%s and %s cannot both be %d", other, port.path, port.number)
+		}
+		ports[port.number] = port.path
+	}
+
+	if c.Database.Host == "" {
+		add("database.host is required")
+	}
+	if c.Database.Port < 1 || c.Database.Port > 65535 {
+		add("database.port must be between 1 and 65535, got %d", c.Database.Port)
+	}
+	if c.Database.Name == "" {
+		add("database.name is required")
+	}
+	if !slices.Contains(sslModes, c.Database.SSLMode) {
+		add("database.sslmode must be one of %v, got %q", sslModes, c.Database.SSLMode)
+	}
+	if c.Database.MaxOpenConns < 0 {
+		add("database.max_open_conns cannot be negative")
+	}
+	if c.Database.MaxIdleConns < 0 {
+		add("database.max_idle_conns cannot be negative")
+	}
+	if c.Database.MaxOpenConns > 0 && c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		add("database.max_idle_conns (%d) cannot exceed database.max_open_conns (%d)",
+			c.Database.MaxIdleConns, c.Database.MaxOpenConns)
+	}
+
+	for _, duration := range []struct {
+		path  string
+		value time.Duration
+	}{
+		{"database.conn_max_lifetime", c.Database.ConnMaxLifetime},
+		{"database.conn_max_idle_time", c.Database.ConnMaxIdleTime},
+		{"database.connect_timeout", c.Database.ConnectTimeout},
+		{"http.read_timeout", c.HTTP.ReadTimeout},
+		{"http.read_header_timeout", c.HTTP.ReadHeaderTimeout},
+		{"http.write_timeout", c.HTTP.WriteTimeout},
+		{"http.idle_timeout", c.HTTP.IdleTimeout},
+	} {
+		if duration.value < 0 {
+			add("%s cannot be negative", duration.path)
+		}
+	}
+	if c.HTTP.ShutdownTimeout <= 0 {
+		add("http.shutdown_timeout must be positive")
+	}
+
+	for _, proxy := range c.HTTP.TrustedProxies {
+		if _, err := netip.ParsePrefix(proxy); err == nil {
+			continue
+		}
+		if _, err := netip.ParseAddr(proxy); err != nil {
+			add("http.trusted_proxies: %q is not an IP address or CIDR range", proxy)
+		}
+	}
+
+	if c.FIX.SenderCompID == "" {
+		add("fix.sender_comp_id is required")
+	}
+	for compID, accountID := range c.FIX.Counterparties {
+		if compID == "" || compID == c.FIX.SenderCompID {
+			add("fix.counterparties: %q is not a valid CompID", compID)
+		}
+		if accountID == uuid.Nil {
+			add("fix.counterparties.%s: an account ID is required", compID)
+		}
+	}
+
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		add("tls.cert_file and tls.key_file must be set together")
+	} else if c.TLS.Enabled() {
+		for path, file := range map[string]string{"tls.cert_file": c.TLS.CertFile, "tls.key_file": c.TLS.KeyFile} {
+			if _, err := os.Stat(file); err != nil {
+				add("%s: %w", path, err)
+			}
+		}
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		add("log.level must be debug, info, warn or error, got %q", c.Log.Level)
+	}
+
+	if !slices.Contains(traceExporters, c.Tracing.Exporter) {
+		add("tracing.exporter must be one of %v, got %q", traceExporters, c.Tracing.Exporter)
+	}
+	if c.Tracing.Exporter == "file" && c.Tracing.File == "" {
+		add("tracing.file is required by the file exporter")
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		add("tracing.sample_ratio must be between 0 and 1, got %g", c.Tracing.SampleRatio)
+	}
+
+	if c.RateLimits.Enabled {
+		for path, bucket := range map[string]BucketConfig{
+			"rate_limits.orders":         c.RateLimits.Orders,
+			"rate_limits.cancels":        c.RateLimits.Cancels,
+			"rate_limits.market_data":    c.RateLimits.MarketData,
+			"rate_limits.authentication": c.RateLimits.Authentication,
+		} {
+			if bucket.Rate <= 0 {
+				add("%s.rate must be positive", path)
+			}
+			if bucket.Burst < 1 {
+				add("%s.burst must be at least 1", path)
+			}
+		}
+		ratio := c.RateLimits.OrderToTradeRatio
+		if ratio.MaxRatio < 0 {
+			add("rate_limits.order_to_trade_ratio.max_ratio cannot be negative")
+		}
+		if ratio.MaxRatio > 0 && ratio.Window <= 0 {
+			add("rate_limits.order_to_trade_ratio.window must be positive")
+		}
+		if ratio.MinOrders < 0 {
+			add("rate_limits.order_to_trade_ratio.min_orders cannot be negative")
+		}
+	}
+
+	if c.MaxBatchSize < 1 {
+		add("max_batch_size must be at least 1, got %d", c.MaxBatchSize)
+	}
+
+	for symbol, instrument := range c.Instruments {
+		if instrument.MaxOrderQuantity < 0 || instrument.MaxOrderNotional < 0 {
+			add("instruments.%s: order limits cannot be negative", symbol)
+		}
+	}
+
+	return errors.Join(problems...)
+}
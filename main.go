@@ -1,39 +1,218 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 
+	"github.com/bartick/golang-order-matching-system/api"
 	internalDb "github.com/bartick/golang-order-matching-system/db"
+	"github.com/bartick/golang-order-matching-system/engine"
 	"github.com/bartick/golang-order-matching-system/internals"
 	"github.com/bartick/golang-order-matching-system/service"
 )
 
 func main() {
-	log.Println("Starting the application...")
+	config, args, err := internals.Load(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "invalid configuration:")
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	logLevel := setupLogging()
+	slog.Info("starting the application")
 
-	environmentConfig := internals.GetConfig()
+	tlsConfig, err := config.TLS.Load()
+	if err != nil {
+		slog.Error("failed to set up TLS", "error", err)
+		os.Exit(1)
+	}
 
-	dbConnection := internalDb.ConnectDatabase(environmentConfig)
+	shutdownTracing, err := service.SetupTracing(config.Tracing)
+	if err != nil {
+		slog.Error("failed to set up tracing", "error", err)
+		os.Exit(1)
+	}
+
+	dbConnection := internalDb.ConnectDatabase(config.Database)
 	if dbConnection == nil {
-		log.Fatal("Failed to connect to the database")
+		slog.Error("failed to connect to the database")
+		os.Exit(1)
+	}
+	slog.Info("database connection established")
+
+	matchingEngine := engine.NewEngine(dbConnection)
+	rateLimiter := api.NewRateLimiter(config.RateLimits)
+	applyReloadable(config, logLevel, matchingEngine, rateLimiter)
+
+	if len(args) > 0 && args[0] == "rebuild-positions" {
+		code := rebuildPositions(matchingEngine, args[1:])
+		dbConnection.Close()
+		os.Exit(code)
 	}
-	log.Println("Database connection established successfully.")
 
-	srv := service.NewWebServer(":"+environmentConfig.ServerPort, dbConnection)
+	metrics := service.NewMetrics(dbConnection, matchingEngine)
+	matchingEngine.SetMetrics(metrics)
+
+	// The HTTP server comes up first so that /readyz reports the startup
+	health := api.NewHealth(dbConnection, matchingEngine)
+	srv := service.NewWebServer(config.HTTP, tlsConfig, dbConnection, matchingEngine, metrics, health, rateLimiter)
 	srv.Start()
 
-	fmt.Println("Application is running...")
+	if err := matchingEngine.LoadOrderLimits(); err != nil {
+		slog.Error("failed to load the order limits", "error", err)
+		os.Exit(1)
+	}
+
+	books, err := matchingEngine.LoadBooks()
+	if err != nil {
+		slog.Error("failed to load the order books", "error", err)
+		os.Exit(1)
+	}
+	health.BooksLoaded(books)
+	slog.Info("order books loaded", "books", books)
+
+	matchingEngine.StartHaltMonitor()
+	matchingEngine.StartExpiryMonitor()
+	matchingEngine.StartMarginMonitor()
+
+	if config.TradingScheduleFile != "" {
+		schedule, err := engine.LoadSchedule(config.TradingScheduleFile)
+		if err != nil {
+			slog.Error("failed to load the trading schedule", "file", config.TradingScheduleFile, "error", err)
+			os.Exit(1)
+		}
+		matchingEngine.StartSchedule(schedule)
+		slog.Info("trading schedule started", "file", config.TradingScheduleFile)
+	}
+
+	grpcSrv := service.NewGRPCServer(":"+strconv.Itoa(config.GRPC.Port), tlsConfig, matchingEngine, rateLimiter)
+	grpcSrv.Start()
+
+	fixSrv := service.NewFIXServer(":"+strconv.Itoa(config.FIX.Port), config.FIX.SenderCompID, config.FIX.Counterparties, dbConnection, matchingEngine)
+	fixSrv.Start()
+	health.SetPhase(api.PhaseReady)
+
+	slog.Info("application is running",
+		"http_port", config.HTTP.Port,
+		"grpc_port", config.GRPC.Port,
+		"fix_port", config.FIX.Port,
+		"tls", config.TLS.Enabled())
+
 	sig := make(chan os.Signal, 1)
-	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
-	signal.Notify(sig, syscall.SIGINT)
-	<-sig
-	log.Println("Shutting down the application gracefully...")
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	for s := range sig {
+		if s != syscall.SIGHUP {
+			break
+		}
+		reloadConfig(config, logLevel, matchingEngine, rateLimiter)
+	}
+	slog.Info("shutting down the application")
+	health.SetPhase(api.PhaseStopping)
 
+	// The HTTP server goes last so that /readyz reports the shutdown
+	fixSrv.Shutdown()
+	grpcSrv.Shutdown()
+	matchingEngine.StopSchedule()
+	matchingEngine.StopMarginMonitor()
+	matchingEngine.StopExpiryMonitor()
+	matchingEngine.StopHaltMonitor()
 	srv.Shutdown()
 	dbConnection.Close()
-	log.Println("Application has been shut down.")
+	if err := shutdownTracing(context.Background()); err != nil {
+		slog.Error("failed to flush traces", "error", err)
+	}
+	slog.Info("application has been shut down")
+}
+
+// setupLogging logs JSON lines to stdout, for the standard log package as
+// well, at the level returned so that a reload can change it.
+func setupLogging() *slog.LevelVar {
+	level := new(slog.LevelVar)
+	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level})))
+	return level
+}
+
+// applyReloadable applies the settings that can change while running. The
+// configuration has been validated, so the log level parses.
+func applyReloadable(config internals.Config, logLevel *slog.LevelVar, matchingEngine *engine.Engine, rateLimiter *api.RateLimiter) {
+	logLevel.UnmarshalText([]byte(config.Log.Level))
+	matchingEngine.SetMaxBatchSize(config.MaxBatchSize)
+	rateLimiter.SetConfig(config.RateLimits)
+
+	// The order-to-trade ratio belongs to the engine so that it holds for
+	// every protocol
+	ratio := config.RateLimits.OrderToTradeRatio
+	if !config.RateLimits.Enabled {
+		ratio = internals.OrderToTradeRatioConfig{}
+	}
+	matchingEngine.SetOrderToTradeRatio(engine.OrderToTradeRatio{
+		MaxRatio:  ratio.MaxRatio,
+		MinOrders: ratio.MinOrders,
+		Window:    ratio.Window,
+	})
+
+	// The limits set through the admin API stay in force over these
+	orderLimits := make(map[string]engine.OrderLimits, len(config.Instruments))
+	for symbol, instrument := range config.Instruments {
+		orderLimits[symbol] = engine.OrderLimits{
+			MaxQuantity: instrument.MaxOrderQuantity,
+			MaxNotional: instrument.MaxOrderNotional,
+		}
+	}
+	matchingEngine.SetOrderLimits(orderLimits)
+}
+
+// reloadConfig loads the configuration again on SIGHUP and applies the
+// settings that can change while running. An invalid configuration is
+// ignored, and the settings changed since startup that need a restart are
+// logged.
+func reloadConfig(startup internals.Config, logLevel *slog.LevelVar, matchingEngine *engine.Engine, rateLimiter *api.RateLimiter) {
+	next, _, err := internals.Load(os.Args[1:])
+	if err != nil {
+		slog.Error("configuration reload failed, keeping the current configuration", "error", err)
+		return
+	}
+
+	applyReloadable(next, logLevel, matchingEngine, rateLimiter)
+	if changed := internals.RestartRequired(startup, next); len(changed) > 0 {
+		slog.Warn("configuration changes need a restart to take effect", "settings", changed)
+	}
+	slog.Info("configuration reloaded")
+}
+
+// rebuildPositions recomputes the positions from the trades and prints
+// those that differ from the stored ones. It exits with 1 when they differ
+// and -fix was not given.
+func rebuildPositions(matchingEngine *engine.Engine, args []string) int {
+	flags := flag.NewFlagSet("rebuild-positions", flag.ExitOnError)
+	fix := flags.Bool("fix", false, "replace the positions that differ with the rebuilt ones")
+	flags.Parse(args)
+
+	mismatches, err := matchingEngine.RebuildPositions(*fix)
+	if err != nil {
+		slog.Error("failed to rebuild positions", "error", err)
+		return 2
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	for _, mismatch := range mismatches {
+		encoder.Encode(mismatch)
+	}
+
+	switch {
+	case len(mismatches) == 0:
+		slog.Info("positions match the trades")
+	case *fix:
+		slog.Info("fixed positions", "count", len(mismatches))
+	default:
+		slog.Warn("positions differ from the trades", "count", len(mismatches))
+		return 1
+	}
+	return 0
 }
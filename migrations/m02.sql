@@ -0,0 +1,14 @@
+-- Queue priority is tracked separately from created_at so that amended
+-- orders can lose their place in the queue without rewriting history.
+ALTER TABLE orders ADD COLUMN queued_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
+UPDATE orders SET queued_at = created_at;
+ALTER TABLE orders ALTER COLUMN queued_at SET NOT NULL;
+
+DROP INDEX IF EXISTS idx_buy_orders;
+DROP INDEX IF EXISTS idx_sell_orders;
+
+CREATE INDEX idx_buy_orders ON orders(symbol, side, price DESC, queued_at ASC)
+WHERE side = 'buy' AND status IN ('open', 'partially_filled');
+
+CREATE INDEX idx_sell_orders ON orders(symbol, side, price ASC, queued_at ASC)
+WHERE side = 'sell' AND status IN ('open', 'partially_filled');
@@ -0,0 +1,37 @@
+-- Client order IDs and the entry point of each order, used by the FIX
+-- gateway to route execution reports back to the owning session.
+ALTER TABLE orders ADD COLUMN client_order_id VARCHAR(64) NULL;
+ALTER TABLE orders ADD COLUMN source VARCHAR(100) NOT NULL DEFAULT 'http';
+
+CREATE INDEX idx_orders_source_client_order_id ON orders(source, client_order_id)
+WHERE client_order_id IS NOT NULL;
+
+-- FIX session sequence numbers survive restarts so that counterparties can
+-- resume a session without resetting it.
+CREATE TABLE fix_sessions (
+    sender_comp_id VARCHAR(64) NOT NULL,
+    target_comp_id VARCHAR(64) NOT NULL,
+    next_sender_seq INTEGER NOT NULL DEFAULT 1,
+    next_target_seq INTEGER NOT NULL DEFAULT 1,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (sender_comp_id, target_comp_id)
+);
+
+CREATE TRIGGER update_fix_sessions_updated_at
+    BEFORE UPDATE ON fix_sessions
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Every outgoing message is kept for resend requests.
+CREATE TABLE fix_messages (
+    sender_comp_id VARCHAR(64) NOT NULL,
+    target_comp_id VARCHAR(64) NOT NULL,
+    seq_num INTEGER NOT NULL,
+    message TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (sender_comp_id, target_comp_id, seq_num),
+    FOREIGN KEY (sender_comp_id, target_comp_id) REFERENCES fix_sessions(sender_comp_id, target_comp_id)
+);
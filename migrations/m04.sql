@@ -0,0 +1,31 @@
+-- Accounts own orders. Clients authenticate with an API key, of which only
+-- the SHA-256 hex digest is stored.
+CREATE TABLE accounts (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(100) NOT NULL,
+    api_key_hash VARCHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Sample account for local development, API key "demo-api-key".
+INSERT INTO accounts (name, api_key_hash)
+VALUES ('demo', 'ca6f2e39b2ff141859b18bb5283aadd5093c8ede2869f3656231a054e54bfc22');
+
+-- Orders placed without an API key have no account.
+ALTER TABLE orders ADD COLUMN account_id UUID NULL REFERENCES accounts(id);
+
+CREATE INDEX idx_orders_account_status ON orders(account_id, status)
+WHERE account_id IS NOT NULL;
+
+-- One row per symbol. Every command against a book bumps its sequence
+-- number, and the row lock serializes commands on that book.
+CREATE TABLE symbol_sequences (
+    symbol VARCHAR(10) PRIMARY KEY,
+    last_sequence BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER update_symbol_sequences_updated_at
+    BEFORE UPDATE ON symbol_sequences
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
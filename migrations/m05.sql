@@ -0,0 +1,10 @@
+-- History of what happened to each order and why.
+CREATE TABLE order_events (
+    id BIGSERIAL PRIMARY KEY,
+    order_id UUID NOT NULL REFERENCES orders(id),
+    event_type VARCHAR(30) NOT NULL,
+    reason VARCHAR(50) NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_order_events_order_id ON order_events(order_id, created_at);
@@ -0,0 +1,9 @@
+-- Iceberg orders show only display_quantity at a time. visible_quantity is
+-- what is left of the current slice; both are NULL for regular orders.
+ALTER TABLE orders ADD COLUMN display_quantity INTEGER NULL;
+ALTER TABLE orders ADD COLUMN visible_quantity INTEGER NULL;
+
+ALTER TABLE orders ADD CONSTRAINT chk_display_quantity
+    CHECK (display_quantity IS NULL OR (display_quantity > 0 AND type = 'limit'));
+ALTER TABLE orders ADD CONSTRAINT chk_visible_quantity
+    CHECK (visible_quantity IS NULL OR (visible_quantity >= 0 AND visible_quantity <= remaining_quantity));
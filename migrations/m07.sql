@@ -0,0 +1,7 @@
+-- Post-only orders never take liquidity; hidden orders rest without being
+-- shown in the order book.
+ALTER TABLE orders ADD COLUMN post_only BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE orders ADD COLUMN hidden BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE orders ADD CONSTRAINT chk_post_only_limit CHECK (NOT post_only OR type = 'limit');
+ALTER TABLE orders ADD CONSTRAINT chk_hidden_limit CHECK (NOT hidden OR (type = 'limit' AND display_quantity IS NULL));
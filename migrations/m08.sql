@@ -0,0 +1,29 @@
+-- Stop and stop-limit orders wait with the status 'pending' until a trade
+-- reaches their stop price. Trailing stops move their stop price with the
+-- last trade price by a fixed amount or a percentage.
+ALTER TABLE orders DROP CONSTRAINT orders_type_check;
+ALTER TABLE orders ADD CONSTRAINT orders_type_check
+    CHECK (type IN ('limit', 'market', 'stop', 'stop_limit'));
+
+ALTER TABLE orders DROP CONSTRAINT orders_status_check;
+ALTER TABLE orders ADD CONSTRAINT orders_status_check
+    CHECK (status IN ('pending', 'open', 'filled', 'canceled', 'partially_filled'));
+
+ALTER TABLE orders DROP CONSTRAINT chk_limit_order_has_price;
+ALTER TABLE orders ADD CONSTRAINT chk_limit_order_has_price
+    CHECK (type IN ('market', 'stop') OR price IS NOT NULL);
+
+ALTER TABLE orders ADD COLUMN stop_price DECIMAL(10, 2) NULL;
+ALTER TABLE orders ADD COLUMN trail_amount DECIMAL(10, 2) NULL;
+ALTER TABLE orders ADD COLUMN trail_percent DECIMAL(5, 2) NULL;
+
+ALTER TABLE orders ADD CONSTRAINT chk_stop_order_has_stop_price
+    CHECK (type NOT IN ('stop', 'stop_limit') OR stop_price IS NOT NULL);
+ALTER TABLE orders ADD CONSTRAINT chk_trail
+    CHECK (trail_amount IS NULL OR trail_percent IS NULL);
+
+CREATE INDEX idx_pending_stop_orders ON orders(symbol, side, stop_price)
+WHERE status = 'pending';
+
+-- Free-form event data, e.g. the new stop price of a trailing stop.
+ALTER TABLE order_events ADD COLUMN details JSONB NULL;
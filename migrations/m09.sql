@@ -0,0 +1,24 @@
+-- Pegged orders follow the best bid, the best offer or the midpoint of the
+-- displayed book. Their price is the current pegged price and is NULL,
+-- with the status 'pending', while the reference price does not exist.
+ALTER TABLE orders DROP CONSTRAINT orders_type_check;
+ALTER TABLE orders ADD CONSTRAINT orders_type_check
+    CHECK (type IN ('limit', 'market', 'stop', 'stop_limit', 'peg'));
+
+ALTER TABLE orders DROP CONSTRAINT chk_limit_order_has_price;
+ALTER TABLE orders ADD CONSTRAINT chk_limit_order_has_price
+    CHECK (type IN ('market', 'stop', 'peg') OR price IS NOT NULL);
+
+ALTER TABLE orders DROP CONSTRAINT chk_hidden_limit;
+ALTER TABLE orders ADD CONSTRAINT chk_hidden_limit
+    CHECK (NOT hidden OR (type IN ('limit', 'peg') AND display_quantity IS NULL));
+
+ALTER TABLE orders ADD COLUMN peg_reference VARCHAR(3) NULL CHECK (peg_reference IN ('bid', 'ask', 'mid'));
+ALTER TABLE orders ADD COLUMN peg_offset DECIMAL(10, 2) NULL;
+ALTER TABLE orders ADD COLUMN peg_limit_price DECIMAL(10, 2) NULL;
+
+ALTER TABLE orders ADD CONSTRAINT chk_peg_order_has_reference
+    CHECK ((type = 'peg') = (peg_reference IS NOT NULL));
+
+CREATE INDEX idx_peg_orders ON orders(symbol)
+WHERE type = 'peg' AND status IN ('pending', 'open', 'partially_filled');
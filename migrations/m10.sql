@@ -0,0 +1,25 @@
+-- Trading phase of each instrument. Instruments without a row trade
+-- continuously. During a call phase orders are collected without matching
+-- until the auction uncrosses the book.
+CREATE TABLE instruments (
+    symbol VARCHAR(10) PRIMARY KEY,
+    trading_phase VARCHAR(20) NOT NULL DEFAULT 'continuous'
+        CHECK (trading_phase IN ('continuous', 'opening_call', 'closing_call')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER update_instruments_updated_at
+    BEFORE UPDATE ON instruments
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Market-on-open and market-on-close orders wait with the status 'pending'
+-- for their auction.
+ALTER TABLE orders DROP CONSTRAINT orders_type_check;
+ALTER TABLE orders ADD CONSTRAINT orders_type_check
+    CHECK (type IN ('limit', 'market', 'stop', 'stop_limit', 'peg', 'market_on_open', 'market_on_close'));
+
+ALTER TABLE orders DROP CONSTRAINT chk_limit_order_has_price;
+ALTER TABLE orders ADD CONSTRAINT chk_limit_order_has_price
+    CHECK (type IN ('market', 'stop', 'peg', 'market_on_open', 'market_on_close') OR price IS NOT NULL);
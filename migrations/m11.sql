@@ -0,0 +1,17 @@
+-- Instrument state machine. The trading phase of the call auctions becomes
+-- the 'auction' state, with the auction being called held separately.
+-- 'manual' is set while an administrator overrides the trading schedule.
+ALTER TABLE instruments
+    ADD COLUMN state VARCHAR(20) NOT NULL DEFAULT 'continuous'
+        CHECK (state IN ('pre_open', 'auction', 'continuous', 'halted', 'closed')),
+    ADD COLUMN auction VARCHAR(10) CHECK (auction IN ('opening', 'closing')),
+    ADD COLUMN manual BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE instruments
+SET state = 'auction', auction = CASE trading_phase WHEN 'opening_call' THEN 'opening' ELSE 'closing' END
+WHERE trading_phase IN ('opening_call', 'closing_call');
+
+ALTER TABLE instruments DROP COLUMN trading_phase;
+
+ALTER TABLE instruments ADD CONSTRAINT chk_auction_state
+    CHECK ((state = 'auction') = (auction IS NOT NULL));
@@ -0,0 +1,28 @@
+-- Volatility circuit breakers. A trade outside the price band of an
+-- instrument halts it into a re-opening auction until halt_ends_at. NULL
+-- band settings fall back to the engine defaults; a band of 0 disables the
+-- circuit breaker.
+ALTER TABLE instruments DROP CONSTRAINT instruments_auction_check;
+ALTER TABLE instruments ADD CONSTRAINT instruments_auction_check
+    CHECK (auction IN ('opening', 'closing', 'reopening'));
+
+ALTER TABLE instruments
+    ADD COLUMN price_band_percent DECIMAL(5, 2) CHECK (price_band_percent >= 0),
+    ADD COLUMN volatility_halt_seconds INTEGER CHECK (volatility_halt_seconds > 0),
+    ADD COLUMN halt_ends_at TIMESTAMP;
+
+-- Every halt and its cause.
+CREATE TABLE trading_halts (
+    id BIGSERIAL PRIMARY KEY,
+    symbol VARCHAR(10) NOT NULL,
+    cause VARCHAR(30) NOT NULL,
+    order_id UUID NULL REFERENCES orders(id),
+    reference_price DECIMAL(10, 2) NULL,
+    trigger_price DECIMAL(10, 2) NULL,
+    band_low DECIMAL(10, 2) NULL,
+    band_high DECIMAL(10, 2) NULL,
+    started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    ends_at TIMESTAMP NULL
+);
+
+CREATE INDEX idx_trading_halts_symbol ON trading_halts(symbol, started_at);
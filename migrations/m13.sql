@@ -0,0 +1,8 @@
+-- Matching algorithm of each instrument: price-time priority ('fifo'),
+-- pro-rata allocation at each price level ('pro_rata') or the first order
+-- of the level filled first and the rest pro-rata ('fifo_pro_rata'). Pro-rata
+-- shares below min_allocation are not allocated.
+ALTER TABLE instruments
+    ADD COLUMN matching_algorithm VARCHAR(20) NOT NULL DEFAULT 'fifo'
+        CHECK (matching_algorithm IN ('fifo', 'pro_rata', 'fifo_pro_rata')),
+    ADD COLUMN min_allocation INTEGER NOT NULL DEFAULT 1 CHECK (min_allocation >= 1);
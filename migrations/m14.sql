@@ -0,0 +1,103 @@
+-- Instruments are base/quote pairs such as BTC-USD, with their own price
+-- and quantity precisions. Columns left NULL are derived from the symbol:
+-- a pair is split on '-', any other symbol is quoted in USD, prices have
+-- 2 decimals and quantities 8 for pairs and none for other symbols.
+ALTER TABLE instruments
+    ADD COLUMN base_asset VARCHAR(10),
+    ADD COLUMN quote_asset VARCHAR(10),
+    ADD COLUMN price_precision SMALLINT CHECK (price_precision BETWEEN 0 AND 8),
+    ADD COLUMN quantity_precision SMALLINT CHECK (quantity_precision BETWEEN 0 AND 8);
+
+-- Pair symbols are longer, and quantities are fractional. The views read
+-- the columns being changed, so they are recreated around the change.
+DROP VIEW active_orders;
+DROP VIEW order_book;
+DROP VIEW recent_trades;
+
+ALTER TABLE orders
+    ALTER COLUMN symbol TYPE VARCHAR(20),
+    ALTER COLUMN price TYPE DECIMAL(20, 8),
+    ALTER COLUMN initial_quantity TYPE DECIMAL(20, 8),
+    ALTER COLUMN remaining_quantity TYPE DECIMAL(20, 8),
+    ALTER COLUMN display_quantity TYPE DECIMAL(20, 8),
+    ALTER COLUMN visible_quantity TYPE DECIMAL(20, 8),
+    ALTER COLUMN stop_price TYPE DECIMAL(20, 8),
+    ALTER COLUMN trail_amount TYPE DECIMAL(20, 8),
+    ALTER COLUMN peg_offset TYPE DECIMAL(20, 8),
+    ALTER COLUMN peg_limit_price TYPE DECIMAL(20, 8);
+
+ALTER TABLE trades
+    ALTER COLUMN symbol TYPE VARCHAR(20),
+    ALTER COLUMN price TYPE DECIMAL(20, 8),
+    ALTER COLUMN quantity TYPE DECIMAL(20, 8);
+
+ALTER TABLE order_book_snapshots
+    ALTER COLUMN symbol TYPE VARCHAR(20),
+    ALTER COLUMN price TYPE DECIMAL(20, 8),
+    ALTER COLUMN total_quantity TYPE DECIMAL(20, 8);
+
+ALTER TABLE symbol_sequences ALTER COLUMN symbol TYPE VARCHAR(20);
+ALTER TABLE instruments ALTER COLUMN symbol TYPE VARCHAR(20);
+
+ALTER TABLE trading_halts
+    ALTER COLUMN symbol TYPE VARCHAR(20),
+    ALTER COLUMN reference_price TYPE DECIMAL(20, 8),
+    ALTER COLUMN trigger_price TYPE DECIMAL(20, 8),
+    ALTER COLUMN band_low TYPE DECIMAL(20, 8),
+    ALTER COLUMN band_high TYPE DECIMAL(20, 8);
+
+-- Pro-rata allocations are fractional too.
+ALTER TABLE instruments DROP CONSTRAINT instruments_min_allocation_check;
+ALTER TABLE instruments
+    ALTER COLUMN min_allocation TYPE DECIMAL(20, 8),
+    ADD CONSTRAINT instruments_min_allocation_check CHECK (min_allocation > 0);
+
+CREATE VIEW active_orders AS
+SELECT * FROM orders
+WHERE status IN ('open', 'partially_filled')
+ORDER BY symbol, side,
+    CASE WHEN side = 'buy' THEN price END DESC,
+    CASE WHEN side = 'sell' THEN price END ASC,
+    created_at ASC;
+
+CREATE VIEW order_book AS
+SELECT
+    symbol,
+    side,
+    price,
+    SUM(remaining_quantity) as total_quantity,
+    COUNT(*) as order_count,
+    MIN(created_at) as earliest_order
+FROM orders
+WHERE status IN ('open', 'partially_filled')
+GROUP BY symbol, side, price
+ORDER BY symbol, side,
+    CASE WHEN side = 'buy' THEN price END DESC,
+    CASE WHEN side = 'sell' THEN price END ASC;
+
+CREATE VIEW recent_trades AS
+SELECT
+    t.*,
+    bo.symbol as buy_symbol,
+    so.symbol as sell_symbol
+FROM trades t
+JOIN orders bo ON t.buy_order_id = bo.id
+JOIN orders so ON t.sell_order_id = so.id
+ORDER BY t.executed_at DESC;
+
+-- Asset balances of each account. Every trade between orders with an
+-- account moves the base asset from the seller to the buyer and the quote
+-- asset the other way round.
+CREATE TABLE balances (
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    asset VARCHAR(10) NOT NULL,
+    amount DECIMAL(30, 8) NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (account_id, asset)
+);
+
+CREATE TRIGGER update_balances_updated_at
+    BEFORE UPDATE ON balances
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,48 @@
+-- Dated futures. A future trades until the end of its last trading day, is
+-- delisted once past its expiry date and settles into positions instead of
+-- balances. Its daily settlement price is the VWAP of the trades in the
+-- closing window ending with the last trade of the day.
+ALTER TABLE instruments
+    ADD COLUMN instrument_type VARCHAR(10) NOT NULL DEFAULT 'spot'
+        CHECK (instrument_type IN ('spot', 'future')),
+    ADD COLUMN contract_multiplier DECIMAL(20, 8) CHECK (contract_multiplier > 0),
+    ADD COLUMN expiry_date DATE,
+    ADD COLUMN last_trading_day DATE,
+    ADD COLUMN settlement_window_minutes INT CHECK (settlement_window_minutes > 0);
+
+ALTER TABLE instruments ADD CONSTRAINT chk_future_terms
+    CHECK (instrument_type = 'spot' OR (contract_multiplier IS NOT NULL
+        AND expiry_date IS NOT NULL AND last_trading_day IS NOT NULL
+        AND last_trading_day <= expiry_date));
+
+ALTER TABLE instruments DROP CONSTRAINT instruments_state_check;
+ALTER TABLE instruments ADD CONSTRAINT instruments_state_check
+    CHECK (state IN ('pre_open', 'auction', 'continuous', 'halted', 'closed', 'delisted'));
+
+CREATE TABLE settlement_prices (
+    symbol VARCHAR(20) NOT NULL,
+    trading_date DATE NOT NULL,
+    price DECIMAL(20, 8) NOT NULL,
+    volume DECIMAL(20, 8) NOT NULL DEFAULT 0,
+    window_start TIMESTAMP,
+    window_end TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (symbol, trading_date)
+);
+
+-- Net futures position of each account: positive when long, negative when
+-- short.
+CREATE TABLE positions (
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    symbol VARCHAR(20) NOT NULL,
+    quantity DECIMAL(20, 8) NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (account_id, symbol)
+);
+
+CREATE TRIGGER update_positions_updated_at
+    BEFORE UPDATE ON positions
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,11 @@
+-- Positions cover every symbol, with their average entry price and
+-- realized PnL in the quote asset. Positions that existed before are
+-- recomputed from the trades with `rebuild-positions -fix`.
+ALTER TABLE positions
+    ADD COLUMN average_entry_price DECIMAL(20, 8) NOT NULL DEFAULT 0,
+    ADD COLUMN realized_pnl DECIMAL(30, 8) NOT NULL DEFAULT 0;
+
+-- Trades of the same transaction share their execution time; the sequence
+-- keeps the order they were executed in for the rebuild.
+ALTER TABLE trades ADD COLUMN seq BIGSERIAL;
+CREATE INDEX idx_trades_executed_at_seq ON trades(executed_at, seq);
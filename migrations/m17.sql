@@ -0,0 +1,44 @@
+-- Futures traded on margin. Orders must be covered by the initial margin
+-- and positions by the maintenance margin, as percentages of the notional;
+-- accounts below their maintenance margin are liquidated.
+ALTER TABLE instruments
+    ADD COLUMN initial_margin_percent DECIMAL(7, 4),
+    ADD COLUMN maintenance_margin_percent DECIMAL(7, 4);
+
+ALTER TABLE instruments ADD CONSTRAINT chk_margin
+    CHECK ((initial_margin_percent IS NULL) = (maintenance_margin_percent IS NULL)
+        AND maintenance_margin_percent > 0 AND maintenance_margin_percent <= initial_margin_percent
+        AND initial_margin_percent <= 100);
+
+-- Orders placed by the margin monitor to close the positions of a
+-- liquidated account.
+ALTER TABLE orders ADD COLUMN liquidation BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- A liquidation stays open until the positions of the account are closed;
+-- the shortfall is what the insurance fund then paid to bring the equity of
+-- the account back to zero.
+CREATE TABLE liquidations (
+    id BIGSERIAL PRIMARY KEY,
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    asset VARCHAR(10) NOT NULL,
+    equity DECIMAL(30, 8) NOT NULL,
+    maintenance_margin DECIMAL(30, 8) NOT NULL,
+    shortfall DECIMAL(30, 8),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    closed_at TIMESTAMP
+);
+
+CREATE INDEX idx_liquidations_open ON liquidations(account_id) WHERE closed_at IS NULL;
+
+-- Insurance fund ledger: its balance in an asset is the sum of its entries.
+CREATE TABLE insurance_fund (
+    id BIGSERIAL PRIMARY KEY,
+    asset VARCHAR(10) NOT NULL,
+    amount DECIMAL(30, 8) NOT NULL,
+    kind VARCHAR(10) NOT NULL CHECK (kind IN ('deposit', 'shortfall')),
+    account_id UUID REFERENCES accounts(id),
+    liquidation_id BIGINT REFERENCES liquidations(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_insurance_fund_asset ON insurance_fund(asset);
@@ -0,0 +1,9 @@
+-- Migrations applied to the database, one row per migrations/mNN.sql file.
+-- Every later migration ends by recording its own version, and the service
+-- only reports itself ready once the database is at the version it expects.
+CREATE TABLE schema_migrations (
+    version INT PRIMARY KEY,
+    applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO schema_migrations (version) SELECT generate_series(1, 18);
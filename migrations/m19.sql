@@ -0,0 +1,5 @@
+-- The order-to-trade ratio limit counts the recent orders of an account
+CREATE INDEX idx_orders_account_created_at ON orders(account_id, created_at)
+WHERE account_id IS NOT NULL;
+
+INSERT INTO schema_migrations (version) VALUES (19);
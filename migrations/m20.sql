@@ -0,0 +1,54 @@
+-- Administrators use the /admin endpoints. Like accounts, they authenticate
+-- with an API key of which only the SHA-256 hex digest is stored.
+CREATE TABLE admins (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(100) NOT NULL UNIQUE,
+    api_key_hash VARCHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Sample administrator for local development, API key "demo-admin-key".
+INSERT INTO admins (name, api_key_hash)
+VALUES ('admin', 'ac5bb3526d3be432ba19fb1fc0712d350c160bd2169cd24401c9fcaeaac2d860');
+
+-- A disabled account can no longer authenticate.
+ALTER TABLE accounts ADD COLUMN enabled BOOLEAN NOT NULL DEFAULT TRUE;
+
+-- Snapshots taken by administrators record the sequence number of the book
+-- they were taken at.
+ALTER TABLE order_book_snapshots ADD COLUMN sequence BIGINT;
+
+-- One row per administrator action, whether it succeeded or not.
+CREATE TABLE admin_audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    admin_id UUID NOT NULL REFERENCES admins(id),
+    admin_name VARCHAR(100) NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    target VARCHAR(100),
+    details JSONB,
+    status INTEGER NOT NULL,
+    request_id VARCHAR(100),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_admin_audit_log_created_at ON admin_audit_log(created_at);
+
+-- Audit records are never changed or removed.
+CREATE OR REPLACE FUNCTION reject_audit_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION '% is append-only', TG_TABLE_NAME;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER admin_audit_log_append_only
+    BEFORE UPDATE OR DELETE ON admin_audit_log
+    FOR EACH ROW
+    EXECUTE FUNCTION reject_audit_change();
+
+CREATE TRIGGER admin_audit_log_no_truncate
+    BEFORE TRUNCATE ON admin_audit_log
+    FOR EACH STATEMENT
+    EXECUTE FUNCTION reject_audit_change();
+
+INSERT INTO schema_migrations (version) VALUES (20);
@@ -0,0 +1,28 @@
+-- Every step of the life of an order is recorded in order_events: accepts,
+-- fills, amends, reprices, triggers, cancels, expiries and rejects. Rejected
+-- orders were never stored, so their events have no order.
+ALTER TABLE order_events ALTER COLUMN order_id DROP NOT NULL;
+
+-- The symbol and its sequence number place an event within the commands of
+-- its book; the actor is who issued the command (an account, a gateway or
+-- the engine itself).
+ALTER TABLE order_events ADD COLUMN symbol VARCHAR(20);
+ALTER TABLE order_events ADD COLUMN sequence BIGINT;
+ALTER TABLE order_events ADD COLUMN actor VARCHAR(100) NOT NULL DEFAULT 'engine';
+
+-- The fields of the order the event changed, before and after.
+ALTER TABLE order_events ADD COLUMN before_values JSONB;
+ALTER TABLE order_events ADD COLUMN after_values JSONB;
+
+-- Like the admin audit log, order events are never changed or removed.
+CREATE TRIGGER order_events_append_only
+    BEFORE UPDATE OR DELETE ON order_events
+    FOR EACH ROW
+    EXECUTE FUNCTION reject_audit_change();
+
+CREATE TRIGGER order_events_no_truncate
+    BEFORE TRUNCATE ON order_events
+    FOR EACH STATEMENT
+    EXECUTE FUNCTION reject_audit_change();
+
+INSERT INTO schema_migrations (version) VALUES (21);
@@ -0,0 +1,11 @@
+-- Order limits set by administrators. They override the limits of the
+-- configuration for their symbol, across reloads and restarts, until they
+-- are removed.
+CREATE TABLE order_limits (
+    symbol VARCHAR(20) PRIMARY KEY,
+    max_quantity DECIMAL(30, 8) NOT NULL DEFAULT 0 CHECK (max_quantity >= 0),
+    max_notional DECIMAL(30, 8) NOT NULL DEFAULT 0 CHECK (max_notional >= 0),
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO schema_migrations (version) VALUES (22);
@@ -0,0 +1,24 @@
+-- The FIX gateway reports the fills and cancels its sessions did not ask
+-- for from the order events, symbol by symbol in sequence order.
+CREATE INDEX idx_order_events_symbol_sequence ON order_events(symbol, sequence);
+
+-- The last sequence number of each symbol whose events have been reported
+-- to a session. A symbol without a row has not been reported on since the
+-- session was created, so all of its events are new to the session.
+CREATE TABLE fix_report_cursors (
+    sender_comp_id VARCHAR(64) NOT NULL,
+    target_comp_id VARCHAR(64) NOT NULL,
+    symbol VARCHAR(20) NOT NULL,
+    last_sequence BIGINT NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (sender_comp_id, target_comp_id, symbol),
+    FOREIGN KEY (sender_comp_id, target_comp_id) REFERENCES fix_sessions(sender_comp_id, target_comp_id)
+);
+
+-- Existing sessions were told about everything up to now.
+INSERT INTO fix_report_cursors (sender_comp_id, target_comp_id, symbol, last_sequence)
+SELECT f.sender_comp_id, f.target_comp_id, s.symbol, s.last_sequence
+FROM fix_sessions f CROSS JOIN symbol_sequences s;
+
+INSERT INTO schema_migrations (version) VALUES (23);
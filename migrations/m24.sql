@@ -0,0 +1,32 @@
+-- Audited admin actions are recorded before they run, with a NULL status
+-- while they are pending, so that none can take effect without a trace.
+ALTER TABLE admin_audit_log ALTER COLUMN status DROP NOT NULL;
+
+-- The only change ever made to an entry is filling in the status, and the
+-- details when it had none, of a pending one.
+CREATE OR REPLACE FUNCTION reject_admin_audit_log_update()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF OLD.status IS NOT NULL OR NEW.status IS NULL
+       OR (OLD.details IS NOT NULL AND NEW.details IS DISTINCT FROM OLD.details)
+       OR (NEW.id, NEW.admin_id, NEW.admin_name, NEW.action, NEW.target, NEW.request_id, NEW.created_at)
+          IS DISTINCT FROM (OLD.id, OLD.admin_id, OLD.admin_name, OLD.action, OLD.target, OLD.request_id, OLD.created_at) THEN
+        RAISE EXCEPTION '% is append-only', TG_TABLE_NAME;
+    END IF;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+DROP TRIGGER admin_audit_log_append_only ON admin_audit_log;
+
+CREATE TRIGGER admin_audit_log_append_only
+    BEFORE DELETE ON admin_audit_log
+    FOR EACH ROW
+    EXECUTE FUNCTION reject_audit_change();
+
+CREATE TRIGGER admin_audit_log_finish_only
+    BEFORE UPDATE ON admin_audit_log
+    FOR EACH ROW
+    EXECUTE FUNCTION reject_admin_audit_log_update();
+
+INSERT INTO schema_migrations (version) VALUES (24);
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Admin struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// AdminAction is an entry of the admin audit log. Status is the HTTP status
+// the action was answered with, or nil while it is pending: an entry that
+// stays pending is an action whose outcome could not be recorded.
+type AdminAction struct {
+	ID        int64           `json:"id" db:"id"`
+	AdminID   uuid.UUID       `json:"admin_id" db:"admin_id"`
+	AdminName string          `json:"admin_name" db:"admin_name"`
+	Action    string          `json:"action" db:"action"`
+	Target    *string         `json:"target,omitempty" db:"target"`
+	Details   json.RawMessage `json:"details,omitempty" db:"details"`
+	Status    *int            `json:"status" db:"status"`
+	RequestID *string         `json:"request_id,omitempty" db:"request_id"`
+	CreatedAt time.Time       `json:"created_at" db:"created_at"`
+}
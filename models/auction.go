@@ -0,0 +1,22 @@
+package models
+
+// AuctionState describes the auction a symbol collects orders for: the
+// price the book would uncross at if the auction ran now, the volume that
+// would execute and the quantity left over on one side.
+type AuctionState struct {
+	Symbol        string   `json:"symbol"`
+	Auction       string   `json:"auction"`
+	Price         *float64 `json:"indicative_price"`
+	Volume        float64  `json:"indicative_volume"`
+	Imbalance     float64  `json:"imbalance"`
+	ImbalanceSide string   `json:"imbalance_side,omitempty"`
+}
+
+// AuctionResult is the outcome of an uncross.
+type AuctionResult struct {
+	Symbol  string   `json:"symbol"`
+	Auction string   `json:"auction"`
+	Price   *float64 `json:"price"`
+	Volume  float64  `json:"volume"`
+	Trades  []Trade  `json:"trades"`
+}
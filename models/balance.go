@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Balance is how much of an asset an account holds. Trades settle into the
+// balances of both accounts, in the base and the quote asset of the symbol.
+type Balance struct {
+	AccountID uuid.UUID `json:"account_id"`
+	Asset     string    `json:"asset"`
+	Amount    float64   `json:"amount"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Instrument is the trading state of a symbol. BaseAsset is what the
+// symbol trades and QuoteAsset what it is priced in; prices and quantities
+// have at most PricePrecision and QuantityPrecision decimals. Type is
+// "spot" or "future"; futures carry their contract terms, and their margin
+// requirements as a percentage of the notional when traded on margin.
+// Auction is "opening", "closing" or "reopening" while the state is
+// "auction". Manual is set while an administrator overrides the trading
+// schedule.
+// PriceBandPercent and VolatilityHaltSeconds override the engine's circuit
+// breaker defaults, and HaltEndsAt is set during a volatility halt.
+// MatchingAlgorithm decides how the orders of a price level share an
+// incoming order.
+type Instrument struct {
+	Symbol                   string     `json:"symbol"`
+	BaseAsset                string     `json:"base_asset"`
+	QuoteAsset               string     `json:"quote_asset"`
+	PricePrecision           int        `json:"price_precision"`
+	QuantityPrecision        int        `json:"quantity_precision"`
+	Type                     string     `json:"type"`
+	ContractMultiplier       *float64   `json:"contract_multiplier,omitempty"`
+	ExpiryDate               *time.Time `json:"expiry_date,omitempty"`
+	LastTradingDay           *time.Time `json:"last_trading_day,omitempty"`
+	SettlementWindowMinutes  *int       `json:"settlement_window_minutes,omitempty"`
+	InitialMarginPercent     *float64   `json:"initial_margin_percent,omitempty"`
+	MaintenanceMarginPercent *float64   `json:"maintenance_margin_percent,omitempty"`
+	State                    string     `json:"state"`
+	Auction                  *string    `json:"auction,omitempty"`
+	Manual                   bool       `json:"manual"`
+	PriceBandPercent         *float64   `json:"price_band_percent,omitempty"`
+	VolatilityHaltSeconds    *int       `json:"volatility_halt_seconds,omitempty"`
+	HaltEndsAt               *time.Time `json:"halt_ends_at,omitempty"`
+	MatchingAlgorithm        string     `json:"matching_algorithm"`
+	MinAllocation            float64    `json:"min_allocation"`
+	UpdatedAt                time.Time  `json:"updated_at"`
+}
+
+// TradingHalt records why trading in a symbol was halted. The prices are
+// set for volatility halts only.
+type TradingHalt struct {
+	ID             int64      `json:"id"`
+	Symbol         string     `json:"symbol"`
+	Cause          string     `json:"cause"`
+	OrderID        *uuid.UUID `json:"order_id,omitempty"`
+	ReferencePrice *float64   `json:"reference_price,omitempty"`
+	TriggerPrice   *float64   `json:"trigger_price,omitempty"`
+	BandLow        *float64   `json:"band_low,omitempty"`
+	BandHigh       *float64   `json:"band_high,omitempty"`
+	StartedAt      time.Time  `json:"started_at"`
+	EndsAt         *time.Time `json:"ends_at,omitempty"`
+}
+
+// SettlementPrice is the daily settlement price of a future: the volume
+// weighted average price of its trades in the closing window of a trading
+// day. A day without trades in the window carries the previous price over
+// with no volume.
+type SettlementPrice struct {
+	Symbol      string     `json:"symbol"`
+	TradingDate time.Time  `json:"trading_date"`
+	Price       float64    `json:"price"`
+	Volume      float64    `json:"volume"`
+	WindowStart *time.Time `json:"window_start,omitempty"`
+	WindowEnd   *time.Time `json:"window_end,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Margin is the margin state of an account in one collateral asset, the
+// quote asset of the futures it trades on margin. Equity is the balance of
+// the asset plus the realized and unrealized PnL of those futures. The
+// initial margin covers the positions and the open orders, and the
+// maintenance margin the positions only; an account whose equity falls
+// below its maintenance margin is liquidated.
+type Margin struct {
+	AccountID         uuid.UUID `json:"account_id"`
+	Asset             string    `json:"asset"`
+	Balance           float64   `json:"balance"`
+	RealizedPnL       float64   `json:"realized_pnl"`
+	UnrealizedPnL     float64   `json:"unrealized_pnl"`
+	Equity            float64   `json:"equity"`
+	InitialMargin     float64   `json:"initial_margin"`
+	MaintenanceMargin float64   `json:"maintenance_margin"`
+}
+
+// InsuranceFundEntry is a movement of the insurance fund: a deposit, or a
+// negative shortfall absorbed when a liquidated account ended up with
+// negative equity.
+type InsuranceFundEntry struct {
+	ID            int64      `json:"id"`
+	Asset         string     `json:"asset"`
+	Amount        float64    `json:"amount"`
+	Kind          string     `json:"kind"`
+	AccountID     *uuid.UUID `json:"account_id,omitempty"`
+	LiquidationID *int64     `json:"liquidation_id,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OrderEvent is a step in the life of an order. Before and After hold the
+// fields of the order the event changed; Sequence is the sequence number of
+// the command of the symbol that produced the event.
+type OrderEvent struct {
+	ID        int64           `json:"id" db:"id"`
+	OrderID   *uuid.UUID      `json:"order_id,omitempty" db:"order_id"`
+	Symbol    *string         `json:"symbol,omitempty" db:"symbol"`
+	Sequence  *int64          `json:"sequence,omitempty" db:"sequence"`
+	EventType string          `json:"event_type" db:"event_type"`
+	Reason    *string         `json:"reason,omitempty" db:"reason"`
+	Actor     string          `json:"actor" db:"actor"`
+	Before    json.RawMessage `json:"before,omitempty" db:"before_values"`
+	After     json.RawMessage `json:"after,omitempty" db:"after_values"`
+	Details   json.RawMessage `json:"details,omitempty" db:"details"`
+	CreatedAt time.Time       `json:"created_at" db:"created_at"`
+}
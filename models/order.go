@@ -7,14 +7,28 @@ import (
 )
 
 type Order struct {
-	ID                uuid.UUID `json:"id" db:"id"`
-	Symbol            string    `json:"symbol" db:"symbol"`
-	Side              string    `json:"side" db:"side"`
-	Type              string    `json:"type" db:"type"`
-	Price             *float64  `json:"price" db:"price"`
-	InitialQuantity   int       `json:"initial_quantity" db:"initial_quantity"`
-	RemainingQuantity int       `json:"remaining_quantity" db:"remaining_quantity"`
-	Status            string    `json:"status" db:"status"`
-	CreatedAt         time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt         time.Time `json:"updated_at" db:"updated_at"`
+	ID                uuid.UUID  `json:"id" db:"id"`
+	ClientOrderID     *string    `json:"client_order_id,omitempty" db:"client_order_id"`
+	Source            string     `json:"source" db:"source"`
+	AccountID         *uuid.UUID `json:"account_id,omitempty" db:"account_id"`
+	Symbol            string     `json:"symbol" db:"symbol"`
+	Side              string     `json:"side" db:"side"`
+	Type              string     `json:"type" db:"type"`
+	Price             *float64   `json:"price" db:"price"`
+	InitialQuantity   float64    `json:"initial_quantity" db:"initial_quantity"`
+	RemainingQuantity float64    `json:"remaining_quantity" db:"remaining_quantity"`
+	DisplayQuantity   *float64   `json:"display_quantity,omitempty" db:"display_quantity"`
+	VisibleQuantity   *float64   `json:"visible_quantity,omitempty" db:"visible_quantity"`
+	PostOnly          bool       `json:"post_only" db:"post_only"`
+	Hidden            bool       `json:"hidden" db:"hidden"`
+	StopPrice         *float64   `json:"stop_price,omitempty" db:"stop_price"`
+	TrailAmount       *float64   `json:"trail_amount,omitempty" db:"trail_amount"`
+	TrailPercent      *float64   `json:"trail_percent,omitempty" db:"trail_percent"`
+	PegReference      *string    `json:"peg_reference,omitempty" db:"peg_reference"`
+	PegOffset         *float64   `json:"peg_offset,omitempty" db:"peg_offset"`
+	PegLimitPrice     *float64   `json:"peg_limit_price,omitempty" db:"peg_limit_price"`
+	Liquidation       bool       `json:"liquidation" db:"liquidation"`
+	Status            string     `json:"status" db:"status"`
+	CreatedAt         time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at" db:"updated_at"`
 }
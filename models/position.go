@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Position is the net quantity of a symbol an account holds: positive when
+// long, negative when short. AverageEntryPrice is what the open quantity
+// cost on average, and RealizedPnL what closing quantity against it earned,
+// in the quote asset. The position is marked to the mid price of the book,
+// or to the last trade price when either side is empty; MarkPrice and
+// UnrealizedPnL are left out when the symbol has neither.
+type Position struct {
+	AccountID         uuid.UUID `json:"account_id"`
+	Symbol            string    `json:"symbol"`
+	Quantity          float64   `json:"quantity"`
+	AverageEntryPrice float64   `json:"average_entry_price"`
+	RealizedPnL       float64   `json:"realized_pnl"`
+	MarkPrice         *float64  `json:"mark_price,omitempty"`
+	UnrealizedPnL     *float64  `json:"unrealized_pnl,omitempty"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
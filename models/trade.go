@@ -12,6 +12,6 @@ type Trade struct {
 	SellOrderID uuid.UUID `json:"sell_order_id" db:"sell_order_id"`
 	Symbol      string    `json:"symbol" db:"symbol"`
 	Price       float64   `json:"price" db:"price"`
-	Quantity    int       `json:"quantity" db:"quantity"`
+	Quantity    float64   `json:"quantity" db:"quantity"`
 	ExecutedAt  time.Time `json:"executed_at" db:"executed_at"`
 }
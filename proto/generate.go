@@ -0,0 +1,5 @@
+// Package proto holds the protobuf definitions of the gRPC API. The
+// generated Go code lives in the omspb sub-package.
+package proto
+
+//go:generate protoc -I .. --go_out=.. --go_opt=module=github.com/bartick/golang-order-matching-system --go-grpc_out=.. --go-grpc_opt=module=github.com/bartick/golang-order-matching-system proto/orders.proto proto/marketdata.proto
@@ -0,0 +1,678 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: proto/marketdata.proto
+
+package omspb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type StreamTradesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Symbol        string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamTradesRequest) Reset() {
+	*x = StreamTradesRequest{}
+	mi := &file_proto_marketdata_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamTradesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamTradesRequest) ProtoMessage() {}
+
+func (x *StreamTradesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_marketdata_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamTradesRequest.ProtoReflect.Descriptor instead.
+func (*StreamTradesRequest) Descriptor() ([]byte, []int) {
+	return file_proto_marketdata_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *StreamTradesRequest) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+type StreamOrderBookRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Symbol        string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamOrderBookRequest) Reset() {
+	*x = StreamOrderBookRequest{}
+	mi := &file_proto_marketdata_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamOrderBookRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamOrderBookRequest) ProtoMessage() {}
+
+func (x *StreamOrderBookRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_marketdata_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamOrderBookRequest.ProtoReflect.Descriptor instead.
+func (*StreamOrderBookRequest) Descriptor() ([]byte, []int) {
+	return file_proto_marketdata_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *StreamOrderBookRequest) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+type StreamAuctionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Symbol        string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamAuctionRequest) Reset() {
+	*x = StreamAuctionRequest{}
+	mi := &file_proto_marketdata_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamAuctionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamAuctionRequest) ProtoMessage() {}
+
+func (x *StreamAuctionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_marketdata_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamAuctionRequest.ProtoReflect.Descriptor instead.
+func (*StreamAuctionRequest) Descriptor() ([]byte, []int) {
+	return file_proto_marketdata_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *StreamAuctionRequest) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+type StreamInstrumentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Symbol        string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamInstrumentRequest) Reset() {
+	*x = StreamInstrumentRequest{}
+	mi := &file_proto_marketdata_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamInstrumentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamInstrumentRequest) ProtoMessage() {}
+
+func (x *StreamInstrumentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_marketdata_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamInstrumentRequest.ProtoReflect.Descriptor instead.
+func (*StreamInstrumentRequest) Descriptor() ([]byte, []int) {
+	return file_proto_marketdata_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *StreamInstrumentRequest) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+type OrderBookLevel struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Price         float64                `protobuf:"fixed64,1,opt,name=price,proto3" json:"price,omitempty"`
+	OrderCount    int64                  `protobuf:"varint,3,opt,name=order_count,json=orderCount,proto3" json:"order_count,omitempty"`
+	TotalQuantity float64                `protobuf:"fixed64,4,opt,name=total_quantity,json=totalQuantity,proto3" json:"total_quantity,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderBookLevel) Reset() {
+	*x = OrderBookLevel{}
+	mi := &file_proto_marketdata_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderBookLevel) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderBookLevel) ProtoMessage() {}
+
+func (x *OrderBookLevel) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_marketdata_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderBookLevel.ProtoReflect.Descriptor instead.
+func (*OrderBookLevel) Descriptor() ([]byte, []int) {
+	return file_proto_marketdata_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *OrderBookLevel) GetPrice() float64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *OrderBookLevel) GetOrderCount() int64 {
+	if x != nil {
+		return x.OrderCount
+	}
+	return 0
+}
+
+func (x *OrderBookLevel) GetTotalQuantity() float64 {
+	if x != nil {
+		return x.TotalQuantity
+	}
+	return 0
+}
+
+type OrderBook struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Symbol        string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Bids          []*OrderBookLevel      `protobuf:"bytes,2,rep,name=bids,proto3" json:"bids,omitempty"`
+	Asks          []*OrderBookLevel      `protobuf:"bytes,3,rep,name=asks,proto3" json:"asks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderBook) Reset() {
+	*x = OrderBook{}
+	mi := &file_proto_marketdata_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderBook) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderBook) ProtoMessage() {}
+
+func (x *OrderBook) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_marketdata_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderBook.ProtoReflect.Descriptor instead.
+func (*OrderBook) Descriptor() ([]byte, []int) {
+	return file_proto_marketdata_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *OrderBook) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *OrderBook) GetBids() []*OrderBookLevel {
+	if x != nil {
+		return x.Bids
+	}
+	return nil
+}
+
+func (x *OrderBook) GetAsks() []*OrderBookLevel {
+	if x != nil {
+		return x.Asks
+	}
+	return nil
+}
+
+type AuctionState struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Symbol           string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Auction          string                 `protobuf:"bytes,2,opt,name=auction,proto3" json:"auction,omitempty"`
+	IndicativePrice  *float64               `protobuf:"fixed64,3,opt,name=indicative_price,json=indicativePrice,proto3,oneof" json:"indicative_price,omitempty"`
+	ImbalanceSide    string                 `protobuf:"bytes,6,opt,name=imbalance_side,json=imbalanceSide,proto3" json:"imbalance_side,omitempty"`
+	IndicativeVolume float64                `protobuf:"fixed64,7,opt,name=indicative_volume,json=indicativeVolume,proto3" json:"indicative_volume,omitempty"`
+	Imbalance        float64                `protobuf:"fixed64,8,opt,name=imbalance,proto3" json:"imbalance,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *AuctionState) Reset() {
+	*x = AuctionState{}
+	mi := &file_proto_marketdata_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuctionState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuctionState) ProtoMessage() {}
+
+func (x *AuctionState) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_marketdata_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuctionState.ProtoReflect.Descriptor instead.
+func (*AuctionState) Descriptor() ([]byte, []int) {
+	return file_proto_marketdata_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *AuctionState) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *AuctionState) GetAuction() string {
+	if x != nil {
+		return x.Auction
+	}
+	return ""
+}
+
+func (x *AuctionState) GetIndicativePrice() float64 {
+	if x != nil && x.IndicativePrice != nil {
+		return *x.IndicativePrice
+	}
+	return 0
+}
+
+func (x *AuctionState) GetImbalanceSide() string {
+	if x != nil {
+		return x.ImbalanceSide
+	}
+	return ""
+}
+
+func (x *AuctionState) GetIndicativeVolume() float64 {
+	if x != nil {
+		return x.IndicativeVolume
+	}
+	return 0
+}
+
+func (x *AuctionState) GetImbalance() float64 {
+	if x != nil {
+		return x.Imbalance
+	}
+	return 0
+}
+
+type Instrument struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Symbol    string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	State     string                 `protobuf:"bytes,2,opt,name=state,proto3" json:"state,omitempty"`
+	Auction   *string                `protobuf:"bytes,3,opt,name=auction,proto3,oneof" json:"auction,omitempty"`
+	Manual    bool                   `protobuf:"varint,4,opt,name=manual,proto3" json:"manual,omitempty"`
+	UpdatedAt *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	// The asset traded and the asset it is priced in, e.g. BTC and USD for
+	// BTC-USD, and the number of decimals of prices and quantities.
+	BaseAsset         string `protobuf:"bytes,6,opt,name=base_asset,json=baseAsset,proto3" json:"base_asset,omitempty"`
+	QuoteAsset        string `protobuf:"bytes,7,opt,name=quote_asset,json=quoteAsset,proto3" json:"quote_asset,omitempty"`
+	PricePrecision    int32  `protobuf:"varint,8,opt,name=price_precision,json=pricePrecision,proto3" json:"price_precision,omitempty"`
+	QuantityPrecision int32  `protobuf:"varint,9,opt,name=quantity_precision,json=quantityPrecision,proto3" json:"quantity_precision,omitempty"`
+	// "spot" or "future"; futures carry their contract terms.
+	Type               string                 `protobuf:"bytes,10,opt,name=type,proto3" json:"type,omitempty"`
+	ContractMultiplier *float64               `protobuf:"fixed64,11,opt,name=contract_multiplier,json=contractMultiplier,proto3,oneof" json:"contract_multiplier,omitempty"`
+	ExpiryDate         *timestamppb.Timestamp `protobuf:"bytes,12,opt,name=expiry_date,json=expiryDate,proto3" json:"expiry_date,omitempty"`
+	LastTradingDay     *timestamppb.Timestamp `protobuf:"bytes,13,opt,name=last_trading_day,json=lastTradingDay,proto3" json:"last_trading_day,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *Instrument) Reset() {
+	*x = Instrument{}
+	mi := &file_proto_marketdata_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Instrument) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Instrument) ProtoMessage() {}
+
+func (x *Instrument) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_marketdata_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Instrument.ProtoReflect.Descriptor instead.
+func (*Instrument) Descriptor() ([]byte, []int) {
+	return file_proto_marketdata_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Instrument) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *Instrument) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *Instrument) GetAuction() string {
+	if x != nil && x.Auction != nil {
+		return *x.Auction
+	}
+	return ""
+}
+
+func (x *Instrument) GetManual() bool {
+	if x != nil {
+		return x.Manual
+	}
+	return false
+}
+
+func (x *Instrument) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *Instrument) GetBaseAsset() string {
+	if x != nil {
+		return x.BaseAsset
+	}
+	return ""
+}
+
+func (x *Instrument) GetQuoteAsset() string {
+	if x != nil {
+		return x.QuoteAsset
+	}
+	return ""
+}
+
+func (x *Instrument) GetPricePrecision() int32 {
+	if x != nil {
+		return x.PricePrecision
+	}
+	return 0
+}
+
+func (x *Instrument) GetQuantityPrecision() int32 {
+	if x != nil {
+		return x.QuantityPrecision
+	}
+	return 0
+}
+
+func (x *Instrument) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Instrument) GetContractMultiplier() float64 {
+	if x != nil && x.ContractMultiplier != nil {
+		return *x.ContractMultiplier
+	}
+	return 0
+}
+
+func (x *Instrument) GetExpiryDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiryDate
+	}
+	return nil
+}
+
+func (x *Instrument) GetLastTradingDay() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastTradingDay
+	}
+	return nil
+}
+
+var File_proto_marketdata_proto protoreflect.FileDescriptor
+
+const file_proto_marketdata_proto_rawDesc = "" +
+	"\n" +
+	"\x16proto/marketdata.proto\x12\x06oms.v1\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x12proto/orders.proto\"-\n" +
+	"\x13StreamTradesRequest\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\"0\n" +
+	"\x16StreamOrderBookRequest\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\".\n" +
+	"\x14StreamAuctionRequest\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\"1\n" +
+	"\x17StreamInstrumentRequest\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\"t\n" +
+	"\x0eOrderBookLevel\x12\x14\n" +
+	"\x05price\x18\x01 \x01(\x01R\x05price\x12\x1f\n" +
+	"\vorder_count\x18\x03 \x01(\x03R\n" +
+	"orderCount\x12%\n" +
+	"\x0etotal_quantity\x18\x04 \x01(\x01R\rtotalQuantityJ\x04\b\x02\x10\x03\"{\n" +
+	"\tOrderBook\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\x12*\n" +
+	"\x04bids\x18\x02 \x03(\v2\x16.oms.v1.OrderBookLevelR\x04bids\x12*\n" +
+	"\x04asks\x18\x03 \x03(\v2\x16.oms.v1.OrderBookLevelR\x04asks\"\x83\x02\n" +
+	"\fAuctionState\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\x12\x18\n" +
+	"\aauction\x18\x02 \x01(\tR\aauction\x12.\n" +
+	"\x10indicative_price\x18\x03 \x01(\x01H\x00R\x0findicativePrice\x88\x01\x01\x12%\n" +
+	"\x0eimbalance_side\x18\x06 \x01(\tR\rimbalanceSide\x12+\n" +
+	"\x11indicative_volume\x18\a \x01(\x01R\x10indicativeVolume\x12\x1c\n" +
+	"\timbalance\x18\b \x01(\x01R\timbalanceB\x13\n" +
+	"\x11_indicative_priceJ\x04\b\x04\x10\x05J\x04\b\x05\x10\x06\"\xb5\x04\n" +
+	"\n" +
+	"Instrument\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\x12\x14\n" +
+	"\x05state\x18\x02 \x01(\tR\x05state\x12\x1d\n" +
+	"\aauction\x18\x03 \x01(\tH\x00R\aauction\x88\x01\x01\x12\x16\n" +
+	"\x06manual\x18\x04 \x01(\bR\x06manual\x129\n" +
+	"\n" +
+	"updated_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12\x1d\n" +
+	"\n" +
+	"base_asset\x18\x06 \x01(\tR\tbaseAsset\x12\x1f\n" +
+	"\vquote_asset\x18\a \x01(\tR\n" +
+	"quoteAsset\x12'\n" +
+	"\x0fprice_precision\x18\b \x01(\x05R\x0epricePrecision\x12-\n" +
+	"\x12quantity_precision\x18\t \x01(\x05R\x11quantityPrecision\x12\x12\n" +
+	"\x04type\x18\n" +
+	" \x01(\tR\x04type\x124\n" +
+	"\x13contract_multiplier\x18\v \x01(\x01H\x01R\x12contractMultiplier\x88\x01\x01\x12;\n" +
+	"\vexpiry_date\x18\f \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"expiryDate\x12D\n" +
+	"\x10last_trading_day\x18\r \x01(\v2\x1a.google.protobuf.TimestampR\x0elastTradingDayB\n" +
+	"\n" +
+	"\b_auctionB\x16\n" +
+	"\x14_contract_multiplier2\xab\x02\n" +
+	"\x11MarketDataService\x12<\n" +
+	"\fStreamTrades\x12\x1b.oms.v1.StreamTradesRequest\x1a\r.oms.v1.Trade0\x01\x12F\n" +
+	"\x0fStreamOrderBook\x12\x1e.oms.v1.StreamOrderBookRequest\x1a\x11.oms.v1.OrderBook0\x01\x12E\n" +
+	"\rStreamAuction\x12\x1c.oms.v1.StreamAuctionRequest\x1a\x14.oms.v1.AuctionState0\x01\x12I\n" +
+	"\x10StreamInstrument\x12\x1f.oms.v1.StreamInstrumentRequest\x1a\x12.oms.v1.Instrument0\x01BCZAgithub.com/bartick/golang-order-matching-system/proto/omspb;omspbb\x06proto3"
+
+var (
+	file_proto_marketdata_proto_rawDescOnce sync.Once
+	file_proto_marketdata_proto_rawDescData []byte
+)
+
+func file_proto_marketdata_proto_rawDescGZIP() []byte {
+	file_proto_marketdata_proto_rawDescOnce.Do(func() {
+		file_proto_marketdata_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_marketdata_proto_rawDesc), len(file_proto_marketdata_proto_rawDesc)))
+	})
+	return file_proto_marketdata_proto_rawDescData
+}
+
+var file_proto_marketdata_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_proto_marketdata_proto_goTypes = []any{
+	(*StreamTradesRequest)(nil),     // 0: oms.v1.StreamTradesRequest
+	(*StreamOrderBookRequest)(nil),  // 1: oms.v1.StreamOrderBookRequest
+	(*StreamAuctionRequest)(nil),    // 2: oms.v1.StreamAuctionRequest
+	(*StreamInstrumentRequest)(nil), // 3: oms.v1.StreamInstrumentRequest
+	(*OrderBookLevel)(nil),          // 4: oms.v1.OrderBookLevel
+	(*OrderBook)(nil),               // 5: oms.v1.OrderBook
+	(*AuctionState)(nil),            // 6: oms.v1.AuctionState
+	(*Instrument)(nil),              // 7: oms.v1.Instrument
+	(*timestamppb.Timestamp)(nil),   // 8: google.protobuf.Timestamp
+	(*Trade)(nil),                   // 9: oms.v1.Trade
+}
+var file_proto_marketdata_proto_depIdxs = []int32{
+	4, // 0: oms.v1.OrderBook.bids:type_name -> oms.v1.OrderBookLevel
+	4, // 1: oms.v1.OrderBook.asks:type_name -> oms.v1.OrderBookLevel
+	8, // 2: oms.v1.Instrument.updated_at:type_name -> google.protobuf.Timestamp
+	8, // 3: oms.v1.Instrument.expiry_date:type_name -> google.protobuf.Timestamp
+	8, // 4: oms.v1.Instrument.last_trading_day:type_name -> google.protobuf.Timestamp
+	0, // 5: oms.v1.MarketDataService.StreamTrades:input_type -> oms.v1.StreamTradesRequest
+	1, // 6: oms.v1.MarketDataService.StreamOrderBook:input_type -> oms.v1.StreamOrderBookRequest
+	2, // 7: oms.v1.MarketDataService.StreamAuction:input_type -> oms.v1.StreamAuctionRequest
+	3, // 8: oms.v1.MarketDataService.StreamInstrument:input_type -> oms.v1.StreamInstrumentRequest
+	9, // 9: oms.v1.MarketDataService.StreamTrades:output_type -> oms.v1.Trade
+	5, // 10: oms.v1.MarketDataService.StreamOrderBook:output_type -> oms.v1.OrderBook
+	6, // 11: oms.v1.MarketDataService.StreamAuction:output_type -> oms.v1.AuctionState
+	7, // 12: oms.v1.MarketDataService.StreamInstrument:output_type -> oms.v1.Instrument
+	9, // [9:13] is the sub-list for method output_type
+	5, // [5:9] is the sub-list for method input_type
+	5, // [5:5] is the sub-list for extension type_name
+	5, // [5:5] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_proto_marketdata_proto_init() }
+func file_proto_marketdata_proto_init() {
+	if File_proto_marketdata_proto != nil {
+		return
+	}
+	file_proto_orders_proto_init()
+	file_proto_marketdata_proto_msgTypes[6].OneofWrappers = []any{}
+	file_proto_marketdata_proto_msgTypes[7].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_marketdata_proto_rawDesc), len(file_proto_marketdata_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_proto_marketdata_proto_goTypes,
+		DependencyIndexes: file_proto_marketdata_proto_depIdxs,
+		MessageInfos:      file_proto_marketdata_proto_msgTypes,
+	}.Build()
+	File_proto_marketdata_proto = out.File
+	file_proto_marketdata_proto_goTypes = nil
+	file_proto_marketdata_proto_depIdxs = nil
+}
@@ -0,0 +1,1154 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: proto/orders.proto
+
+package omspb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Order struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Id     string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Symbol string                 `protobuf:"bytes,2,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Side   string                 `protobuf:"bytes,3,opt,name=side,proto3" json:"side,omitempty"`
+	Type   string                 `protobuf:"bytes,4,opt,name=type,proto3" json:"type,omitempty"`
+	// Unset for market orders.
+	Price     *float64               `protobuf:"fixed64,5,opt,name=price,proto3,oneof" json:"price,omitempty"`
+	Status    string                 `protobuf:"bytes,8,opt,name=status,proto3" json:"status,omitempty"`
+	CreatedAt *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	PostOnly  bool                   `protobuf:"varint,13,opt,name=post_only,json=postOnly,proto3" json:"post_only,omitempty"`
+	Hidden    bool                   `protobuf:"varint,14,opt,name=hidden,proto3" json:"hidden,omitempty"`
+	// Current trigger price of stop orders; trailing stops move it.
+	StopPrice    *float64 `protobuf:"fixed64,15,opt,name=stop_price,json=stopPrice,proto3,oneof" json:"stop_price,omitempty"`
+	TrailAmount  *float64 `protobuf:"fixed64,16,opt,name=trail_amount,json=trailAmount,proto3,oneof" json:"trail_amount,omitempty"`
+	TrailPercent *float64 `protobuf:"fixed64,17,opt,name=trail_percent,json=trailPercent,proto3,oneof" json:"trail_percent,omitempty"`
+	// Set for peg orders, whose price is the current pegged price.
+	PegReference  *string  `protobuf:"bytes,18,opt,name=peg_reference,json=pegReference,proto3,oneof" json:"peg_reference,omitempty"`
+	PegOffset     *float64 `protobuf:"fixed64,19,opt,name=peg_offset,json=pegOffset,proto3,oneof" json:"peg_offset,omitempty"`
+	PegLimitPrice *float64 `protobuf:"fixed64,20,opt,name=peg_limit_price,json=pegLimitPrice,proto3,oneof" json:"peg_limit_price,omitempty"`
+	// Quantities are fractional; the integer fields they replace are
+	// reserved.
+	InitialQuantity   float64 `protobuf:"fixed64,21,opt,name=initial_quantity,json=initialQuantity,proto3" json:"initial_quantity,omitempty"`
+	RemainingQuantity float64 `protobuf:"fixed64,22,opt,name=remaining_quantity,json=remainingQuantity,proto3" json:"remaining_quantity,omitempty"`
+	// Set for iceberg orders only.
+	DisplayQuantity *float64 `protobuf:"fixed64,23,opt,name=display_quantity,json=displayQuantity,proto3,oneof" json:"display_quantity,omitempty"`
+	VisibleQuantity *float64 `protobuf:"fixed64,24,opt,name=visible_quantity,json=visibleQuantity,proto3,oneof" json:"visible_quantity,omitempty"`
+	// Set for the orders that close the positions of a liquidated account.
+	Liquidation   bool `protobuf:"varint,25,opt,name=liquidation,proto3" json:"liquidation,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Order) Reset() {
+	*x = Order{}
+	mi := &file_proto_orders_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Order) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Order) ProtoMessage() {}
+
+func (x *Order) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_orders_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Order.ProtoReflect.Descriptor instead.
+func (*Order) Descriptor() ([]byte, []int) {
+	return file_proto_orders_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Order) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Order) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *Order) GetSide() string {
+	if x != nil {
+		return x.Side
+	}
+	return ""
+}
+
+func (x *Order) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Order) GetPrice() float64 {
+	if x != nil && x.Price != nil {
+		return *x.Price
+	}
+	return 0
+}
+
+func (x *Order) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Order) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Order) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *Order) GetPostOnly() bool {
+	if x != nil {
+		return x.PostOnly
+	}
+	return false
+}
+
+func (x *Order) GetHidden() bool {
+	if x != nil {
+		return x.Hidden
+	}
+	return false
+}
+
+func (x *Order) GetStopPrice() float64 {
+	if x != nil && x.StopPrice != nil {
+		return *x.StopPrice
+	}
+	return 0
+}
+
+func (x *Order) GetTrailAmount() float64 {
+	if x != nil && x.TrailAmount != nil {
+		return *x.TrailAmount
+	}
+	return 0
+}
+
+func (x *Order) GetTrailPercent() float64 {
+	if x != nil && x.TrailPercent != nil {
+		return *x.TrailPercent
+	}
+	return 0
+}
+
+func (x *Order) GetPegReference() string {
+	if x != nil && x.PegReference != nil {
+		return *x.PegReference
+	}
+	return ""
+}
+
+func (x *Order) GetPegOffset() float64 {
+	if x != nil && x.PegOffset != nil {
+		return *x.PegOffset
+	}
+	return 0
+}
+
+func (x *Order) GetPegLimitPrice() float64 {
+	if x != nil && x.PegLimitPrice != nil {
+		return *x.PegLimitPrice
+	}
+	return 0
+}
+
+func (x *Order) GetInitialQuantity() float64 {
+	if x != nil {
+		return x.InitialQuantity
+	}
+	return 0
+}
+
+func (x *Order) GetRemainingQuantity() float64 {
+	if x != nil {
+		return x.RemainingQuantity
+	}
+	return 0
+}
+
+func (x *Order) GetDisplayQuantity() float64 {
+	if x != nil && x.DisplayQuantity != nil {
+		return *x.DisplayQuantity
+	}
+	return 0
+}
+
+func (x *Order) GetVisibleQuantity() float64 {
+	if x != nil && x.VisibleQuantity != nil {
+		return *x.VisibleQuantity
+	}
+	return 0
+}
+
+func (x *Order) GetLiquidation() bool {
+	if x != nil {
+		return x.Liquidation
+	}
+	return false
+}
+
+type Trade struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	BuyOrderId    string                 `protobuf:"bytes,2,opt,name=buy_order_id,json=buyOrderId,proto3" json:"buy_order_id,omitempty"`
+	SellOrderId   string                 `protobuf:"bytes,3,opt,name=sell_order_id,json=sellOrderId,proto3" json:"sell_order_id,omitempty"`
+	Symbol        string                 `protobuf:"bytes,4,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Price         float64                `protobuf:"fixed64,5,opt,name=price,proto3" json:"price,omitempty"`
+	ExecutedAt    *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=executed_at,json=executedAt,proto3" json:"executed_at,omitempty"`
+	Quantity      float64                `protobuf:"fixed64,8,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Trade) Reset() {
+	*x = Trade{}
+	mi := &file_proto_orders_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Trade) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Trade) ProtoMessage() {}
+
+func (x *Trade) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_orders_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Trade.ProtoReflect.Descriptor instead.
+func (*Trade) Descriptor() ([]byte, []int) {
+	return file_proto_orders_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Trade) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Trade) GetBuyOrderId() string {
+	if x != nil {
+		return x.BuyOrderId
+	}
+	return ""
+}
+
+func (x *Trade) GetSellOrderId() string {
+	if x != nil {
+		return x.SellOrderId
+	}
+	return ""
+}
+
+func (x *Trade) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *Trade) GetPrice() float64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *Trade) GetExecutedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExecutedAt
+	}
+	return nil
+}
+
+func (x *Trade) GetQuantity() float64 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+type PlaceOrderRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Symbol string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Side   string                 `protobuf:"bytes,2,opt,name=side,proto3" json:"side,omitempty"`
+	Type   string                 `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
+	Price  *float64               `protobuf:"fixed64,4,opt,name=price,proto3,oneof" json:"price,omitempty"`
+	// Rejects the order if it would cross the book, or with
+	// post_only_reprice moves it one tick behind the best opposite price.
+	PostOnly        bool `protobuf:"varint,7,opt,name=post_only,json=postOnly,proto3" json:"post_only,omitempty"`
+	PostOnlyReprice bool `protobuf:"varint,8,opt,name=post_only_reprice,json=postOnlyReprice,proto3" json:"post_only_reprice,omitempty"`
+	// Rests without appearing in the order book.
+	Hidden bool `protobuf:"varint,9,opt,name=hidden,proto3" json:"hidden,omitempty"`
+	// Trigger price of stop and stop_limit orders. Trailing stops set
+	// trail_amount or trail_percent and may leave stop_price unset to start
+	// from the last trade price.
+	StopPrice    *float64 `protobuf:"fixed64,10,opt,name=stop_price,json=stopPrice,proto3,oneof" json:"stop_price,omitempty"`
+	TrailAmount  *float64 `protobuf:"fixed64,11,opt,name=trail_amount,json=trailAmount,proto3,oneof" json:"trail_amount,omitempty"`
+	TrailPercent *float64 `protobuf:"fixed64,12,opt,name=trail_percent,json=trailPercent,proto3,oneof" json:"trail_percent,omitempty"`
+	// Required by peg orders: "bid", "ask" or "mid". The price follows the
+	// reference plus peg_offset, capped by peg_limit_price.
+	PegReference  string   `protobuf:"bytes,13,opt,name=peg_reference,json=pegReference,proto3" json:"peg_reference,omitempty"`
+	PegOffset     *float64 `protobuf:"fixed64,14,opt,name=peg_offset,json=pegOffset,proto3,oneof" json:"peg_offset,omitempty"`
+	PegLimitPrice *float64 `protobuf:"fixed64,15,opt,name=peg_limit_price,json=pegLimitPrice,proto3,oneof" json:"peg_limit_price,omitempty"`
+	Quantity      float64  `protobuf:"fixed64,16,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	// Makes a limit order an iceberg order showing at most this quantity.
+	DisplayQuantity *float64 `protobuf:"fixed64,17,opt,name=display_quantity,json=displayQuantity,proto3,oneof" json:"display_quantity,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *PlaceOrderRequest) Reset() {
+	*x = PlaceOrderRequest{}
+	mi := &file_proto_orders_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlaceOrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlaceOrderRequest) ProtoMessage() {}
+
+func (x *PlaceOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_orders_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlaceOrderRequest.ProtoReflect.Descriptor instead.
+func (*PlaceOrderRequest) Descriptor() ([]byte, []int) {
+	return file_proto_orders_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *PlaceOrderRequest) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *PlaceOrderRequest) GetSide() string {
+	if x != nil {
+		return x.Side
+	}
+	return ""
+}
+
+func (x *PlaceOrderRequest) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *PlaceOrderRequest) GetPrice() float64 {
+	if x != nil && x.Price != nil {
+		return *x.Price
+	}
+	return 0
+}
+
+func (x *PlaceOrderRequest) GetPostOnly() bool {
+	if x != nil {
+		return x.PostOnly
+	}
+	return false
+}
+
+func (x *PlaceOrderRequest) GetPostOnlyReprice() bool {
+	if x != nil {
+		return x.PostOnlyReprice
+	}
+	return false
+}
+
+func (x *PlaceOrderRequest) GetHidden() bool {
+	if x != nil {
+		return x.Hidden
+	}
+	return false
+}
+
+func (x *PlaceOrderRequest) GetStopPrice() float64 {
+	if x != nil && x.StopPrice != nil {
+		return *x.StopPrice
+	}
+	return 0
+}
+
+func (x *PlaceOrderRequest) GetTrailAmount() float64 {
+	if x != nil && x.TrailAmount != nil {
+		return *x.TrailAmount
+	}
+	return 0
+}
+
+func (x *PlaceOrderRequest) GetTrailPercent() float64 {
+	if x != nil && x.TrailPercent != nil {
+		return *x.TrailPercent
+	}
+	return 0
+}
+
+func (x *PlaceOrderRequest) GetPegReference() string {
+	if x != nil {
+		return x.PegReference
+	}
+	return ""
+}
+
+func (x *PlaceOrderRequest) GetPegOffset() float64 {
+	if x != nil && x.PegOffset != nil {
+		return *x.PegOffset
+	}
+	return 0
+}
+
+func (x *PlaceOrderRequest) GetPegLimitPrice() float64 {
+	if x != nil && x.PegLimitPrice != nil {
+		return *x.PegLimitPrice
+	}
+	return 0
+}
+
+func (x *PlaceOrderRequest) GetQuantity() float64 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+func (x *PlaceOrderRequest) GetDisplayQuantity() float64 {
+	if x != nil && x.DisplayQuantity != nil {
+		return *x.DisplayQuantity
+	}
+	return 0
+}
+
+type PlaceOrderResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Order         *Order                 `protobuf:"bytes,1,opt,name=order,proto3" json:"order,omitempty"`
+	Trades        []*Trade               `protobuf:"bytes,2,rep,name=trades,proto3" json:"trades,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PlaceOrderResponse) Reset() {
+	*x = PlaceOrderResponse{}
+	mi := &file_proto_orders_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlaceOrderResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlaceOrderResponse) ProtoMessage() {}
+
+func (x *PlaceOrderResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_orders_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlaceOrderResponse.ProtoReflect.Descriptor instead.
+func (*PlaceOrderResponse) Descriptor() ([]byte, []int) {
+	return file_proto_orders_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *PlaceOrderResponse) GetOrder() *Order {
+	if x != nil {
+		return x.Order
+	}
+	return nil
+}
+
+func (x *PlaceOrderResponse) GetTrades() []*Trade {
+	if x != nil {
+		return x.Trades
+	}
+	return nil
+}
+
+type CancelOrderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderId       string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelOrderRequest) Reset() {
+	*x = CancelOrderRequest{}
+	mi := &file_proto_orders_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelOrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelOrderRequest) ProtoMessage() {}
+
+func (x *CancelOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_orders_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelOrderRequest.ProtoReflect.Descriptor instead.
+func (*CancelOrderRequest) Descriptor() ([]byte, []int) {
+	return file_proto_orders_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CancelOrderRequest) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+type CancelOrderResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Order         *Order                 `protobuf:"bytes,1,opt,name=order,proto3" json:"order,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelOrderResponse) Reset() {
+	*x = CancelOrderResponse{}
+	mi := &file_proto_orders_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelOrderResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelOrderResponse) ProtoMessage() {}
+
+func (x *CancelOrderResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_orders_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelOrderResponse.ProtoReflect.Descriptor instead.
+func (*CancelOrderResponse) Descriptor() ([]byte, []int) {
+	return file_proto_orders_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CancelOrderResponse) GetOrder() *Order {
+	if x != nil {
+		return x.Order
+	}
+	return nil
+}
+
+// AmendOrderRequest changes the price and/or the total quantity of a
+// resting limit order, and optionally its client order ID. Unset fields are
+// left untouched.
+type AmendOrderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderId       string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	Price         *float64               `protobuf:"fixed64,2,opt,name=price,proto3,oneof" json:"price,omitempty"`
+	Quantity      *float64               `protobuf:"fixed64,4,opt,name=quantity,proto3,oneof" json:"quantity,omitempty"`
+	ClientOrderId *string                `protobuf:"bytes,5,opt,name=client_order_id,json=clientOrderId,proto3,oneof" json:"client_order_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AmendOrderRequest) Reset() {
+	*x = AmendOrderRequest{}
+	mi := &file_proto_orders_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AmendOrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AmendOrderRequest) ProtoMessage() {}
+
+func (x *AmendOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_orders_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AmendOrderRequest.ProtoReflect.Descriptor instead.
+func (*AmendOrderRequest) Descriptor() ([]byte, []int) {
+	return file_proto_orders_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *AmendOrderRequest) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *AmendOrderRequest) GetPrice() float64 {
+	if x != nil && x.Price != nil {
+		return *x.Price
+	}
+	return 0
+}
+
+func (x *AmendOrderRequest) GetQuantity() float64 {
+	if x != nil && x.Quantity != nil {
+		return *x.Quantity
+	}
+	return 0
+}
+
+func (x *AmendOrderRequest) GetClientOrderId() string {
+	if x != nil && x.ClientOrderId != nil {
+		return *x.ClientOrderId
+	}
+	return ""
+}
+
+type AmendOrderResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Order         *Order                 `protobuf:"bytes,1,opt,name=order,proto3" json:"order,omitempty"`
+	Trades        []*Trade               `protobuf:"bytes,2,rep,name=trades,proto3" json:"trades,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AmendOrderResponse) Reset() {
+	*x = AmendOrderResponse{}
+	mi := &file_proto_orders_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AmendOrderResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AmendOrderResponse) ProtoMessage() {}
+
+func (x *AmendOrderResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_orders_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AmendOrderResponse.ProtoReflect.Descriptor instead.
+func (*AmendOrderResponse) Descriptor() ([]byte, []int) {
+	return file_proto_orders_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *AmendOrderResponse) GetOrder() *Order {
+	if x != nil {
+		return x.Order
+	}
+	return nil
+}
+
+func (x *AmendOrderResponse) GetTrades() []*Trade {
+	if x != nil {
+		return x.Trades
+	}
+	return nil
+}
+
+type GetOrderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderId       string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetOrderRequest) Reset() {
+	*x = GetOrderRequest{}
+	mi := &file_proto_orders_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetOrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetOrderRequest) ProtoMessage() {}
+
+func (x *GetOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_orders_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetOrderRequest.ProtoReflect.Descriptor instead.
+func (*GetOrderRequest) Descriptor() ([]byte, []int) {
+	return file_proto_orders_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetOrderRequest) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+type GetOrderResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Order         *Order                 `protobuf:"bytes,1,opt,name=order,proto3" json:"order,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetOrderResponse) Reset() {
+	*x = GetOrderResponse{}
+	mi := &file_proto_orders_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetOrderResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetOrderResponse) ProtoMessage() {}
+
+func (x *GetOrderResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_orders_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetOrderResponse.ProtoReflect.Descriptor instead.
+func (*GetOrderResponse) Descriptor() ([]byte, []int) {
+	return file_proto_orders_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *GetOrderResponse) GetOrder() *Order {
+	if x != nil {
+		return x.Order
+	}
+	return nil
+}
+
+// Empty filters match every order. Orders are returned newest first.
+type ListOrdersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Symbol        string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Side          string                 `protobuf:"bytes,2,opt,name=side,proto3" json:"side,omitempty"`
+	Status        string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	Limit         int32                  `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListOrdersRequest) Reset() {
+	*x = ListOrdersRequest{}
+	mi := &file_proto_orders_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListOrdersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListOrdersRequest) ProtoMessage() {}
+
+func (x *ListOrdersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_orders_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListOrdersRequest.ProtoReflect.Descriptor instead.
+func (*ListOrdersRequest) Descriptor() ([]byte, []int) {
+	return file_proto_orders_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ListOrdersRequest) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *ListOrdersRequest) GetSide() string {
+	if x != nil {
+		return x.Side
+	}
+	return ""
+}
+
+func (x *ListOrdersRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *ListOrdersRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type ListOrdersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Orders        []*Order               `protobuf:"bytes,1,rep,name=orders,proto3" json:"orders,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListOrdersResponse) Reset() {
+	*x = ListOrdersResponse{}
+	mi := &file_proto_orders_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListOrdersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListOrdersResponse) ProtoMessage() {}
+
+func (x *ListOrdersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_orders_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListOrdersResponse.ProtoReflect.Descriptor instead.
+func (*ListOrdersResponse) Descriptor() ([]byte, []int) {
+	return file_proto_orders_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ListOrdersResponse) GetOrders() []*Order {
+	if x != nil {
+		return x.Orders
+	}
+	return nil
+}
+
+var File_proto_orders_proto protoreflect.FileDescriptor
+
+const file_proto_orders_proto_rawDesc = "" +
+	"\n" +
+	"\x12proto/orders.proto\x12\x06oms.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xb5\a\n" +
+	"\x05Order\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06symbol\x18\x02 \x01(\tR\x06symbol\x12\x12\n" +
+	"\x04side\x18\x03 \x01(\tR\x04side\x12\x12\n" +
+	"\x04type\x18\x04 \x01(\tR\x04type\x12\x19\n" +
+	"\x05price\x18\x05 \x01(\x01H\x00R\x05price\x88\x01\x01\x12\x16\n" +
+	"\x06status\x18\b \x01(\tR\x06status\x129\n" +
+	"\n" +
+	"created_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12\x1b\n" +
+	"\tpost_only\x18\r \x01(\bR\bpostOnly\x12\x16\n" +
+	"\x06hidden\x18\x0e \x01(\bR\x06hidden\x12\"\n" +
+	"\n" +
+	"stop_price\x18\x0f \x01(\x01H\x01R\tstopPrice\x88\x01\x01\x12&\n" +
+	"\ftrail_amount\x18\x10 \x01(\x01H\x02R\vtrailAmount\x88\x01\x01\x12(\n" +
+	"\rtrail_percent\x18\x11 \x01(\x01H\x03R\ftrailPercent\x88\x01\x01\x12(\n" +
+	"\rpeg_reference\x18\x12 \x01(\tH\x04R\fpegReference\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"peg_offset\x18\x13 \x01(\x01H\x05R\tpegOffset\x88\x01\x01\x12+\n" +
+	"\x0fpeg_limit_price\x18\x14 \x01(\x01H\x06R\rpegLimitPrice\x88\x01\x01\x12)\n" +
+	"\x10initial_quantity\x18\x15 \x01(\x01R\x0finitialQuantity\x12-\n" +
+	"\x12remaining_quantity\x18\x16 \x01(\x01R\x11remainingQuantity\x12.\n" +
+	"\x10display_quantity\x18\x17 \x01(\x01H\aR\x0fdisplayQuantity\x88\x01\x01\x12.\n" +
+	"\x10visible_quantity\x18\x18 \x01(\x01H\bR\x0fvisibleQuantity\x88\x01\x01\x12 \n" +
+	"\vliquidation\x18\x19 \x01(\bR\vliquidationB\b\n" +
+	"\x06_priceB\r\n" +
+	"\v_stop_priceB\x0f\n" +
+	"\r_trail_amountB\x10\n" +
+	"\x0e_trail_percentB\x10\n" +
+	"\x0e_peg_referenceB\r\n" +
+	"\v_peg_offsetB\x12\n" +
+	"\x10_peg_limit_priceB\x13\n" +
+	"\x11_display_quantityB\x13\n" +
+	"\x11_visible_quantityJ\x04\b\x06\x10\aJ\x04\b\a\x10\bJ\x04\b\v\x10\fJ\x04\b\f\x10\r\"\xea\x01\n" +
+	"\x05Trade\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12 \n" +
+	"\fbuy_order_id\x18\x02 \x01(\tR\n" +
+	"buyOrderId\x12\"\n" +
+	"\rsell_order_id\x18\x03 \x01(\tR\vsellOrderId\x12\x16\n" +
+	"\x06symbol\x18\x04 \x01(\tR\x06symbol\x12\x14\n" +
+	"\x05price\x18\x05 \x01(\x01R\x05price\x12;\n" +
+	"\vexecuted_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"executedAt\x12\x1a\n" +
+	"\bquantity\x18\b \x01(\x01R\bquantityJ\x04\b\x06\x10\a\"\x87\x05\n" +
+	"\x11PlaceOrderRequest\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\x12\x12\n" +
+	"\x04side\x18\x02 \x01(\tR\x04side\x12\x12\n" +
+	"\x04type\x18\x03 \x01(\tR\x04type\x12\x19\n" +
+	"\x05price\x18\x04 \x01(\x01H\x00R\x05price\x88\x01\x01\x12\x1b\n" +
+	"\tpost_only\x18\a \x01(\bR\bpostOnly\x12*\n" +
+	"\x11post_only_reprice\x18\b \x01(\bR\x0fpostOnlyReprice\x12\x16\n" +
+	"\x06hidden\x18\t \x01(\bR\x06hidden\x12\"\n" +
+	"\n" +
+	"stop_price\x18\n" +
+	" \x01(\x01H\x01R\tstopPrice\x88\x01\x01\x12&\n" +
+	"\ftrail_amount\x18\v \x01(\x01H\x02R\vtrailAmount\x88\x01\x01\x12(\n" +
+	"\rtrail_percent\x18\f \x01(\x01H\x03R\ftrailPercent\x88\x01\x01\x12#\n" +
+	"\rpeg_reference\x18\r \x01(\tR\fpegReference\x12\"\n" +
+	"\n" +
+	"peg_offset\x18\x0e \x01(\x01H\x04R\tpegOffset\x88\x01\x01\x12+\n" +
+	"\x0fpeg_limit_price\x18\x0f \x01(\x01H\x05R\rpegLimitPrice\x88\x01\x01\x12\x1a\n" +
+	"\bquantity\x18\x10 \x01(\x01R\bquantity\x12.\n" +
+	"\x10display_quantity\x18\x11 \x01(\x01H\x06R\x0fdisplayQuantity\x88\x01\x01B\b\n" +
+	"\x06_priceB\r\n" +
+	"\v_stop_priceB\x0f\n" +
+	"\r_trail_amountB\x10\n" +
+	"\x0e_trail_percentB\r\n" +
+	"\v_peg_offsetB\x12\n" +
+	"\x10_peg_limit_priceB\x13\n" +
+	"\x11_display_quantityJ\x04\b\x05\x10\x06J\x04\b\x06\x10\a\"`\n" +
+	"\x12PlaceOrderResponse\x12#\n" +
+	"\x05order\x18\x01 \x01(\v2\r.oms.v1.OrderR\x05order\x12%\n" +
+	"\x06trades\x18\x02 \x03(\v2\r.oms.v1.TradeR\x06trades\"/\n" +
+	"\x12CancelOrderRequest\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\tR\aorderId\":\n" +
+	"\x13CancelOrderResponse\x12#\n" +
+	"\x05order\x18\x01 \x01(\v2\r.oms.v1.OrderR\x05order\"\xc8\x01\n" +
+	"\x11AmendOrderRequest\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\tR\aorderId\x12\x19\n" +
+	"\x05price\x18\x02 \x01(\x01H\x00R\x05price\x88\x01\x01\x12\x1f\n" +
+	"\bquantity\x18\x04 \x01(\x01H\x01R\bquantity\x88\x01\x01\x12+\n" +
+	"\x0fclient_order_id\x18\x05 \x01(\tH\x02R\rclientOrderId\x88\x01\x01B\b\n" +
+	"\x06_priceB\v\n" +
+	"\t_quantityB\x12\n" +
+	"\x10_client_order_idJ\x04\b\x03\x10\x04\"`\n" +
+	"\x12AmendOrderResponse\x12#\n" +
+	"\x05order\x18\x01 \x01(\v2\r.oms.v1.OrderR\x05order\x12%\n" +
+	"\x06trades\x18\x02 \x03(\v2\r.oms.v1.TradeR\x06trades\",\n" +
+	"\x0fGetOrderRequest\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\tR\aorderId\"7\n" +
+	"\x10GetOrderResponse\x12#\n" +
+	"\x05order\x18\x01 \x01(\v2\r.oms.v1.OrderR\x05order\"m\n" +
+	"\x11ListOrdersRequest\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\x12\x12\n" +
+	"\x04side\x18\x02 \x01(\tR\x04side\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12\x14\n" +
+	"\x05limit\x18\x04 \x01(\x05R\x05limit\";\n" +
+	"\x12ListOrdersResponse\x12%\n" +
+	"\x06orders\x18\x01 \x03(\v2\r.oms.v1.OrderR\x06orders2\xe4\x02\n" +
+	"\fOrderService\x12C\n" +
+	"\n" +
+	"PlaceOrder\x12\x19.oms.v1.PlaceOrderRequest\x1a\x1a.oms.v1.PlaceOrderResponse\x12F\n" +
+	"\vCancelOrder\x12\x1a.oms.v1.CancelOrderRequest\x1a\x1b.oms.v1.CancelOrderResponse\x12C\n" +
+	"\n" +
+	"AmendOrder\x12\x19.oms.v1.AmendOrderRequest\x1a\x1a.oms.v1.AmendOrderResponse\x12=\n" +
+	"\bGetOrder\x12\x17.oms.v1.GetOrderRequest\x1a\x18.oms.v1.GetOrderResponse\x12C\n" +
+	"\n" +
+	"ListOrders\x12\x19.oms.v1.ListOrdersRequest\x1a\x1a.oms.v1.ListOrdersResponseBCZAgithub.com/bartick/golang-order-matching-system/proto/omspb;omspbb\x06proto3"
+
+var (
+	file_proto_orders_proto_rawDescOnce sync.Once
+	file_proto_orders_proto_rawDescData []byte
+)
+
+func file_proto_orders_proto_rawDescGZIP() []byte {
+	file_proto_orders_proto_rawDescOnce.Do(func() {
+		file_proto_orders_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_orders_proto_rawDesc), len(file_proto_orders_proto_rawDesc)))
+	})
+	return file_proto_orders_proto_rawDescData
+}
+
+var file_proto_orders_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_proto_orders_proto_goTypes = []any{
+	(*Order)(nil),                 // 0: oms.v1.Order
+	(*Trade)(nil),                 // 1: oms.v1.Trade
+	(*PlaceOrderRequest)(nil),     // 2: oms.v1.PlaceOrderRequest
+	(*PlaceOrderResponse)(nil),    // 3: oms.v1.PlaceOrderResponse
+	(*CancelOrderRequest)(nil),    // 4: oms.v1.CancelOrderRequest
+	(*CancelOrderResponse)(nil),   // 5: oms.v1.CancelOrderResponse
+	(*AmendOrderRequest)(nil),     // 6: oms.v1.AmendOrderRequest
+	(*AmendOrderResponse)(nil),    // 7: oms.v1.AmendOrderResponse
+	(*GetOrderRequest)(nil),       // 8: oms.v1.GetOrderRequest
+	(*GetOrderResponse)(nil),      // 9: oms.v1.GetOrderResponse
+	(*ListOrdersRequest)(nil),     // 10: oms.v1.ListOrdersRequest
+	(*ListOrdersResponse)(nil),    // 11: oms.v1.ListOrdersResponse
+	(*timestamppb.Timestamp)(nil), // 12: google.protobuf.Timestamp
+}
+var file_proto_orders_proto_depIdxs = []int32{
+	12, // 0: oms.v1.Order.created_at:type_name -> google.protobuf.Timestamp
+	12, // 1: oms.v1.Order.updated_at:type_name -> google.protobuf.Timestamp
+	12, // 2: oms.v1.Trade.executed_at:type_name -> google.protobuf.Timestamp
+	0,  // 3: oms.v1.PlaceOrderResponse.order:type_name -> oms.v1.Order
+	1,  // 4: oms.v1.PlaceOrderResponse.trades:type_name -> oms.v1.Trade
+	0,  // 5: oms.v1.CancelOrderResponse.order:type_name -> oms.v1.Order
+	0,  // 6: oms.v1.AmendOrderResponse.order:type_name -> oms.v1.Order
+	1,  // 7: oms.v1.AmendOrderResponse.trades:type_name -> oms.v1.Trade
+	0,  // 8: oms.v1.GetOrderResponse.order:type_name -> oms.v1.Order
+	0,  // 9: oms.v1.ListOrdersResponse.orders:type_name -> oms.v1.Order
+	2,  // 10: oms.v1.OrderService.PlaceOrder:input_type -> oms.v1.PlaceOrderRequest
+	4,  // 11: oms.v1.OrderService.CancelOrder:input_type -> oms.v1.CancelOrderRequest
+	6,  // 12: oms.v1.OrderService.AmendOrder:input_type -> oms.v1.AmendOrderRequest
+	8,  // 13: oms.v1.OrderService.GetOrder:input_type -> oms.v1.GetOrderRequest
+	10, // 14: oms.v1.OrderService.ListOrders:input_type -> oms.v1.ListOrdersRequest
+	3,  // 15: oms.v1.OrderService.PlaceOrder:output_type -> oms.v1.PlaceOrderResponse
+	5,  // 16: oms.v1.OrderService.CancelOrder:output_type -> oms.v1.CancelOrderResponse
+	7,  // 17: oms.v1.OrderService.AmendOrder:output_type -> oms.v1.AmendOrderResponse
+	9,  // 18: oms.v1.OrderService.GetOrder:output_type -> oms.v1.GetOrderResponse
+	11, // 19: oms.v1.OrderService.ListOrders:output_type -> oms.v1.ListOrdersResponse
+	15, // [15:20] is the sub-list for method output_type
+	10, // [10:15] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
+}
+
+func init() { file_proto_orders_proto_init() }
+func file_proto_orders_proto_init() {
+	if File_proto_orders_proto != nil {
+		return
+	}
+	file_proto_orders_proto_msgTypes[0].OneofWrappers = []any{}
+	file_proto_orders_proto_msgTypes[2].OneofWrappers = []any{}
+	file_proto_orders_proto_msgTypes[6].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_orders_proto_rawDesc), len(file_proto_orders_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   12,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_proto_orders_proto_goTypes,
+		DependencyIndexes: file_proto_orders_proto_depIdxs,
+		MessageInfos:      file_proto_orders_proto_msgTypes,
+	}.Build()
+	File_proto_orders_proto = out.File
+	file_proto_orders_proto_goTypes = nil
+	file_proto_orders_proto_depIdxs = nil
+}
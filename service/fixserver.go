@@ -0,0 +1,47 @@
+package service
+
+import (
+	"net"
+
+	"github.com/bartick/golang-order-matching-system/engine"
+	"github.com/bartick/golang-order-matching-system/fix"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type FIXServer struct {
+	Addr     string
+	acceptor *fix.Acceptor
+	listener net.Listener
+}
+
+// NewFIXServer serves the FIX gateway on addr to the counterparties, each
+// trading for the account its CompID maps to.
+func NewFIXServer(addr, senderCompID string, counterparties map[string]uuid.UUID, db *sqlx.DB, eng *engine.Engine) *FIXServer {
+	return &FIXServer{
+		Addr:     addr,
+		acceptor: fix.NewAcceptor(senderCompID, counterparties, eng, db),
+	}
+}
+
+func (fs *FIXServer) Start() {
+	lis, err := net.Listen("tcp", fs.Addr)
+	if err != nil {
+		panic("listen: " + err.Error())
+	}
+	fs.listener = lis
+
+	go fs.Serve(lis)
+}
+
+// Serve accepts FIX connections on lis until the server is shut down.
+func (fs *FIXServer) Serve(lis net.Listener) error {
+	return fs.acceptor.Serve(lis)
+}
+
+func (fs *FIXServer) Shutdown() {
+	fs.acceptor.Close()
+	if fs.listener != nil {
+		fs.listener.Close()
+	}
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"crypto/tls"
+	"net"
+
+	"github.com/bartick/golang-order-matching-system/api"
+	"github.com/bartick/golang-order-matching-system/engine"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+type GRPCServer struct {
+	Addr string
+	srv  *grpc.Server
+}
+
+// NewGRPCServer serves the gRPC API on addr, over TLS when tlsConfig is not
+// nil. Calls authenticate with the API key in their x-api-key metadata,
+// unknown keys being limited by rateLimiter like those sent over HTTP.
+func NewGRPCServer(addr string, tlsConfig *tls.Config, eng *engine.Engine, rateLimiter *api.RateLimiter) *GRPCServer {
+	options := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(api.UnaryAuthInterceptor(eng, rateLimiter)),
+		grpc.ChainStreamInterceptor(api.StreamAuthInterceptor(eng, rateLimiter)),
+	}
+	if tlsConfig != nil {
+		options = append(options, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	srv := grpc.NewServer(options...)
+	api.RegisterOrderService(srv, eng)
+	api.RegisterMarketDataService(srv, eng)
+
+	return &GRPCServer{
+		Addr: addr,
+		srv:  srv,
+	}
+}
+
+func (gs *GRPCServer) Start() {
+	lis, err := net.Listen("tcp", gs.Addr)
+	if err != nil {
+		panic("listen: " + err.Error())
+	}
+
+	go gs.Serve(lis)
+}
+
+// Serve accepts connections on lis until the server is shut down. Start
+// uses a TCP listener; tests can pass an in-process bufconn listener.
+func (gs *GRPCServer) Serve(lis net.Listener) error {
+	return gs.srv.Serve(lis)
+}
+
+func (gs *GRPCServer) Shutdown() {
+	gs.srv.GracefulStop()
+}
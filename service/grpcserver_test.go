@@ -0,0 +1,326 @@
+package service
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/bartick/golang-order-matching-system/api"
+	"github.com/bartick/golang-order-matching-system/db/dbtest"
+	"github.com/bartick/golang-order-matching-system/engine"
+	"github.com/bartick/golang-order-matching-system/internals"
+	"github.com/bartick/golang-order-matching-system/proto/omspb"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	aliceKey = "alice-api-key"
+	bobKey   = "bob-api-key"
+)
+
+type grpcTest struct {
+	eng        *engine.Engine
+	limiter    *api.RateLimiter
+	orders     omspb.OrderServiceClient
+	marketData omspb.MarketDataServiceClient
+}
+
+// newGRPCTest serves the gRPC API of a fresh engine over an in-process
+// listener, with the accounts of aliceKey and bobKey.
+func newGRPCTest(t *testing.T) *grpcTest {
+	db := dbtest.Open(t)
+	dbtest.CreateAccount(t, db, "alice", aliceKey)
+	dbtest.CreateAccount(t, db, "bob", bobKey)
+
+	eng := engine.NewEngine(db)
+	if err := eng.LoadOrderLimits(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := eng.LoadBooks(); err != nil {
+		t.Fatal(err)
+	}
+
+	limiter := api.NewRateLimiter(internals.Defaults().RateLimits)
+	lis := bufconn.Listen(1 << 20)
+	srv := NewGRPCServer("bufconn", nil, eng, limiter)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Shutdown)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return &grpcTest{
+		eng:        eng,
+		limiter:    limiter,
+		orders:     omspb.NewOrderServiceClient(conn),
+		marketData: omspb.NewMarketDataServiceClient(conn),
+	}
+}
+
+func withKey(apiKey string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "x-api-key", apiKey)
+}
+
+func (g *grpcTest) place(t *testing.T, apiKey string, req *omspb.PlaceOrderRequest) *omspb.PlaceOrderResponse {
+	t.Helper()
+	resp, err := g.orders.PlaceOrder(withKey(apiKey), req)
+	if err != nil {
+		t.Fatalf("place order: %v", err)
+	}
+	return resp
+}
+
+func limitOrder(side string, price, quantity float64) *omspb.PlaceOrderRequest {
+	return &omspb.PlaceOrderRequest{Symbol: "AAPL", Side: side, Type: "limit", Price: proto.Float64(price), Quantity: quantity}
+}
+
+func assertCode(t *testing.T, err error, want codes.Code) {
+	t.Helper()
+	if got := status.Code(err); got != want {
+		t.Fatalf("got code %s (%v), want %s", got, err, want)
+	}
+}
+
+func TestGRPCPlaceOrder(t *testing.T) {
+	g := newGRPCTest(t)
+
+	resting := g.place(t, aliceKey, limitOrder("buy", 150, 10))
+	if resting.Order.Status != "open" || resting.Order.RemainingQuantity != 10 || len(resting.Trades) != 0 {
+		t.Fatalf("resting order: %+v, trades %v", resting.Order, resting.Trades)
+	}
+
+	taker := g.place(t, bobKey, limitOrder("sell", 149, 4))
+	if taker.Order.Status != "filled" || len(taker.Trades) != 1 {
+		t.Fatalf("taker order: %+v, trades %v", taker.Order, taker.Trades)
+	}
+	trade := taker.Trades[0]
+	if trade.Price != 150 || trade.Quantity != 4 || trade.BuyOrderId != resting.Order.Id || trade.SellOrderId != taker.Order.Id {
+		t.Fatalf("trade: %+v", trade)
+	}
+
+	// The order belongs to the account of the API key
+	order, err := g.eng.GetOrder(uuid.MustParse(resting.Order.Id), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.AccountID == nil || order.RemainingQuantity != 6 {
+		t.Fatalf("stored order: %+v", order)
+	}
+
+	got, err := g.orders.GetOrder(withKey(aliceKey), &omspb.GetOrderRequest{OrderId: resting.Order.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Order.Status != "partially_filled" {
+		t.Fatalf("status %q, want partially_filled", got.Order.Status)
+	}
+}
+
+func TestGRPCReadOrdersOfTheAccount(t *testing.T) {
+	g := newGRPCTest(t)
+	alices := g.place(t, aliceKey, limitOrder("buy", 150, 10))
+	bobs := g.place(t, bobKey, limitOrder("buy", 149, 10))
+	req := &omspb.GetOrderRequest{OrderId: alices.Order.Id}
+
+	_, err := g.orders.GetOrder(context.Background(), req)
+	assertCode(t, err, codes.Unauthenticated)
+
+	_, err = g.orders.GetOrder(withKey(bobKey), req)
+	assertCode(t, err, codes.NotFound)
+
+	_, err = g.orders.ListOrders(context.Background(), &omspb.ListOrdersRequest{})
+	assertCode(t, err, codes.Unauthenticated)
+
+	listed, err := g.orders.ListOrders(withKey(bobKey), &omspb.ListOrdersRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listed.Orders) != 1 || listed.Orders[0].Id != bobs.Order.Id {
+		t.Fatalf("listed %v, want only %s", listed.Orders, bobs.Order.Id)
+	}
+}
+
+func TestGRPCPlaceOrderRejected(t *testing.T) {
+	g := newGRPCTest(t)
+
+	_, err := g.orders.PlaceOrder(withKey(aliceKey), limitOrder("sideways", 150, 10))
+	assertCode(t, err, codes.InvalidArgument)
+
+	_, err = g.orders.PlaceOrder(withKey("unknown-key"), limitOrder("buy", 150, 10))
+	assertCode(t, err, codes.Unauthenticated)
+
+	// Nobody could cancel an order without an account
+	_, err = g.orders.PlaceOrder(context.Background(), limitOrder("buy", 150, 10))
+	assertCode(t, err, codes.Unauthenticated)
+}
+
+func TestGRPCCancelOrder(t *testing.T) {
+	g := newGRPCTest(t)
+	placed := g.place(t, aliceKey, limitOrder("buy", 150, 10))
+	req := &omspb.CancelOrderRequest{OrderId: placed.Order.Id}
+
+	_, err := g.orders.CancelOrder(context.Background(), req)
+	assertCode(t, err, codes.Unauthenticated)
+
+	// Other accounts cannot tell the order exists
+	_, err = g.orders.CancelOrder(withKey(bobKey), req)
+	assertCode(t, err, codes.NotFound)
+
+	canceled, err := g.orders.CancelOrder(withKey(aliceKey), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if canceled.Order.Status != "canceled" {
+		t.Fatalf("status %q, want canceled", canceled.Order.Status)
+	}
+
+	_, err = g.orders.CancelOrder(withKey(aliceKey), req)
+	assertCode(t, err, codes.FailedPrecondition)
+
+	_, err = g.orders.CancelOrder(withKey(aliceKey), &omspb.CancelOrderRequest{OrderId: "not-a-uuid"})
+	assertCode(t, err, codes.InvalidArgument)
+}
+
+func TestGRPCAmendOrder(t *testing.T) {
+	g := newGRPCTest(t)
+	placed := g.place(t, aliceKey, limitOrder("buy", 150, 10))
+	req := &omspb.AmendOrderRequest{
+		OrderId:       placed.Order.Id,
+		Price:         proto.Float64(151),
+		Quantity:      proto.Float64(8),
+		ClientOrderId: proto.String("alice-1"),
+	}
+
+	_, err := g.orders.AmendOrder(context.Background(), req)
+	assertCode(t, err, codes.Unauthenticated)
+
+	_, err = g.orders.AmendOrder(withKey(bobKey), req)
+	assertCode(t, err, codes.NotFound)
+
+	amended, err := g.orders.AmendOrder(withKey(aliceKey), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amended.Order.GetPrice() != 151 || amended.Order.RemainingQuantity != 8 {
+		t.Fatalf("amended order: %+v", amended.Order)
+	}
+	order, err := g.eng.GetOrder(uuid.MustParse(placed.Order.Id), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.ClientOrderID == nil || *order.ClientOrderID != "alice-1" {
+		t.Fatalf("client order ID %v, want alice-1", order.ClientOrderID)
+	}
+
+	// A crossing price trades straight away
+	g.place(t, bobKey, limitOrder("sell", 155, 5))
+	crossed, err := g.orders.AmendOrder(withKey(aliceKey), &omspb.AmendOrderRequest{OrderId: placed.Order.Id, Price: proto.Float64(155)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(crossed.Trades) != 1 || crossed.Trades[0].Quantity != 5 || crossed.Order.RemainingQuantity != 3 {
+		t.Fatalf("crossing amend: %+v, trades %v", crossed.Order, crossed.Trades)
+	}
+}
+
+func TestGRPCStreamTrades(t *testing.T) {
+	g := newGRPCTest(t)
+	ctx, cancel := context.WithTimeout(withKey(aliceKey), 10*time.Second)
+	defer cancel()
+
+	stream, err := g.marketData.StreamTrades(ctx, &omspb.StreamTradesRequest{Symbol: "aapl"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The trades published before the server subscribed are not streamed
+	for g.eng.MarketData().SubscriberCounts()["AAPL"] == 0 {
+		if ctx.Err() != nil {
+			t.Fatal("the stream never subscribed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	g.place(t, aliceKey, limitOrder("buy", 150, 10))
+	taker := g.place(t, bobKey, limitOrder("sell", 150, 10))
+
+	trade, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trade.Id != taker.Trades[0].Id || trade.Symbol != "AAPL" || trade.Quantity != 10 {
+		t.Fatalf("streamed trade: %+v", trade)
+	}
+}
+
+func TestGRPCStreamOrderBook(t *testing.T) {
+	g := newGRPCTest(t)
+	g.place(t, aliceKey, limitOrder("buy", 150, 10))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	stream, err := g.marketData.StreamOrderBook(ctx, &omspb.StreamOrderBookRequest{Symbol: "AAPL"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The current book comes first
+	book, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(book.Bids) != 1 || book.Bids[0].Price != 150 || book.Bids[0].TotalQuantity != 10 || len(book.Asks) != 0 {
+		t.Fatalf("snapshot: %+v", book)
+	}
+
+	g.place(t, bobKey, limitOrder("sell", 151, 3))
+	book, err = stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(book.Asks) != 1 || book.Asks[0].Price != 151 || book.Asks[0].TotalQuantity != 3 {
+		t.Fatalf("update: %+v", book)
+	}
+}
+
+func TestGRPCStreamRejectsUnknownKey(t *testing.T) {
+	g := newGRPCTest(t)
+	ctx, cancel := context.WithTimeout(withKey("unknown-key"), 10*time.Second)
+	defer cancel()
+
+	stream, err := g.marketData.StreamTrades(ctx, &omspb.StreamTradesRequest{Symbol: "AAPL"})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	assertCode(t, err, codes.Unauthenticated)
+}
+
+func TestGRPCThrottlesUnknownKeys(t *testing.T) {
+	g := newGRPCTest(t)
+	limits := internals.Defaults().RateLimits
+	limits.Enabled = true
+	limits.Authentication = internals.BucketConfig{Rate: 0.001, Burst: 1}
+	g.limiter.SetConfig(limits)
+
+	_, err := g.orders.PlaceOrder(withKey("unknown-key"), limitOrder("buy", 150, 10))
+	assertCode(t, err, codes.Unauthenticated)
+
+	// Even a valid key is refused once the address used up its attempts
+	_, err = g.orders.PlaceOrder(withKey(aliceKey), limitOrder("buy", 150, 10))
+	assertCode(t, err, codes.ResourceExhausted)
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/bartick/golang-order-matching-system/engine"
+	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const metricsNamespace = "oms"
+
+// Metrics exports the measurements of the engine to Prometheus, together
+// with the resting orders of every book, the database connection pool and
+// the market data subscribers, which are read when scraped.
+type Metrics struct {
+	registry           *prometheus.Registry
+	orders             *prometheus.CounterVec
+	placeOrderDuration *prometheus.HistogramVec
+	trades             *prometheus.CounterVec
+	tradeVolume        *prometheus.CounterVec
+	tradeNotional      *prometheus.CounterVec
+}
+
+func NewMetrics(db *sqlx.DB, eng *engine.Engine) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		orders: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "orders_total",
+			Help:      "Order entry attempts by symbol, side, type and result (accepted, rejected or error).",
+		}, []string{"symbol", "side", "type", "result"}),
+		placeOrderDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "place_order_duration_seconds",
+			Help:      "Duration of the transactions that store and match a single order.",
+			Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 12),
+		}, []string{"symbol"}),
+		trades: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "trades_total",
+			Help:      "Trades executed by symbol.",
+		}, []string{"symbol"}),
+		tradeVolume: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "trade_volume_total",
+			Help:      "Quantity traded by symbol.",
+		}, []string{"symbol"}),
+		tradeNotional: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "trade_notional_total",
+			Help:      "Price times quantity traded by symbol, in its quote asset.",
+		}, []string{"symbol"}),
+	}
+
+	m.registry.MustRegister(
+		m.orders, m.placeOrderDuration, m.trades, m.tradeVolume, m.tradeNotional,
+		&bookCollector{db: db},
+		&subscriberCollector{marketData: eng.MarketData()},
+		collectors.NewDBStatsCollector(db.DB, metricsNamespace),
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+func (m *Metrics) OrderPlaced(symbol, side, orderType, result string) {
+	m.orders.WithLabelValues(symbol, side, orderType, result).Inc()
+}
+
+func (m *Metrics) PlaceOrderObserved(symbol string, duration time.Duration) {
+	m.placeOrderDuration.WithLabelValues(symbol).Observe(duration.Seconds())
+}
+
+func (m *Metrics) TradeExecuted(symbol string, quantity, notional float64) {
+	m.trades.WithLabelValues(symbol).Inc()
+	m.tradeVolume.WithLabelValues(symbol).Add(quantity)
+	m.tradeNotional.WithLabelValues(symbol).Add(notional)
+}
+
+var (
+	restingOrdersDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "", "resting_orders"),
+		"Orders resting in the book by symbol and side.",
+		[]string{"symbol", "side"}, nil)
+	restingQuantityDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "", "resting_quantity"),
+		"Remaining quantity resting in the book by symbol and side.",
+		[]string{"symbol", "side"}, nil)
+	subscribersDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "", "market_data_subscribers"),
+		"Market data stream subscribers by symbol; \"*\" counts those of every symbol.",
+		[]string{"symbol"}, nil)
+)
+
+// bookCollector reads the resting orders of every book when scraped.
+type bookCollector struct {
+	db *sqlx.DB
+}
+
+func (c *bookCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- restingOrdersDesc
+	ch <- restingQuantityDesc
+}
+
+func (c *bookCollector) Collect(ch chan<- prometheus.Metric) {
+	rows, err := c.db.Query(`SELECT symbol, side, COUNT(*), SUM(remaining_quantity) FROM orders
+		WHERE status IN ('open', 'partially_filled') GROUP BY symbol, side`)
+	if err != nil {
+		slog.Error("failed to collect resting orders", "error", err)
+		ch <- prometheus.NewInvalidMetric(restingOrdersDesc, err)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var symbol, side string
+		var count, quantity float64
+		if err := rows.Scan(&symbol, &side, &count, &quantity); err != nil {
+			ch <- prometheus.NewInvalidMetric(restingOrdersDesc, err)
+			return
+		}
+		ch <- prometheus.MustNewConstMetric(restingOrdersDesc, prometheus.GaugeValue, count, symbol, side)
+		ch <- prometheus.MustNewConstMetric(restingQuantityDesc, prometheus.GaugeValue, quantity, symbol, side)
+	}
+}
+
+// subscriberCollector counts the market data subscribers when scraped.
+type subscriberCollector struct {
+	marketData *engine.MarketData
+}
+
+func (c *subscriberCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- subscribersDesc
+}
+
+func (c *subscriberCollector) Collect(ch chan<- prometheus.Metric) {
+	for symbol, count := range c.marketData.SubscriberCounts() {
+		if symbol == "" {
+			symbol = "*"
+		}
+		ch <- prometheus.MustNewConstMetric(subscribersDesc, prometheus.GaugeValue, float64(count), symbol)
+	}
+}
@@ -1,54 +1,105 @@
 package service
 
 import (
+	"context"
+	"crypto/tls"
 	"net/http"
+	"strconv"
 
 	"github.com/bartick/golang-order-matching-system/api"
+	"github.com/bartick/golang-order-matching-system/engine"
+	"github.com/bartick/golang-order-matching-system/internals"
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
 )
 
 type WebServer struct {
 	Addr         string
+	config       internals.HTTPConfig
+	tlsConfig    *tls.Config
 	router       *gin.Engine
 	srv          *http.Server
 	dbConnection *sqlx.DB
+	engine       *engine.Engine
+	metrics      *Metrics
+	health       *api.Health
+	rateLimiter  *api.RateLimiter
 }
 
 type WebServerInterface interface {
 	Start() error
 }
 
-func NewWebServer(addr string, db *sqlx.DB) *WebServer {
+// NewWebServer serves the HTTP API on the configured port, over TLS when
+// tlsConfig is not nil.
+func NewWebServer(config internals.HTTPConfig, tlsConfig *tls.Config, db *sqlx.DB, eng *engine.Engine, metrics *Metrics, health *api.Health, rateLimiter *api.RateLimiter) *WebServer {
 	return &WebServer{
-		Addr:         addr,
-		router:       gin.Default(),
+		Addr:         ":" + strconv.Itoa(config.Port),
+		config:       config,
+		tlsConfig:    tlsConfig,
+		router:       gin.New(),
 		dbConnection: db,
+		engine:       eng,
+		metrics:      metrics,
+		health:       health,
+		rateLimiter:  rateLimiter,
 	}
 }
 
 func (ws *WebServer) Start() {
 
+	// The configuration is validated, so the proxies always parse
+	if err := ws.router.SetTrustedProxies(ws.config.TrustedProxies); err != nil {
+		panic("trusted proxies: " + err.Error())
+	}
+
+	ws.router.Use(api.RequestIDMiddleware(), api.TracingMiddleware(), api.RecoveryMiddleware())
+	ws.router.Use(api.AccountMiddleware(ws.engine, ws.rateLimiter), api.RateLimitMiddleware(ws.rateLimiter))
+
 	api.AddPingRoute(ws.router)
-	api.AddOrderRoute(ws.router, ws.dbConnection)
-	api.AddOrderBookRoute(ws.router, ws.dbConnection)
+	api.AddHealthRoute(ws.router, ws.health)
+	ws.router.GET("/metrics", gin.WrapH(ws.metrics.Handler()))
+	api.AddOrderRoute(ws.router, ws.engine)
+	api.AddOrderBookRoute(ws.router, ws.engine)
+	api.AddCancelAllAfterRoute(ws.router, ws.engine)
+	api.AddAuctionRoute(ws.router, ws.engine)
+	api.AddInstrumentRoute(ws.router, ws.engine)
+	api.AddBalanceRoute(ws.router, ws.engine)
+	api.AddPositionRoute(ws.router, ws.engine)
+	api.AddMarginRoute(ws.router, ws.engine)
 	api.AddTradeRoute(ws.router, ws.dbConnection)
+	api.AddAdminRoute(ws.router, ws.engine)
 
 	ws.srv = &http.Server{
-		Addr:    ws.Addr,
-		Handler: ws.router.Handler(),
+		Addr:              ws.Addr,
+		Handler:           ws.router.Handler(),
+		TLSConfig:         ws.tlsConfig,
+		ReadTimeout:       ws.config.ReadTimeout,
+		ReadHeaderTimeout: ws.config.ReadHeaderTimeout,
+		WriteTimeout:      ws.config.WriteTimeout,
+		IdleTimeout:       ws.config.IdleTimeout,
 	}
 
 	go func() {
-		if err := ws.srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if ws.tlsConfig != nil {
+			err = ws.srv.ListenAndServeTLS("", "")
+		} else {
+			err = ws.srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			panic("listen: " + err.Error())
 		}
 	}()
 }
 
+// Shutdown stops accepting requests and waits for those in flight, for at
+// most the configured shutdown timeout.
 func (ws *WebServer) Shutdown() error {
 	if ws.srv != nil {
-		return ws.srv.Shutdown(nil)
+		ctx, cancel := context.WithTimeout(context.Background(), ws.config.ShutdownTimeout)
+		defer cancel()
+		return ws.srv.Shutdown(ctx)
 	}
 	return nil
 }